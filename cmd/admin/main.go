@@ -0,0 +1,713 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/mateus/familia-steam/internal/config"
+	"github.com/mateus/familia-steam/internal/db"
+	"github.com/mateus/familia-steam/internal/mercadopago"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+const usage = `Uso: admin <comando> [opções]
+
+Comandos:
+  users                               Lista usuários e saldos
+  tx      -id N | -ref REF            Mostra uma transação
+  resync  -id N | -ref REF            Ressincroniza uma transação com o Mercado Pago
+  approve -id N | -ref REF            Aprova um PIX aguardando aprovação (usuário sinalizado)
+  hold    -user ID -reason | -release Sinaliza um usuário por contestação ou tira a sinalização
+  credit  -user ID -amount V -reason  Credita manualmente a carteira de um usuário
+  debit   -user ID -amount V -reason  Debita manualmente a carteira de um usuário
+  merge   -from ID -into ID           Junta um usuário duplicado a outro
+  purchase -title NOME -amount V      Registra uma compra e divide entre os membros
+  purchases [-id N]                   Lista compras ou mostra a divisão de uma compra
+  export  [-from DATA] [-to DATA]     Exporta as transações do período (também csv e ofx)
+  audit   [-tx N] [-actor-filter ID]  Lista eventos de auditoria
+  outbox  [-status S] [-retry N]      Lista eventos do outbox travados ou reenfileira um
+
+Todos os comandos aceitam -format table|json (padrão: table) e -actor, que
+identifica o operador na auditoria (padrão: cli:$USER).
+Use "admin <comando> -h" para ver as opções de cada comando.
+`
+
+type admin struct {
+	cfg             *config.Config
+	database        *sql.DB
+	userRepo        *repository.UserRepository
+	walletRepo      *repository.WalletRepository
+	txRepo          *repository.TransactionRepository
+	walletService   *service.WalletService
+	paymentService  *service.PaymentService
+	auditService    *service.AuditService
+	reportService   *service.ReportService
+	purchaseService *service.PurchaseService
+	outboxService   *service.OutboxService
+	format          string
+	actor           string
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]func(*admin, []string) error{
+		"users":   (*admin).users,
+		"tx":      (*admin).transaction,
+		"resync":  (*admin).resync,
+		"approve": (*admin).approve,
+		"hold":    (*admin).hold,
+		"credit":  func(a *admin, args []string) error { return a.adjust("credit", 1, args) },
+		"debit":   func(a *admin, args []string) error { return a.adjust("debit", -1, args) },
+		"merge":   (*admin).merge,
+		"export":  (*admin).export,
+		"audit":   (*admin).audit,
+
+		"purchase":  (*admin).purchase,
+		"purchases": (*admin).purchases,
+		"outbox":    (*admin).outbox,
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadAdmin()
+	if err != nil {
+		fatal(err)
+	}
+
+	database, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		fatal(err)
+	}
+	defer database.Close()
+
+	a := newAdmin(cfg, database)
+	if err := command(a, os.Args[2:]); err != nil {
+		database.Close()
+		fatal(err)
+	}
+}
+
+func newAdmin(cfg *config.Config, database *sql.DB) *admin {
+	userRepo := repository.NewUserRepository(database)
+	walletRepo := repository.NewWalletRepository(database)
+	txRepo := repository.NewTransactionRepository(database)
+	purchaseRepo := repository.NewPurchaseRepository(database)
+
+	// Com PURCHASE_SPLIT_MODE inválido o padrão fica vazio e o comando
+	// purchase só funciona informando -split
+	splitMode, _ := service.ParseSplitMode(cfg.PurchaseSplitMode)
+
+	a := &admin{
+		cfg:           cfg,
+		database:      database,
+		userRepo:      userRepo,
+		walletRepo:    walletRepo,
+		txRepo:        txRepo,
+		walletService: service.NewWalletService(userRepo, walletRepo, txRepo, purchaseRepo),
+		auditService:  service.NewAuditService(repository.NewAuditRepository(database)),
+		reportService: service.NewReportService(txRepo),
+
+		purchaseService: service.NewPurchaseService(purchaseRepo, splitMode),
+		outboxService:   service.NewOutboxService(repository.NewOutboxRepository(database)),
+	}
+
+	if cfg.MercadoPagoToken != "" {
+		mpClient := mercadopago.NewClient(cfg.MercadoPagoToken, cfg.MercadoPagoOptions())
+		a.paymentService = service.NewPaymentService(mpClient, txRepo, userRepo, walletRepo, cfg.PaymentLimits())
+	}
+
+	return a
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+	os.Exit(1)
+}
+
+// flags cria o FlagSet do subcomando já com as opções -format e -actor
+func (a *admin) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&a.format, "format", "table", "formato de saída: table ou json")
+	fs.StringVar(&a.actor, "actor", defaultActor(), "quem executa a operação (registrado na auditoria)")
+	return fs
+}
+
+func defaultActor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return "cli:" + user
+	}
+	return "cli"
+}
+
+func (a *admin) auditMeta(reason string) repository.AuditMeta {
+	return repository.AuditMeta{
+		Actor:  a.actor,
+		Source: repository.SourceAdmin,
+		Reason: reason,
+	}
+}
+
+func (a *admin) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if a.format != "table" && a.format != "json" {
+		return fmt.Errorf("formato inválido: %s (use table ou json)", a.format)
+	}
+	return nil
+}
+
+func (a *admin) users(args []string) error {
+	fs := a.flags("users")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	balances, err := a.walletService.ListBalances()
+	if err != nil {
+		return err
+	}
+
+	out := newOutput(a.format, "ID", "DISCORD ID", "USUÁRIO", "SALDO", "PENDENTE", "GASTO", "DISPONÍVEL")
+	for _, b := range balances {
+		out.row(userView{
+			ID:        b.UserID,
+			DiscordID: b.DiscordID,
+			Username:  b.Username,
+			Balance:   b.Balance,
+			Pending:   b.Pending,
+			Spent:     b.Spent,
+			Available: b.Balance - b.Spent,
+		}, b.UserID, b.DiscordID, b.Username, money(b.Balance), money(b.Pending), money(b.Spent), money(b.Balance-b.Spent))
+	}
+	return out.flush()
+}
+
+// findTransaction busca pela flag -id ou -ref, exatamente uma delas
+func (a *admin) findTransaction(id int64, ref string) (*repository.Transaction, error) {
+	var (
+		transaction *repository.Transaction
+		err         error
+	)
+
+	switch {
+	case id != 0 && ref != "":
+		return nil, errors.New("informe -id ou -ref, não ambos")
+	case id != 0:
+		transaction, err = a.txRepo.FindByID(id)
+	case ref != "":
+		transaction, err = a.txRepo.FindByExternalReference(ref)
+	default:
+		return nil, errors.New("informe -id ou -ref")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if transaction == nil {
+		return nil, errors.New("transação não encontrada")
+	}
+	return transaction, nil
+}
+
+func (a *admin) transaction(args []string) error {
+	fs := a.flags("tx")
+	id := fs.Int64("id", 0, "ID da transação")
+	ref := fs.String("ref", "", "referência externa (ID do pagamento no Mercado Pago)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	transaction, err := a.findTransaction(*id, *ref)
+	if err != nil {
+		return err
+	}
+
+	return a.printTransaction(transaction)
+}
+
+func (a *admin) printTransaction(transaction *repository.Transaction) error {
+	view := transactionView{
+		ID:                transaction.ID,
+		Type:              string(transaction.Type),
+		Status:            string(transaction.Status),
+		Amount:            transaction.Amount,
+		Description:       transaction.Description,
+		ExternalReference: transaction.ExternalReference,
+		IdempotencyKey:    transaction.IdempotencyKey,
+		PaymentData:       transaction.PaymentData,
+		CreatedAt:         transaction.CreatedAt,
+		ConfirmedAt:       transaction.ConfirmedAt,
+	}
+
+	wallet, err := a.walletRepo.FindByID(transaction.WalletID)
+	if err != nil {
+		return err
+	}
+	if wallet != nil {
+		user, err := a.userRepo.FindByID(wallet.UserID)
+		if err != nil {
+			return err
+		}
+		if user != nil {
+			view.DiscordID = user.DiscordID
+			view.Username = user.Username
+		}
+	}
+
+	if a.format == "json" {
+		return printJSON(view)
+	}
+
+	confirmedAt := "-"
+	if view.ConfirmedAt != nil {
+		confirmedAt = formatTime(*view.ConfirmedAt)
+	}
+
+	out := newOutput(a.format, "CAMPO", "VALOR")
+	out.row(nil, "ID", view.ID)
+	out.row(nil, "Usuário", fmt.Sprintf("%s (%s)", view.Username, view.DiscordID))
+	out.row(nil, "Tipo", view.Type)
+	out.row(nil, "Status", view.Status)
+	out.row(nil, "Valor", money(view.Amount))
+	out.row(nil, "Descrição", orDash(view.Description))
+	out.row(nil, "Referência externa", orDash(view.ExternalReference))
+	out.row(nil, "Chave de idempotência", orDash(view.IdempotencyKey))
+	out.row(nil, "Criada em", formatTime(view.CreatedAt))
+	out.row(nil, "Confirmada em", confirmedAt)
+	if status, ok := view.PaymentData["status"].(string); ok {
+		out.row(nil, "Status no Mercado Pago", status)
+	}
+	return out.flush()
+}
+
+func (a *admin) resync(args []string) error {
+	fs := a.flags("resync")
+	id := fs.Int64("id", 0, "ID da transação")
+	ref := fs.String("ref", "", "referência externa (ID do pagamento no Mercado Pago)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	if a.paymentService == nil {
+		return errors.New("MERCADOPAGO_ACCESS_TOKEN é obrigatória para ressincronizar")
+	}
+
+	transaction, err := a.findTransaction(*id, *ref)
+	if err != nil {
+		return err
+	}
+	if transaction.ExternalReference == "" {
+		return fmt.Errorf("transação %d não tem pagamento no Mercado Pago", transaction.ID)
+	}
+
+	updated, err := a.paymentService.SyncPayment(transaction.ExternalReference, a.auditMeta("ressincronização manual"))
+	if err != nil {
+		return err
+	}
+
+	if a.format == "table" {
+		fmt.Printf("Status: %s → %s\n\n", transaction.Status, updated.Status)
+	}
+	return a.printTransaction(updated)
+}
+
+func (a *admin) approve(args []string) error {
+	fs := a.flags("approve")
+	id := fs.Int64("id", 0, "ID da transação")
+	ref := fs.String("ref", "", "referência externa (ID do pagamento no Mercado Pago)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	transaction, err := a.findTransaction(*id, *ref)
+	if err != nil {
+		return err
+	}
+	if transaction.Status != repository.StatusAwaitingApproval {
+		return fmt.Errorf("transação %d está %s, não aguarda aprovação", transaction.ID, transaction.Status)
+	}
+
+	if err := a.txRepo.UpdateStatus(transaction.ID, repository.StatusConfirmed, a.auditMeta("pagamento aprovado")); err != nil {
+		return err
+	}
+
+	updated, err := a.txRepo.FindByID(transaction.ID)
+	if err != nil {
+		return err
+	}
+	return a.printTransaction(updated)
+}
+
+func (a *admin) hold(args []string) error {
+	fs := a.flags("hold")
+	discordID := fs.String("user", "", "Discord ID do usuário")
+	reason := fs.String("reason", "", "motivo da sinalização")
+	release := fs.Bool("release", false, "tira a sinalização (contestação resolvida)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *discordID == "" {
+		return errors.New("informe -user")
+	}
+	if !*release && *reason == "" {
+		return errors.New("informe -reason ou -release")
+	}
+
+	user, err := a.userRepo.FindByDiscordID(*discordID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("usuário não encontrado: %s", *discordID)
+	}
+
+	var changed bool
+	if *release {
+		changed, err = a.userRepo.ReleasePaymentHold(user.ID, a.auditMeta("contestação resolvida"))
+	} else {
+		changed, err = a.userRepo.SetPaymentHold(user.ID, *reason, a.auditMeta(*reason))
+	}
+	if err != nil {
+		return err
+	}
+
+	if a.format == "json" {
+		return printJSON(map[string]interface{}{"discord_id": user.DiscordID, "payment_hold": !*release, "changed": changed})
+	}
+	switch {
+	case *release && changed:
+		fmt.Printf("Sinalização de %s retirada. Novos pagamentos entram direto no saldo.\n", user.Username)
+	case *release:
+		fmt.Printf("%s não estava sinalizado.\n", user.Username)
+	case changed:
+		fmt.Printf("%s sinalizado. Novos pagamentos vão aguardar aprovação.\n", user.Username)
+	default:
+		fmt.Printf("%s já estava sinalizado: %s\n", user.Username, user.PaymentHoldReason)
+	}
+	return nil
+}
+
+func (a *admin) adjust(name string, sign float64, args []string) error {
+	fs := a.flags(name)
+	discordID := fs.String("user", "", "Discord ID do usuário")
+	amount := fs.Float64("amount", 0, "valor (positivo)")
+	reason := fs.String("reason", "", "motivo do ajuste (obrigatório)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *discordID == "" {
+		return errors.New("informe -user")
+	}
+	if *amount <= 0 {
+		return errors.New("-amount deve ser maior que zero")
+	}
+
+	transaction, err := a.walletService.AdjustBalance(*discordID, sign**amount, *reason, a.auditMeta(*reason))
+	if err != nil {
+		return err
+	}
+
+	return a.printTransaction(transaction)
+}
+
+func (a *admin) merge(args []string) error {
+	fs := a.flags("merge")
+	from := fs.String("from", "", "Discord ID do usuário duplicado (será removido)")
+	into := fs.String("into", "", "Discord ID do usuário que permanece")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *from == "" || *into == "" {
+		return errors.New("informe -from e -into")
+	}
+
+	if err := a.walletService.MergeUsers(*from, *into, a.auditMeta("usuário duplicado")); err != nil {
+		return err
+	}
+
+	balance, err := a.walletService.GetUserBalance(*into)
+	if err != nil {
+		return err
+	}
+
+	if a.format == "json" {
+		return printJSON(map[string]interface{}{"merged_into": *into, "balance": balance})
+	}
+	fmt.Printf("Usuário %s mesclado em %s. Saldo atual: %s\n", *from, *into, money(balance))
+	return nil
+}
+
+func (a *admin) purchase(args []string) error {
+	fs := a.flags("purchase")
+	title := fs.String("title", "", "nome do jogo/compra (obrigatório)")
+	amount := fs.Float64("amount", 0, "valor pago")
+	split := fs.String("split", "", "divisão: pro_rata ou equal (padrão: PURCHASE_SPLIT_MODE)")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	var mode repository.SplitMode
+	if *split != "" {
+		var err error
+		if mode, err = service.ParseSplitMode(*split); err != nil {
+			return err
+		}
+	}
+
+	purchase, err := a.purchaseService.RecordPurchase(*title, *amount, mode, a.auditMeta(*title))
+	if err != nil {
+		return err
+	}
+
+	return a.printPurchase(purchase)
+}
+
+func (a *admin) purchases(args []string) error {
+	fs := a.flags("purchases")
+	id := fs.Int64("id", 0, "mostra a divisão de uma compra")
+	limit := fs.Int("limit", 50, "quantidade máxima de compras")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *id != 0 {
+		purchase, err := a.purchaseService.Get(*id)
+		if err != nil {
+			return err
+		}
+		return a.printPurchase(purchase)
+	}
+
+	purchases, err := a.purchaseService.List(*limit)
+	if err != nil {
+		return err
+	}
+
+	out := newOutput(a.format, "ID", "QUANDO", "COMPRA", "VALOR", "DIVISÃO", "POR")
+	for _, p := range purchases {
+		out.row(newPurchaseView(&p), p.ID, formatTime(p.CreatedAt), p.Title, money(p.Amount), p.SplitMode, p.CreatedBy)
+	}
+	return out.flush()
+}
+
+func newPurchaseView(p *repository.Purchase) purchaseView {
+	return purchaseView{
+		ID:        p.ID,
+		Title:     p.Title,
+		Amount:    p.Amount,
+		SplitMode: string(p.SplitMode),
+		CreatedBy: p.CreatedBy,
+		CreatedAt: p.CreatedAt,
+		Shares:    p.Shares,
+	}
+}
+
+func (a *admin) printPurchase(purchase *repository.Purchase) error {
+	if a.format == "json" {
+		return printJSON(newPurchaseView(purchase))
+	}
+
+	fmt.Printf("Compra #%d: %s - %s (%s, por %s em %s)\n\n",
+		purchase.ID, purchase.Title, money(purchase.Amount), purchase.SplitMode, purchase.CreatedBy, formatTime(purchase.CreatedAt))
+
+	out := newOutput(a.format, "DISCORD ID", "USUÁRIO", "DISPONÍVEL ANTES", "PARTE")
+	for _, share := range purchase.Shares {
+		out.row(nil, share.DiscordID, share.Username, money(share.AvailableBefore), money(share.Amount))
+	}
+	return out.flush()
+}
+
+func (a *admin) export(args []string) error {
+	fs := a.flags("export")
+	fs.Lookup("format").Usage = "formato de saída: table, json, csv ou ofx"
+	fromStr := fs.String("from", "", "data inicial (AAAA-MM-DD, inclusiva)")
+	toStr := fs.String("to", "", "data final (AAAA-MM-DD, inclusiva)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	from, to, err := parsePeriod(*fromStr, *toStr)
+	if err != nil {
+		return err
+	}
+
+	if a.format != "table" {
+		format := service.ReportFormat(a.format)
+		if !format.Valid() {
+			return service.ErrInvalidReportFormat
+		}
+		return a.reportService.Export(os.Stdout, format, from, to)
+	}
+
+	out := newOutput(a.format, "ID", "DISCORD ID", "USUÁRIO", "TIPO", "STATUS", "VALOR", "CRIADA EM", "CONFIRMADA EM", "DESCRIÇÃO")
+	err = a.txRepo.EachReportRow(from, to, func(row repository.ReportRow) error {
+		confirmedAt := "-"
+		if row.ConfirmedAt != nil {
+			confirmedAt = formatTime(*row.ConfirmedAt)
+		}
+		out.row(nil, row.TransactionID, row.DiscordID, row.Username, row.Type, row.Status,
+			money(row.Amount), formatTime(row.CreatedAt), confirmedAt, orDash(row.Description))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.flush()
+}
+
+func (a *admin) audit(args []string) error {
+	fs := a.flags("audit")
+	txID := fs.Int64("tx", 0, "filtra por transação")
+	actor := fs.String("actor-filter", "", "filtra por ator")
+	source := fs.String("source", "", "filtra por origem: webhook, poll, admin, api")
+	limit := fs.Int("limit", 50, "quantidade máxima de eventos")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	filter := repository.AuditFilter{
+		Actor:  *actor,
+		Source: repository.AuditSource(*source),
+		Limit:  *limit,
+	}
+	if *txID != 0 {
+		filter.EntityType = "transaction"
+		filter.EntityID = *txID
+	}
+
+	events, err := a.auditService.List(filter)
+	if err != nil {
+		return err
+	}
+
+	out := newOutput(a.format, "ID", "QUANDO", "AÇÃO", "ATOR", "ORIGEM", "ENTIDADE", "ANTES", "DEPOIS", "MOTIVO")
+	for _, e := range events {
+		out.row(auditView{
+			ID:          e.ID,
+			Action:      e.Action,
+			Actor:       e.Actor,
+			Source:      string(e.Source),
+			EntityType:  e.EntityType,
+			EntityID:    e.EntityID,
+			Before:      e.Before,
+			After:       e.After,
+			PayloadHash: e.PayloadHash,
+			Reason:      e.Reason,
+			CreatedAt:   e.CreatedAt,
+		}, e.ID, formatTime(e.CreatedAt), e.Action, e.Actor, e.Source,
+			fmt.Sprintf("%s#%d", e.EntityType, e.EntityID), orDash(string(e.Before)), orDash(string(e.After)), orDash(e.Reason))
+	}
+	return out.flush()
+}
+
+func (a *admin) outbox(args []string) error {
+	fs := a.flags("outbox")
+	status := fs.String("status", "stuck", "stuck (mortos e pendentes que já falharam), pending, dead ou done")
+	limit := fs.Int("limit", 50, "quantidade máxima de eventos")
+	retry := fs.Int64("retry", 0, "reenfileira o evento com este ID")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *retry != 0 {
+		event, err := a.outboxService.Retry(*retry)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Evento %d reenfileirado (%s)\n", event.ID, event.Topic)
+		return nil
+	}
+
+	filter, err := service.ParseOutboxFilter(*status)
+	if err != nil {
+		return err
+	}
+	filter.Limit = *limit
+
+	events, err := a.outboxService.List(filter)
+	if err != nil {
+		return err
+	}
+
+	out := newOutput(a.format, "ID", "CRIADO", "TÓPICO", "ENTIDADE", "STATUS", "TENTATIVAS", "PRÓXIMA", "ERRO")
+	for _, e := range events {
+		next := "-"
+		if e.Status == repository.OutboxPending {
+			next = formatTime(e.AvailableAt)
+		}
+		out.row(outboxView{
+			ID:          e.ID,
+			Topic:       e.Topic,
+			EntityType:  e.EntityType,
+			EntityID:    e.EntityID,
+			Payload:     e.Payload,
+			Status:      string(e.Status),
+			Attempts:    e.Attempts,
+			LastError:   e.LastError,
+			Handled:     e.Handled,
+			AvailableAt: e.AvailableAt,
+			CreatedAt:   e.CreatedAt,
+			ProcessedAt: e.ProcessedAt,
+		}, e.ID, formatTime(e.CreatedAt), e.Topic, fmt.Sprintf("%s#%d", e.EntityType, e.EntityID),
+			e.Status, e.Attempts, next, orDash(e.LastError))
+	}
+	return out.flush()
+}
+
+// parsePeriod converte datas AAAA-MM-DD; a data final é inclusiva, então o
+// limite superior vira o início do dia seguinte
+func parsePeriod(fromStr, toStr string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+
+	if fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			return from, to, fmt.Errorf("data inicial inválida: %s", fromStr)
+		}
+	}
+	if toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			return from, to, fmt.Errorf("data final inválida: %s", toStr)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return from, to, nil
+}
+
+func money(v float64) string {
+	return "R$ " + strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+type userView struct {
+	ID        int64   `json:"id"`
+	DiscordID string  `json:"discord_id"`
+	Username  string  `json:"username"`
+	Balance   float64 `json:"balance"`
+	Pending   float64 `json:"pending"`
+	Spent     float64 `json:"spent"`
+	Available float64 `json:"available"`
+}
+
+type transactionView struct {
+	ID                int64                  `json:"id"`
+	DiscordID         string                 `json:"discord_id"`
+	Username          string                 `json:"username"`
+	Type              string                 `json:"type"`
+	Status            string                 `json:"status"`
+	Amount            float64                `json:"amount"`
+	Description       string                 `json:"description,omitempty"`
+	ExternalReference string                 `json:"external_reference,omitempty"`
+	IdempotencyKey    string                 `json:"idempotency_key,omitempty"`
+	PaymentData       map[string]interface{} `json:"payment_data,omitempty"`
+	CreatedAt         time.Time              `json:"created_at"`
+	ConfirmedAt       *time.Time             `json:"confirmed_at"`
+}
+
+type purchaseView struct {
+	ID        int64                      `json:"id"`
+	Title     string                     `json:"title"`
+	Amount    float64                    `json:"amount"`
+	SplitMode string                     `json:"split_mode"`
+	CreatedBy string                     `json:"created_by"`
+	CreatedAt time.Time                  `json:"created_at"`
+	Shares    []repository.PurchaseShare `json:"shares,omitempty"`
+}
+
+type auditView struct {
+	ID          int64           `json:"id"`
+	Action      string          `json:"action"`
+	Actor       string          `json:"actor"`
+	Source      string          `json:"source"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    int64           `json:"entity_id"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	PayloadHash string          `json:"payload_hash,omitempty"`
+	Reason      string          `json:"reason,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type outboxView struct {
+	ID          int64           `json:"id"`
+	Topic       string          `json:"topic"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    int64           `json:"entity_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	Handled     []string        `json:"handled"`
+	AvailableAt time.Time       `json:"available_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+// output escreve linhas como tabela alinhada ou como array JSON, uma linha
+// por vez, para que exports grandes não fiquem inteiros em memória
+type output struct {
+	format string
+	table  *tabwriter.Writer
+	count  int
+}
+
+func newOutput(format string, headers ...string) *output {
+	out := &output{format: format}
+
+	if format == "json" {
+		fmt.Print("[")
+		return out
+	}
+
+	out.table = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out.table, strings.Join(headers, "\t"))
+	return out
+}
+
+// row recebe o valor serializado no modo JSON e as colunas do modo tabela
+func (o *output) row(value interface{}, columns ...interface{}) {
+	if o.format == "json" {
+		if o.count > 0 {
+			fmt.Print(",")
+		}
+		data, _ := json.Marshal(value)
+		fmt.Printf("\n  %s", data)
+		o.count++
+		return
+	}
+
+	cells := make([]string, len(columns))
+	for i, c := range columns {
+		cells[i] = fmt.Sprint(c)
+	}
+	fmt.Fprintln(o.table, strings.Join(cells, "\t"))
+	o.count++
+}
+
+func (o *output) flush() error {
+	if o.format == "json" {
+		if o.count > 0 {
+			fmt.Print("\n")
+		}
+		fmt.Println("]")
+		return nil
+	}
+	return o.table.Flush()
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,64 @@
+// Processo web: API HTTP, webhook do Mercado Pago e agendador de
+// mensalidades. Não conecta ao gateway do Discord; as DMs saem pela API REST.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/mateus/familia-steam/internal/app"
+	"github.com/mateus/familia-steam/internal/bot"
+	"github.com/mateus/familia-steam/internal/config"
+	"github.com/mateus/familia-steam/internal/db"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erro ao carregar configurações: %v", err)
+	}
+
+	database, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
+	defer database.Close()
+	log.Println("Conectado ao banco de dados")
+
+	notifier, err := bot.NewRESTNotifier(cfg.DiscordToken)
+	if err != nil {
+		log.Fatalf("Erro ao criar notificador do Discord: %v", err)
+	}
+
+	server, err := app.NewAPI(cfg, database, notifier)
+	if err != nil {
+		log.Fatalf("Erro ao montar a API: %v", err)
+	}
+	go func() {
+		if err := server.Start(); err != nil {
+			log.Fatalf("Erro no servidor HTTP: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Iniciando shutdown gracioso...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Erro ao encerrar servidor HTTP: %v", err)
+	}
+
+	log.Println("API encerrada")
+}
@@ -1,8 +1,9 @@
+// Modo combinado: bot do Discord e API HTTP no mesmo processo. Para escalar
+// a API sem abrir conexões duplicadas com o Discord, use cmd/api e cmd/bot.
 package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -10,13 +11,9 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/mateus/familia-steam/internal/api"
-	"github.com/mateus/familia-steam/internal/bot"
+	"github.com/mateus/familia-steam/internal/app"
 	"github.com/mateus/familia-steam/internal/config"
 	"github.com/mateus/familia-steam/internal/db"
-	"github.com/mateus/familia-steam/internal/mercadopago"
-	"github.com/mateus/familia-steam/internal/repository"
-	"github.com/mateus/familia-steam/internal/service"
 )
 
 func main() {
@@ -34,18 +31,7 @@ func main() {
 	defer database.Close()
 	log.Println("Conectado ao banco de dados")
 
-	userRepo := repository.NewUserRepository(database)
-	walletRepo := repository.NewWalletRepository(database)
-	txRepo := repository.NewTransactionRepository(database)
-
-	mpClient := mercadopago.NewClient(cfg.MercadoPagoToken)
-
-	paymentService := service.NewPaymentService(mpClient, txRepo, userRepo, walletRepo)
-	walletService := service.NewWalletService(userRepo, walletRepo)
-
-	apiURL := fmt.Sprintf("http://localhost:%s", cfg.Port)
-
-	discordBot, err := bot.New(cfg.DiscordToken, apiURL)
+	discordBot, err := app.NewBot(cfg)
 	if err != nil {
 		log.Fatalf("Erro ao criar bot do Discord: %v", err)
 	}
@@ -55,7 +41,10 @@ func main() {
 	}
 	defer discordBot.Stop()
 
-	server := api.New(cfg.Port, database, paymentService, walletService)
+	server, err := app.NewAPI(cfg, database, discordBot.Notifier())
+	if err != nil {
+		log.Fatalf("Erro ao montar a API: %v", err)
+	}
 	go func() {
 		if err := server.Start(); err != nil {
 			log.Fatalf("Erro no servidor HTTP: %v", err)
@@ -0,0 +1,43 @@
+// Processo worker: bot do Discord. Fala só com a API em API_BASE_URL, então
+// roda um único processo sem acesso ao banco.
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/mateus/familia-steam/internal/app"
+	"github.com/mateus/familia-steam/internal/config"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	cfg, err := config.LoadBot()
+	if err != nil {
+		log.Fatalf("Erro ao carregar configurações: %v", err)
+	}
+
+	discordBot, err := app.NewBot(cfg)
+	if err != nil {
+		log.Fatalf("Erro ao criar bot do Discord: %v", err)
+	}
+
+	if err := discordBot.Start(); err != nil {
+		log.Fatalf("Erro ao iniciar bot do Discord: %v", err)
+	}
+	log.Printf("Usando a API em %s", cfg.APIBaseURL)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	if err := discordBot.Stop(); err != nil {
+		log.Printf("Erro ao encerrar bot do Discord: %v", err)
+	}
+
+	log.Println("Bot encerrado")
+}
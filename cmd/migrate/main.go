@@ -1,8 +1,11 @@
 package main
 
 import (
+	"database/sql"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/mateus/familia-steam/internal/db"
@@ -22,14 +25,61 @@ func main() {
 
 	log.Println("Conectado ao banco. Aplicando migrations...")
 
-	sqlBytes, err := os.ReadFile("migrations/001_init_schema.sql")
+	if _, err := database.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		log.Fatalf("Erro ao criar tabela schema_migrations: %v", err)
+	}
+
+	files, err := filepath.Glob("migrations/*.sql")
 	if err != nil {
-		log.Fatalf("Erro ao ler migration: %v", err)
+		log.Fatalf("Erro ao listar migrations: %v", err)
 	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := filepath.Base(file)
+
+		var applied bool
+		if err := database.QueryRow(`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied); err != nil {
+			log.Fatalf("Erro ao verificar migration %s: %v", version, err)
+		}
+		if applied {
+			continue
+		}
 
-	if _, err := database.Exec(string(sqlBytes)); err != nil {
-		log.Fatalf("Erro ao executar migration: %v", err)
+		sqlBytes, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Erro ao ler migration %s: %v", version, err)
+		}
+
+		if err := apply(database, version, string(sqlBytes)); err != nil {
+			log.Fatalf("Erro ao executar migration %s: %v", version, err)
+		}
+
+		log.Printf("✓ %s", version)
 	}
 
 	log.Println("✓ Migrations aplicadas com sucesso!")
 }
+
+func apply(database *sql.DB, version, query string) error {
+	tx, err := database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
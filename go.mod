@@ -1,15 +1,17 @@
 module github.com/mateus/familia-steam
 
-go 1.21
+// +heroku install ./cmd/...
+
+go 1.22
 
 require (
 	github.com/bwmarrin/discordgo v0.27.1
+	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
 )
 
 require (
 	github.com/gorilla/websocket v1.4.2 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e // indirect
 	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
 	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // indirect
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func newTransactionResponse(tx *repository.Transaction) apiclient.Transaction {
+	return apiclient.Transaction{
+		ID:          tx.ID,
+		Amount:      tx.Amount,
+		Status:      string(tx.Status),
+		Type:        string(tx.Type),
+		Description: tx.Description,
+		CreatedAt:   tx.CreatedAt,
+		ConfirmedAt: tx.ConfirmedAt,
+	}
+}
+
+// handleAdjustBalance credita ou debita manualmente a carteira de um membro,
+// como o admin credit/debit
+func (s *Server) handleAdjustBalance(w http.ResponseWriter, r *http.Request) {
+	var req apiclient.AdjustBalanceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	transaction, err := s.walletService.AdjustBalance(r.PathValue("discord_id"), req.Amount, req.Reason, repository.AuditMeta{
+		Actor:  requestActor(r),
+		Source: repository.SourceAPI,
+		Reason: req.Reason,
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrReasonRequired):
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		writeError(w, r, http.StatusNotFound, codeNotFound, errorMessage(r, err))
+		return
+	case errors.Is(err, service.ErrDebitExceedsBalance):
+		writeError(w, r, http.StatusUnprocessableEntity, codeInsufficientFunds, errorMessage(r, err))
+		return
+	case err != nil:
+		log.Printf("Erro ao ajustar saldo: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.adjust_balance"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newTransactionResponse(transaction))
+}
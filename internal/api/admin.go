@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func (s *Server) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := repository.AuditFilter{
+		Actor:      q.Get("actor"),
+		Source:     repository.AuditSource(q.Get("source")),
+		Action:     q.Get("action"),
+		EntityType: q.Get("entity_type"),
+	}
+
+	if v := q.Get("entity_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_param", "entity_id"))
+			return
+		}
+		filter.EntityID = id
+	}
+	if v := q.Get("transaction_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_param", "transaction_id"))
+			return
+		}
+		filter.EntityType = "transaction"
+		filter.EntityID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			filter.Limit = l
+		}
+	}
+
+	var err error
+	if filter.From, filter.To, err = parsePeriodParams(q.Get("from"), q.Get("to")); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	}
+
+	events, err := s.auditService.List(filter)
+	if err != nil {
+		log.Printf("Erro ao buscar auditoria: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.audit"))
+		return
+	}
+
+	response := make([]apiclient.AuditEvent, 0, len(events))
+	for _, e := range events {
+		response = append(response, apiclient.AuditEvent{
+			ID:          e.ID,
+			Action:      e.Action,
+			Actor:       e.Actor,
+			Source:      string(e.Source),
+			EntityType:  e.EntityType,
+			EntityID:    e.EntityID,
+			Before:      e.Before,
+			After:       e.After,
+			PayloadHash: e.PayloadHash,
+			Reason:      e.Reason,
+			CreatedAt:   e.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleExportReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := service.ReportFormat(q.Get("format"))
+	if format == "" {
+		format = service.ReportCSV
+	}
+	if !format.Valid() {
+		writeError(w, r, http.StatusBadRequest, codeInvalidFormat, errorMessage(r, service.ErrInvalidReportFormat))
+		return
+	}
+
+	from, to, err := parsePeriodParams(q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="familia-steam-%s.%s"`, time.Now().Format("20060102"), format))
+
+	// O corpo já começou a ser enviado; um erro no meio só pode ser logado
+	if err := s.reportService.Export(w, format, from, to); err != nil {
+		log.Printf("Erro ao exportar relatório: %v", err)
+	}
+}
+
+// parsePeriodParams aceita AAAA-MM-DD ou RFC 3339. Uma data final sem hora é
+// inclusiva: to=2024-01-31 cobre o dia 31 inteiro.
+func parsePeriodParams(fromStr, toStr string) (time.Time, time.Time, error) {
+	var from, to time.Time
+
+	if fromStr != "" {
+		t, _, err := parseTimeParam(fromStr)
+		if err != nil {
+			return from, to, i18n.NewError("error.invalid_date", "from")
+		}
+		from = t
+	}
+
+	if toStr != "" {
+		t, dateOnly, err := parseTimeParam(toStr)
+		if err != nil {
+			return from, to, i18n.NewError("error.invalid_date", "to")
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+
+	return from, to, nil
+}
+
+func parseTimeParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
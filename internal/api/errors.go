@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+// Códigos de erro estáveis; a mensagem é para pessoas e pode mudar
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeInternal             = "internal_error"
+	codeAdminDisabled        = "admin_disabled"
+	codeIdempotencyReused    = "idempotency_key_reused"
+	codeInsufficientFunds    = "insufficient_funds"
+	codeInvalidFormat        = "invalid_format"
+	codeBodyTooLarge         = "body_too_large"
+	codeRateLimited          = "rate_limited"
+	codeTooManyPending       = "too_many_pending"
+	codeMessageAttached      = "payment_message_attached"
+	codeNotAwaitingApproval  = "payment_not_awaiting_approval"
+	codeAmountOutOfRange     = "amount_out_of_range"
+	codeMercadoPagoLimited   = "mercadopago_rate_limited"
+	codeMercadoPagoDown      = "mercadopago_unavailable"
+	codeMercadoPagoRejected  = "mercadopago_invalid_amount"
+	codeMercadoPagoAuthError = "mercadopago_unauthorized"
+)
+
+// requestLocale escolhe o idioma das mensagens pelo Accept-Language
+func requestLocale(r *http.Request) i18n.Locale {
+	return i18n.Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// t traduz key para o idioma da requisição
+func t(r *http.Request, key string, args ...interface{}) string {
+	return i18n.T(requestLocale(r), key, args...)
+}
+
+// errorMessage traduz um erro dos serviços para o idioma da requisição
+func errorMessage(r *http.Request, err error) string {
+	return i18n.Message(requestLocale(r), err)
+}
+
+// writeError responde com o envelope de erro da API; message já vem
+// traduzida para o idioma da requisição
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeErrorDetails(w, r, status, code, message, nil)
+}
+
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", string(requestLocale(r)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiclient.ErrorBody{
+		Code:    code,
+		Message: message,
+		Details: details,
+	})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, t(r, "api.method_not_allowed"))
+}
+
+// decodeJSON lê o corpo da requisição em v. Responde 413 quando o corpo passa
+// do limite e 400 quando não é JSON válido
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	writeBodyError(w, r, err)
+	return false
+}
+
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeErrorDetails(w, r, http.StatusRequestEntityTooLarge, codeBodyTooLarge, t(r, "api.body_too_large"), map[string]interface{}{"max_bytes": tooLarge.Limit})
+		return
+	}
+	writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_request"))
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func newGuildSettingsResponse(settings *repository.GuildSettings) apiclient.GuildSettings {
+	response := apiclient.GuildSettings{
+		GuildID:               settings.GuildID,
+		CommandPrefix:         settings.CommandPrefix,
+		AllowedChannelIDs:     settings.AllowedChannelIDs,
+		AnnouncementChannelID: settings.AnnouncementChannelID,
+		UpdatedBy:             settings.UpdatedBy,
+	}
+	// Servidor nunca configurado: os padrões não têm data
+	if !settings.UpdatedAt.IsZero() {
+		response.UpdatedAt = &settings.UpdatedAt
+	}
+	return response
+}
+
+func (s *Server) handleGetGuildSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.guildSettings.Get(r.PathValue("guild_id"))
+	if err != nil {
+		log.Printf("Erro ao buscar configurações do servidor: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.guild_settings"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newGuildSettingsResponse(settings))
+}
+
+func (s *Server) handleUpdateGuildSettings(w http.ResponseWriter, r *http.Request) {
+	var req apiclient.UpdateGuildSettingsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	settings, err := s.guildSettings.Update(r.PathValue("guild_id"), service.GuildSettingsUpdate{
+		CommandPrefix:         req.CommandPrefix,
+		AllowedChannelIDs:     req.AllowedChannelIDs,
+		AnnouncementChannelID: req.AnnouncementChannelID,
+	}, repository.AuditMeta{
+		Actor:  requestActor(r),
+		Source: repository.SourceAPI,
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidPrefix):
+		writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err), map[string]interface{}{"field": "command_prefix"})
+		return
+	case errors.Is(err, service.ErrInvalidChannel):
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	case err != nil:
+		log.Printf("Erro ao salvar configurações do servidor: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.save_guild_settings"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newGuildSettingsResponse(settings))
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func newLocaleResponse(setting service.LocaleSetting) apiclient.LocaleSetting {
+	return apiclient.LocaleSetting{
+		Locale: string(setting.Locale),
+		Source: string(setting.Source),
+	}
+}
+
+// handleGetLocale devolve o idioma configurado para o membro, considerando
+// o servidor quando guild_id é informado. Sem configuração, locale vem vazio
+func (s *Server) handleGetLocale(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	discordID := q.Get("discord_id")
+	if discordID == "" {
+		writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.field_required", "discord_id"), map[string]interface{}{"field": "discord_id"})
+		return
+	}
+
+	setting, err := s.localeService.Resolve(discordID, q.Get("guild_id"))
+	if err != nil {
+		log.Printf("Erro ao buscar idioma: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.locale"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newLocaleResponse(setting))
+}
+
+// handleSetUserLocale só deixa o membro trocar o próprio idioma
+func (s *Server) handleSetUserLocale(w http.ResponseWriter, r *http.Request) {
+	discordID := r.PathValue("discord_id")
+	if _, ok := callerOwns(w, r, discordID); !ok {
+		return
+	}
+	s.setLocale(w, r, repository.LocaleScopeUser, discordID)
+}
+
+func (s *Server) handleSetGuildLocale(w http.ResponseWriter, r *http.Request) {
+	s.setLocale(w, r, repository.LocaleScopeGuild, r.PathValue("guild_id"))
+}
+
+func (s *Server) setLocale(w http.ResponseWriter, r *http.Request, scope repository.LocaleScope, scopeID string) {
+	var req apiclient.SetLocaleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	setting, err := s.localeService.Set(scope, scopeID, req.Locale)
+	if errors.Is(err, service.ErrInvalidLocale) {
+		writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err), map[string]interface{}{"field": "locale"})
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao salvar idioma: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.save_locale"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newLocaleResponse(setting))
+}
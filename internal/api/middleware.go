@@ -0,0 +1,302 @@
+package api
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+// maxBodyBytes limita o corpo das requisições; nenhuma rota precisa de mais
+const maxBodyBytes = 1 << 20
+
+type middleware func(http.Handler) http.Handler
+
+// chain aplica os middlewares na ordem em que aparecem: o primeiro é o mais
+// externo
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// statusRecorder guarda o status escrito pelo handler para o log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				log.Printf("Panic em %s %s: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+				writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.generic"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// cors libera as origens configuradas em CORS_ALLOWED_ORIGINS ("*" libera
+// todas) e responde os preflights antes do roteamento, com os métodos que as
+// rotas usam
+func cors(origins, methods []string) middleware {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[o] = true
+	}
+	allowMethods := strings.Join(append(methods, http.MethodOptions), ", ")
+	allowHeaders := strings.Join([]string{
+		"Authorization", "Content-Type", "Idempotency-Key",
+		apiclient.HeaderDiscordUserID, apiclient.HeaderDiscordGuildID, apiclient.HeaderDiscordRoles,
+	}, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || (!allowed["*"] && !allowed[origin]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if allowed["*"] {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			h.Set("Access-Control-Expose-Headers", "Idempotent-Replayed, Retry-After, Deprecation, Link")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", allowMethods)
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+				h.Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routeMethods devolve os métodos das rotas, sem repetir e em ordem alfabética
+func routeMethods(routes []route) []string {
+	var methods []string
+	for _, rt := range routes {
+		if !slices.Contains(methods, rt.method) {
+			methods = append(methods, rt.method)
+		}
+	}
+	slices.Sort(methods)
+	return methods
+}
+
+func limitBody(n int64) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireAdmin exige "Authorization: Bearer <ADMIN_API_TOKEN>". Sem token
+// configurado as rotas administrativas ficam desligadas. Quando o bot informa
+// o membro do Discord que pediu a operação, ele precisa ter a capacidade; nas
+// rotas de um servidor, a do servidor do caminho. Só o token, sem membro, é o
+// acesso de operador (CLI, curl)
+func (s *Server) requireAdmin(capability service.Capability, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			writeError(w, r, http.StatusNotFound, codeAdminDisabled, t(r, "api.admin_disabled"))
+			return
+		}
+
+		if !s.isAdminRequest(r) {
+			writeError(w, r, http.StatusUnauthorized, codeUnauthorized, t(r, "api.unauthorized"))
+			return
+		}
+
+		if caller, ok := requestCaller(r); ok {
+			if guildID := r.PathValue("guild_id"); guildID != "" {
+				if caller.GuildID != "" && caller.GuildID != guildID {
+					writeErrorDetails(w, r, http.StatusForbidden, codeForbidden, t(r, "api.guild_mismatch"), map[string]interface{}{"guild_id": guildID})
+					return
+				}
+				caller.GuildID = guildID
+			}
+
+			allowed, err := s.permissionService.Allowed(caller, capability)
+			if err != nil {
+				log.Printf("Erro ao verificar permissão de %s: %v", caller.DiscordID, err)
+				writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.generic"))
+				return
+			}
+			if !allowed {
+				writeErrorDetails(w, r, http.StatusForbidden, codeForbidden, t(r, "api.forbidden"), map[string]interface{}{"capability": capability})
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireCaller exige o token do bot e o membro do Discord que pediu a
+// operação. Cada handler confere se o membro é o dono dos dados que altera
+func (s *Server) requireCaller(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAdminRequest(r) {
+			writeError(w, r, http.StatusUnauthorized, codeUnauthorized, t(r, "api.unauthorized"))
+			return
+		}
+		if _, ok := requestCaller(r); !ok {
+			writeErrorDetails(w, r, http.StatusUnauthorized, codeUnauthorized, t(r, "api.caller_required"), map[string]interface{}{"header": apiclient.HeaderDiscordUserID})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestCaller lê o membro do Discord informado pelo bot. Só vale junto com
+// o token administrativo, que já identifica o bot
+func requestCaller(r *http.Request) (service.Caller, bool) {
+	caller := service.Caller{
+		DiscordID: r.Header.Get(apiclient.HeaderDiscordUserID),
+		GuildID:   r.Header.Get(apiclient.HeaderDiscordGuildID),
+	}
+	if caller.DiscordID == "" {
+		return caller, false
+	}
+	for _, role := range strings.Split(r.Header.Get(apiclient.HeaderDiscordRoles), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			caller.RoleIDs = append(caller.RoleIDs, role)
+		}
+	}
+	return caller, true
+}
+
+// callerOwns confirma que o membro informado pelo bot é o dono dos dados que
+// a requisição altera; se não for, responde 403
+func callerOwns(w http.ResponseWriter, r *http.Request, discordID string) (service.Caller, bool) {
+	caller, ok := requestCaller(r)
+	if !ok || caller.DiscordID != discordID {
+		writeError(w, r, http.StatusForbidden, codeForbidden, t(r, "api.not_owner"))
+		return caller, false
+	}
+	return caller, true
+}
+
+// callerID devolve o membro informado pelo bot, dono dos dados que a
+// requisição altera. Um discord_id no corpo é opcional, mas se vier tem de ser
+// o dele
+func callerID(w http.ResponseWriter, r *http.Request, bodyID string) (string, bool) {
+	if bodyID == "" {
+		caller, _ := requestCaller(r)
+		return caller.DiscordID, true
+	}
+	caller, ok := callerOwns(w, r, bodyID)
+	return caller.DiscordID, ok
+}
+
+// requestActor é quem aparece na auditoria: o membro do Discord, se o bot
+// informou, ou "api"
+func requestActor(r *http.Request) string {
+	if caller, ok := requestCaller(r); ok {
+		return caller.DiscordID
+	}
+	return "api"
+}
+
+func (s *Server) isAdminRequest(r *http.Request) bool {
+	if s.adminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1
+}
+
+// deprecated marca os caminhos antigos, sem /v1, apontando para o substituto
+func deprecated(successor string) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// headerRecorder captura a resposta padrão do ServeMux para 404 e 405
+type headerRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *headerRecorder) Header() http.Header         { return r.header }
+func (r *headerRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (r *headerRecorder) WriteHeader(status int)      { r.status = status }
+
+// jsonErrors troca as respostas em texto do ServeMux para rotas inexistentes
+// e métodos não permitidos pelo envelope de erro da API
+func jsonErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &headerRecorder{header: http.Header{}}
+		h.ServeHTTP(rec, r)
+		if rec.status == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", rec.header.Get("Allow"))
+			writeMethodNotAllowed(w, r)
+			return
+		}
+		writeError(w, r, http.StatusNotFound, codeNotFound, t(r, "api.route_not_found"))
+	})
+}
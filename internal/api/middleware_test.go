@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func TestCORSPreflightAllowsEveryRouteMethod(t *testing.T) {
+	s := &Server{}
+	routes := s.routes()
+	handler := cors([]string{"*"}, routeMethods(routes))(http.NotFoundHandler())
+
+	for _, rt := range routes {
+		req := httptest.NewRequest(http.MethodOptions, rt.path, nil)
+		req.Header.Set("Origin", "https://painel.example")
+		req.Header.Set("Access-Control-Request-Method", rt.method)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("preflight de %s %s: status %d", rt.method, rt.path, rec.Code)
+		}
+		allowed := strings.Split(rec.Header().Get("Access-Control-Allow-Methods"), ", ")
+		if !slices.Contains(allowed, rt.method) {
+			t.Errorf("preflight de %s %s não libera o método: %v", rt.method, rt.path, allowed)
+		}
+	}
+}
+
+func TestIsAdminRequestRequiresBearer(t *testing.T) {
+	s := &Server{adminToken: "segredo"}
+
+	tests := []struct {
+		authorization string
+		want          bool
+	}{
+		{"Bearer segredo", true},
+		{"segredo", false},
+		{"Bearer outro", false},
+		{"Basic segredo", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", tt.authorization)
+		if got := s.isAdminRequest(req); got != tt.want {
+			t.Errorf("isAdminRequest(%q) = %v, quer %v", tt.authorization, got, tt.want)
+		}
+	}
+}
+
+func TestRequireAdminChecksThePathGuild(t *testing.T) {
+	s := &Server{
+		adminToken:        "segredo",
+		permissionService: service.NewPermissionService(nil, []string{"dono"}),
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /guilds/{guild_id}", s.requireAdmin(service.CapabilitySettings, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	tests := []struct {
+		name        string
+		headerGuild string
+		want        int
+	}{
+		{"mesmo servidor", "1", http.StatusNoContent},
+		{"sem servidor no cabeçalho", "", http.StatusNoContent},
+		{"outro servidor", "2", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/guilds/1", nil)
+			req.Header.Set("Authorization", "Bearer segredo")
+			req.Header.Set(apiclient.HeaderDiscordUserID, "dono")
+			req.Header.Set(apiclient.HeaderDiscordGuildID, tt.headerGuild)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status %d, quer %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
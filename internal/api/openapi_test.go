@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPICoversAllRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json inválido: %v", err)
+	}
+
+	s := &Server{}
+	for _, rt := range s.routes() {
+		paths := []string{strings.TrimSuffix(rt.path, "{$}")}
+		if rt.legacy != "" {
+			paths = append(paths, rt.legacy)
+		}
+
+		for _, path := range paths {
+			operations, ok := spec.Paths[path]
+			if !ok {
+				t.Errorf("rota %s não está documentada em openapi.json", path)
+				continue
+			}
+			if _, ok := operations[strings.ToLower(rt.method)]; !ok {
+				t.Errorf("rota %s %s não está documentada em openapi.json", rt.method, path)
+			}
+		}
+	}
+}
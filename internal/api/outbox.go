@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func newOutboxEventResponse(e *repository.OutboxEvent) apiclient.OutboxEvent {
+	return apiclient.OutboxEvent{
+		ID:          e.ID,
+		Topic:       e.Topic,
+		EntityType:  e.EntityType,
+		EntityID:    e.EntityID,
+		Payload:     e.Payload,
+		Status:      string(e.Status),
+		Attempts:    e.Attempts,
+		LastError:   e.LastError,
+		Handled:     e.Handled,
+		AvailableAt: e.AvailableAt,
+		CreatedAt:   e.CreatedAt,
+		ProcessedAt: e.ProcessedAt,
+	}
+}
+
+// handleListOutbox mostra, por padrão, os eventos travados: mortos e
+// pendentes que já falharam
+func (s *Server) handleListOutbox(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter, err := service.ParseOutboxFilter(q.Get("status"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	}
+	filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+
+	events, err := s.outboxService.List(filter)
+	if err != nil {
+		log.Printf("Erro ao listar outbox: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.list_outbox"))
+		return
+	}
+
+	response := make([]apiclient.OutboxEvent, 0, len(events))
+	for i := range events {
+		response = append(response, newOutboxEventResponse(&events[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleRetryOutbox(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_param", "id"))
+		return
+	}
+
+	event, err := s.outboxService.Retry(id)
+	if errors.Is(err, service.ErrOutboxEventNotFound) {
+		writeError(w, r, http.StatusNotFound, codeNotFound, errorMessage(r, err))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao reenfileirar evento %d do outbox: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.retry_outbox"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newOutboxEventResponse(event))
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func (s *Server) handleListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := s.permissionService.List(r.PathValue("guild_id"))
+	if err != nil {
+		log.Printf("Erro ao listar permissões: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.list_permissions"))
+		return
+	}
+
+	response := make([]apiclient.RolePermission, 0, len(permissions))
+	for _, p := range permissions {
+		response = append(response, apiclient.RolePermission{
+			GuildID:    p.GuildID,
+			RoleID:     p.RoleID,
+			Capability: p.Capability,
+			GrantedBy:  p.GrantedBy,
+			CreatedAt:  p.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleGrantPermission(w http.ResponseWriter, r *http.Request) {
+	s.changePermission(w, r, s.permissionService.Grant)
+}
+
+func (s *Server) handleRevokePermission(w http.ResponseWriter, r *http.Request) {
+	s.changePermission(w, r, s.permissionService.Revoke)
+}
+
+type permissionChange func(guildID, roleID string, capability service.Capability, meta repository.AuditMeta) (bool, error)
+
+func (s *Server) changePermission(w http.ResponseWriter, r *http.Request, change permissionChange) {
+	guildID, roleID := r.PathValue("guild_id"), r.PathValue("role_id")
+
+	capability, err := service.ParseCapability(r.PathValue("capability"))
+	if err != nil {
+		writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err), map[string]interface{}{
+			"capabilities": service.Capabilities,
+		})
+		return
+	}
+
+	changed, err := change(guildID, roleID, capability, repository.AuditMeta{
+		Actor:  requestActor(r),
+		Source: repository.SourceAPI,
+	})
+	if errors.Is(err, service.ErrGuildRequired) || errors.Is(err, service.ErrRoleRequired) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao alterar permissão: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.save_permission"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiclient.PermissionChange{
+		GuildID:    guildID,
+		RoleID:     roleID,
+		Capability: string(capability),
+		Changed:    changed,
+	})
+}
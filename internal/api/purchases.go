@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func newPurchaseResponse(p *repository.Purchase) apiclient.Purchase {
+	response := apiclient.Purchase{
+		ID:        p.ID,
+		Title:     p.Title,
+		Amount:    p.Amount,
+		SplitMode: string(p.SplitMode),
+		CreatedBy: p.CreatedBy,
+		CreatedAt: p.CreatedAt,
+	}
+	for _, share := range p.Shares {
+		response.Shares = append(response.Shares, apiclient.PurchaseShare(share))
+	}
+	return response
+}
+
+// handleListPurchases lista as compras; o caminho antigo ainda aceita ?id=
+// para mostrar uma compra só
+func (s *Server) handleListPurchases(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if v := q.Get("id"); v != "" {
+		s.writePurchase(w, r, v)
+		return
+	}
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	purchases, err := s.purchaseService.List(limit)
+	if err != nil {
+		log.Printf("Erro ao listar compras: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.list_purchases"))
+		return
+	}
+
+	response := make([]apiclient.Purchase, 0, len(purchases))
+	for i := range purchases {
+		response = append(response, newPurchaseResponse(&purchases[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleGetPurchase(w http.ResponseWriter, r *http.Request) {
+	s.writePurchase(w, r, r.PathValue("id"))
+}
+
+func (s *Server) writePurchase(w http.ResponseWriter, r *http.Request, rawID string) {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_param", "id"))
+		return
+	}
+
+	purchase, err := s.purchaseService.Get(id)
+	if errors.Is(err, service.ErrPurchaseNotFound) {
+		writeError(w, r, http.StatusNotFound, codeNotFound, errorMessage(r, err))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar compra: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.get_purchase"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newPurchaseResponse(purchase))
+}
+
+func (s *Server) handleCreatePurchase(w http.ResponseWriter, r *http.Request) {
+	var req apiclient.CreatePurchaseRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	var mode repository.SplitMode
+	if req.SplitMode != "" {
+		var err error
+		if mode, err = service.ParseSplitMode(req.SplitMode); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+			return
+		}
+	}
+
+	actor := req.Actor
+	if actor == "" {
+		actor = requestActor(r)
+	}
+
+	purchase, err := s.purchaseService.RecordPurchase(req.Title, req.Amount, mode, repository.AuditMeta{
+		Actor:  actor,
+		Source: repository.SourceAPI,
+		Reason: req.Title,
+	})
+	switch {
+	case errors.Is(err, service.ErrTitleRequired), errors.Is(err, service.ErrInvalidAmount):
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	case errors.Is(err, service.ErrInsufficientFunds):
+		writeError(w, r, http.StatusUnprocessableEntity, codeInsufficientFunds, errorMessage(r, err))
+		return
+	case err != nil:
+		log.Printf("Erro ao registrar compra: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.create_purchase"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newPurchaseResponse(purchase))
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PaymentRateLimits limita quantos PIX cada Discord ID e cada IP podem criar
+// a cada Window. Zero desliga o limite correspondente
+type PaymentRateLimits struct {
+	PerUser int
+	PerIP   int
+	Window  time.Duration
+}
+
+// rateLimiter é um token bucket por chave: cada chave começa com limit
+// tokens e recupera limit tokens a cada window
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     float64
+	perSecond float64
+	window    time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newRateLimiter devolve nil (sem limite) quando limit ou window é zero
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		limit:     float64(limit),
+		perSecond: float64(limit) / window.Seconds(),
+		window:    window,
+		buckets:   make(map[string]*bucket),
+	}
+}
+
+// allow consome um token da chave. Sem token, devolve quanto tempo falta
+// para o próximo
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.limit, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.limit, b.tokens+now.Sub(b.updated).Seconds()*l.perSecond)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep descarta os buckets que já estariam cheios de novo, para o mapa não
+// crescer com cada IP que passou por aqui
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// clientIP usa o último endereço do X-Forwarded-For, que é o que o roteador
+// do Heroku viu; os anteriores vêm do cliente e podem ser forjados
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		parts := strings.Split(forwarded, ",")
+		if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limitByIP aplica o limite por IP a quem não mandou o token do bot. O bot
+// chama a API de um único IP em nome de todos os membros, então para ele só
+// vale o limite por Discord ID; como a configuração exige ADMIN_API_TOKEN,
+// ele sempre se identifica
+func (s *Server) limitByIP(limiter *rateLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAdminRequest(r) {
+			if ok, wait := limiter.allow(clientIP(r), time.Now()); !ok {
+				writeRateLimited(w, r, "ip", wait)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeRateLimited(w http.ResponseWriter, r *http.Request, scope string, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeErrorDetails(w, r, http.StatusTooManyRequests, codeRateLimited, t(r, "api.rate_limited"), map[string]interface{}{
+		"scope":               scope,
+		"retry_after_seconds": seconds,
+	})
+}
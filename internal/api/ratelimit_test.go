@@ -0,0 +1,124 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("chamada %d recusada dentro do limite", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok {
+		t.Fatal("terceira chamada aceita acima do limite")
+	}
+	if wait != 30*time.Second {
+		t.Errorf("espera = %v, quer 30s", wait)
+	}
+
+	// Cada chave tem o próprio bucket
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("outra chave recusada")
+	}
+
+	// Meia janela devolve um token
+	if ok, _ := l.allow("a", now.Add(30*time.Second)); !ok {
+		t.Error("chamada recusada depois de recuperar um token")
+	}
+	if ok, _ := l.allow("a", now.Add(30*time.Second)); ok {
+		t.Error("só um token deveria ter voltado")
+	}
+}
+
+func TestRateLimiterSweepsFullBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(1, time.Minute)
+
+	l.allow("a", now)
+	l.allow("b", now.Add(30*time.Second))
+	l.allow("c", now.Add(time.Minute))
+
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("bucket cheio de novo não foi descartado")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("bucket ainda em uso foi descartado")
+	}
+}
+
+func TestNilRateLimiterAllowsEverything(t *testing.T) {
+	if l := newRateLimiter(0, time.Minute); l != nil {
+		t.Fatal("limite zero deveria desligar o limitador")
+	}
+	var l *rateLimiter
+	if ok, _ := l.allow("a", time.Now()); !ok {
+		t.Error("limitador desligado recusou a chamada")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded string
+		remote    string
+		want      string
+	}{
+		{"sem proxy", "", "10.0.0.1:5000", "10.0.0.1"},
+		{"último do X-Forwarded-For", "1.1.1.1, 2.2.2.2", "10.0.0.1:5000", "2.2.2.2"},
+		{"endereço sem porta", "", "10.0.0.1", "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP = %q, quer %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitByIPSkipsTheBot(t *testing.T) {
+	s := &Server{adminToken: "segredo"}
+	handler := s.limitByIP(newRateLimiter(1, time.Minute), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	call := func(token string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/payments/create", nil)
+		r.RemoteAddr = "10.0.0.1:5000"
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := call(""); code != http.StatusNoContent {
+		t.Fatalf("primeira chamada anônima: status %d", code)
+	}
+	if code := call(""); code != http.StatusTooManyRequests {
+		t.Errorf("segunda chamada anônima: status %d, quer 429", code)
+	}
+	if code := call("errado"); code != http.StatusTooManyRequests {
+		t.Errorf("token errado: status %d, quer 429", code)
+	}
+	for i := 0; i < 3; i++ {
+		if code := call("segredo"); code != http.StatusNoContent {
+			t.Fatalf("chamada %d do bot: status %d", i+1, code)
+		}
+	}
+}
@@ -4,48 +4,192 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/mercadopago"
+	"github.com/mateus/familia-steam/internal/repository"
 	"github.com/mateus/familia-steam/internal/service"
 )
 
+// O webhook é público: cada IP pode mandar webhookRatePerIP notificações por
+// minuto, de até maxWebhookBytes. O Mercado Pago repete as que receberem 429
+const (
+	webhookRatePerIP = 120
+	maxWebhookBytes  = 64 << 10
+)
+
+// WriteTimeout é o prazo para responder uma requisição; chamadas ao Mercado
+// Pago feitas durante ela precisam terminar antes
+const WriteTimeout = 15 * time.Second
+
 type Server struct {
 	server         *http.Server
 	db             *sql.DB
+	adminToken     string
 	paymentService *service.PaymentService
 	walletService  *service.WalletService
-}
+	auditService   *service.AuditService
+	reportService  *service.ReportService
 
-func New(port string, db *sql.DB, paymentService *service.PaymentService, walletService *service.WalletService) *Server {
-	mux := http.NewServeMux()
+	subscriptionService *service.SubscriptionService
+	purchaseService     *service.PurchaseService
+	transferService     *service.TransferService
+	outboxService       *service.OutboxService
+	webhookService      *service.WebhookService
+	localeService       *service.LocaleService
+	permissionService   *service.PermissionService
+	guildSettings       *service.GuildSettingsService
+
+	paymentUserLimiter *rateLimiter
+	paymentIPLimiter   *rateLimiter
+	webhookIPLimiter   *rateLimiter
+}
 
+func New(
+	port string,
+	adminToken string,
+	corsOrigins []string,
+	paymentRateLimits PaymentRateLimits,
+	db *sql.DB,
+	paymentService *service.PaymentService,
+	walletService *service.WalletService,
+	auditService *service.AuditService,
+	reportService *service.ReportService,
+	subscriptionService *service.SubscriptionService,
+	purchaseService *service.PurchaseService,
+	transferService *service.TransferService,
+	outboxService *service.OutboxService,
+	webhookService *service.WebhookService,
+	localeService *service.LocaleService,
+	permissionService *service.PermissionService,
+	guildSettings *service.GuildSettingsService,
+) *Server {
 	s := &Server{
 		server: &http.Server{
 			Addr:         ":" + port,
-			Handler:      mux,
 			ReadTimeout:  15 * time.Second,
-			WriteTimeout: 15 * time.Second,
+			WriteTimeout: WriteTimeout,
 			IdleTimeout:  60 * time.Second,
 		},
 		db:             db,
+		adminToken:     adminToken,
 		paymentService: paymentService,
 		walletService:  walletService,
+		auditService:   auditService,
+		reportService:  reportService,
+
+		subscriptionService: subscriptionService,
+		purchaseService:     purchaseService,
+		transferService:     transferService,
+		outboxService:       outboxService,
+		webhookService:      webhookService,
+		localeService:       localeService,
+		permissionService:   permissionService,
+		guildSettings:       guildSettings,
+
+		paymentUserLimiter: newRateLimiter(paymentRateLimits.PerUser, paymentRateLimits.Window),
+		paymentIPLimiter:   newRateLimiter(paymentRateLimits.PerIP, paymentRateLimits.Window),
+		webhookIPLimiter:   newRateLimiter(webhookRatePerIP, time.Minute),
+	}
+
+	routes := s.routes()
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		h := http.Handler(rt.handler)
+		switch {
+		case rt.capability != "":
+			h = s.requireAdmin(rt.capability, h)
+		case rt.caller:
+			h = s.requireCaller(h)
+		}
+		if rt.ipLimit != nil {
+			h = s.limitByIP(rt.ipLimit, h)
+		}
+		mux.Handle(rt.method+" "+rt.path, h)
+		if rt.legacy != "" {
+			mux.Handle(rt.method+" "+rt.legacy, deprecated(rt.path)(h))
+		}
 	}
 
-	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/", s.handleRoot)
-	mux.HandleFunc("/api/payments/create", s.handleCreatePayment)
-	mux.HandleFunc("/api/payments/webhook", s.handleWebhook)
-	mux.HandleFunc("/api/wallet/balance", s.handleGetBalance)
-	mux.HandleFunc("/api/wallet/ranking", s.handleGetRanking)
+	s.server.Handler = chain(jsonErrors(mux),
+		recoverPanics,
+		logRequests,
+		cors(corsOrigins, routeMethods(routes)),
+		limitBody(maxBodyBytes),
+	)
 
 	return s
 }
 
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	// capability torna a rota administrativa: exige o token e, quando o bot
+	// informa o membro do Discord, que ele tenha a capacidade
+	capability service.Capability
+	// caller exige o token do bot e o membro do Discord em nome de quem ele
+	// chama; vale para as rotas em que um membro altera os próprios dados
+	caller bool
+	// ipLimit limita por IP as chamadas sem o token do bot, antes da
+	// autenticação
+	ipLimit *rateLimiter
+	// legacy é o caminho de antes do /api/v1, mantido como alias obsoleto
+	legacy string
+}
+
+// routes lista todas as rotas da API; o teste do OpenAPI usa esta mesma
+// lista para garantir que a especificação está completa
+func (s *Server) routes() []route {
+	return []route{
+		{method: "GET", path: "/health", handler: s.handleHealth},
+		{method: "GET", path: "/{$}", handler: s.handleRoot},
+		{method: "GET", path: "/api/v1/openapi.json", handler: s.handleOpenAPI, legacy: "/api/openapi.json"},
+
+		{method: "POST", path: "/api/v1/payments/create", handler: s.handleCreatePayment, caller: true, ipLimit: s.paymentIPLimiter, legacy: "/api/payments/create"},
+		{method: "PUT", path: "/api/v1/payments/{id}/discord-message", handler: s.handleAttachPaymentMessage, caller: true},
+		{method: "POST", path: "/api/v1/payments/webhook", handler: s.handleWebhook, legacy: "/api/payments/webhook"},
+
+		{method: "GET", path: "/api/v1/wallet/balance", handler: s.handleGetBalance, legacy: "/api/wallet/balance"},
+		{method: "GET", path: "/api/v1/wallet/total", handler: s.handleGetTotal, legacy: "/api/wallet/total"},
+		{method: "GET", path: "/api/v1/wallet/ranking", handler: s.handleGetRanking, legacy: "/api/wallet/ranking"},
+		{method: "POST", path: "/api/v1/wallet/transfer", handler: s.handleTransfer, caller: true, legacy: "/api/wallet/transfer"},
+
+		{method: "POST", path: "/api/v1/subscriptions", handler: s.handleSubscribe, caller: true, legacy: "/api/subscriptions"},
+		{method: "POST", path: "/api/v1/subscriptions/cancel", handler: s.handleCancelSubscription, caller: true, legacy: "/api/subscriptions/cancel"},
+		{method: "GET", path: "/api/v1/subscriptions/status", handler: s.handleSubscriptionStatus, legacy: "/api/subscriptions/status"},
+
+		{method: "GET", path: "/api/v1/locale", handler: s.handleGetLocale},
+		{method: "PUT", path: "/api/v1/locale/users/{discord_id}", handler: s.handleSetUserLocale, caller: true},
+		{method: "PUT", path: "/api/v1/locale/guilds/{guild_id}", handler: s.handleSetGuildLocale, capability: service.CapabilitySettings},
+
+		{method: "GET", path: "/api/v1/admin/audit", handler: s.handleListAudit, capability: service.CapabilityAudit, legacy: "/api/admin/audit"},
+		{method: "GET", path: "/api/v1/admin/purchases", handler: s.handleListPurchases, capability: service.CapabilityPurchase, legacy: "/api/admin/purchases"},
+		{method: "POST", path: "/api/v1/admin/purchases", handler: s.handleCreatePurchase, capability: service.CapabilityPurchase, legacy: "/api/admin/purchases"},
+		{method: "GET", path: "/api/v1/admin/purchases/{id}", handler: s.handleGetPurchase, capability: service.CapabilityPurchase},
+		{method: "POST", path: "/api/v1/admin/wallets/{discord_id}/adjustments", handler: s.handleAdjustBalance, capability: service.CapabilityAdjust},
+		{method: "POST", path: "/api/v1/admin/payments/{id}/approve", handler: s.handleApprovePayment, capability: service.CapabilityRefund},
+		{method: "GET", path: "/api/v1/admin/outbox", handler: s.handleListOutbox, capability: service.CapabilityOperations},
+		{method: "POST", path: "/api/v1/admin/outbox/{id}/retry", handler: s.handleRetryOutbox, capability: service.CapabilityOperations},
+		{method: "GET", path: "/api/v1/admin/webhooks", handler: s.handleListWebhooks, capability: service.CapabilityOperations},
+		{method: "POST", path: "/api/v1/admin/webhooks/{id}/replay", handler: s.handleReplayWebhook, capability: service.CapabilityOperations},
+		{method: "GET", path: "/api/v1/admin/guilds/{guild_id}/permissions", handler: s.handleListPermissions, capability: service.CapabilityPermissions},
+		{method: "PUT", path: "/api/v1/admin/guilds/{guild_id}/roles/{role_id}/permissions/{capability}", handler: s.handleGrantPermission, capability: service.CapabilityPermissions},
+		{method: "DELETE", path: "/api/v1/admin/guilds/{guild_id}/roles/{role_id}/permissions/{capability}", handler: s.handleRevokePermission, capability: service.CapabilityPermissions},
+		{method: "GET", path: "/api/v1/admin/guilds/{guild_id}/settings", handler: s.handleGetGuildSettings, capability: service.CapabilitySettings},
+		{method: "PATCH", path: "/api/v1/admin/guilds/{guild_id}/settings", handler: s.handleUpdateGuildSettings, capability: service.CapabilitySettings},
+		{method: "GET", path: "/api/v1/reports/export", handler: s.handleExportReport, capability: service.CapabilityExport, legacy: "/api/reports/export"},
+	}
+}
+
 func (s *Server) Start() error {
 	log.Printf("Servidor HTTP iniciado na porta %s", s.server.Addr)
 	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -60,14 +204,22 @@ func (s *Server) Shutdown(ctx context.Context) error {
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := map[string]string{
+		"status":      "ok",
+		"database":    "ok",
+		"mercadopago": string(s.paymentService.MercadoPagoState()),
+	}
+	statusCode := http.StatusOK
+
 	if err := s.db.Ping(); err != nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		w.Write([]byte("Database unavailable"))
-		return
+		health["status"] = "unavailable"
+		health["database"] = "unavailable"
+		statusCode = http.StatusServiceUnavailable
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(health)
 }
 
 func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
@@ -76,72 +228,209 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleCreatePayment(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+	var req apiclient.CreatePaymentRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	var req struct {
-		DiscordID string  `json:"discord_id"`
-		Username  string  `json:"username"`
-		Amount    float64 `json:"amount"`
+	if req.Amount <= 0 {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.amount_must_be_positive"))
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Requisição inválida", http.StatusBadRequest)
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if len(idempotencyKey) > 255 {
+		writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.idempotency_key_too_long"), map[string]interface{}{"field": "Idempotency-Key", "max_length": 255})
 		return
 	}
 
-	if req.Amount <= 0 {
-		http.Error(w, "Valor deve ser maior que zero", http.StatusBadRequest)
+	discordID, ok := callerID(w, r, req.DiscordID)
+	if !ok {
+		return
+	}
+
+	if ok, wait := s.paymentUserLimiter.allow(discordID, time.Now()); !ok {
+		writeRateLimited(w, r, "discord_id", wait)
 		return
 	}
 
-	payment, err := s.paymentService.CreatePixPayment(service.CreatePixPaymentRequest{
-		DiscordID: req.DiscordID,
-		Username:  req.Username,
-		Amount:    req.Amount,
+	payment, err := s.paymentService.CreatePixPayment(r.Context(), service.CreatePixPaymentRequest{
+		DiscordID:      discordID,
+		Username:       req.Username,
+		Amount:         req.Amount,
+		IdempotencyKey: idempotencyKey,
 	})
 
+	if errors.Is(err, service.ErrIdempotencyKeyReused) {
+		writeError(w, r, http.StatusConflict, codeIdempotencyReused, t(r, "api.idempotency_key_reused"))
+		return
+	}
+	if errors.Is(err, service.ErrAmountOutOfRange) {
+		limits := s.paymentService.Limits()
+		writeErrorDetails(w, r, http.StatusBadRequest, codeAmountOutOfRange, errorMessage(r, err), map[string]interface{}{
+			"min_amount": limits.MinAmount,
+			"max_amount": limits.MaxAmount,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrTooManyPending) {
+		writeErrorDetails(w, r, http.StatusTooManyRequests, codeTooManyPending, errorMessage(r, err), map[string]interface{}{
+			"max_pending": s.paymentService.Limits().MaxPending,
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Erro ao criar pagamento: %v", err)
-		http.Error(w, "Erro ao criar pagamento", http.StatusInternalServerError)
+		writePaymentError(w, r, err)
 		return
 	}
 
+	if payment.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(payment)
+	json.NewEncoder(w).Encode(apiclient.Payment{
+		TransactionID:     payment.TransactionID,
+		Amount:            payment.Amount,
+		QRCode:            payment.QRCode,
+		QRCodeBase64:      payment.QRCodeBase64,
+		ExternalReference: payment.ExternalReference,
+		RequiresApproval:  payment.RequiresApproval,
+	})
 }
 
-func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+// handleAttachPaymentMessage registra a mensagem do bot com o QR Code, que é
+// editada quando o pagamento é confirmado, expira ou falha
+func (s *Server) handleAttachPaymentMessage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_param", "id"))
+		return
+	}
+
+	var req apiclient.AttachPaymentMessageRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	for _, required := range []struct{ field, value string }{
+		{"channel_id", req.ChannelID},
+		{"message_id", req.MessageID},
+	} {
+		if required.value == "" {
+			writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.field_required", required.field), map[string]interface{}{"field": required.field})
+			return
+		}
+	}
+
+	// O dono do PIX é quem pediu, não o que vier no corpo
+	discordID, ok := callerID(w, r, req.DiscordID)
+	if !ok {
+		return
+	}
+
+	err = s.paymentService.AttachDiscordMessage(id, discordID, repository.DiscordMessage{
+		GuildID:   req.GuildID,
+		ChannelID: req.ChannelID,
+		MessageID: req.MessageID,
+	})
+	if errors.Is(err, service.ErrPaymentNotFound) {
+		writeError(w, r, http.StatusNotFound, codeNotFound, errorMessage(r, err))
+		return
+	}
+	if errors.Is(err, service.ErrMessageAttached) {
+		writeError(w, r, http.StatusConflict, codeMessageAttached, errorMessage(r, err))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao associar mensagem ao PIX #%d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.attach_payment_message"))
 		return
 	}
 
-	var webhook struct {
-		Action string `json:"action"`
-		Data   struct {
-			ID string `json:"id"`
-		} `json:"data"`
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleApprovePayment libera um PIX retido porque o dono está sinalizado,
+// como o admin approve
+func (s *Server) handleApprovePayment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_param", "id"))
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
-		log.Printf("Erro ao decodificar webhook: %v", err)
-		http.Error(w, "Requisição inválida", http.StatusBadRequest)
+	transaction, err := s.paymentService.ApprovePayment(id, repository.AuditMeta{
+		Actor:  requestActor(r),
+		Source: repository.SourceAPI,
+		Reason: "pagamento aprovado",
+	})
+	switch {
+	case errors.Is(err, service.ErrPaymentNotFound):
+		writeError(w, r, http.StatusNotFound, codeNotFound, errorMessage(r, err))
+		return
+	case errors.Is(err, service.ErrNotAwaitingApproval):
+		writeError(w, r, http.StatusConflict, codeNotAwaitingApproval, errorMessage(r, err))
+		return
+	case err != nil:
+		log.Printf("Erro ao aprovar PIX #%d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.approve_payment"))
 		return
 	}
 
-	if webhook.Action == "payment.updated" || webhook.Action == "payment.created" {
-		if webhook.Data.ID != "" {
-			confirmed, err := s.paymentService.ConfirmPayment(webhook.Data.ID)
-			if err != nil {
-				log.Printf("Erro ao confirmar pagamento %s: %v", webhook.Data.ID, err)
-			} else if confirmed != nil {
-				log.Printf("✅ Pagamento confirmado! User: %s, Valor: R$ %.2f", confirmed.Username, confirmed.Amount)
-			}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newTransactionResponse(transaction))
+}
+
+// writePaymentError traduz falhas do Mercado Pago em status HTTP distintos
+// para que o bot saiba o que dizer ao usuário
+func writePaymentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case mercadopago.IsRateLimited(err):
+		var details map[string]interface{}
+		if apiErr, ok := mercadopago.AsAPIError(err); ok && apiErr.RetryAfter > 0 {
+			seconds := int(math.Ceil(apiErr.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			details = map[string]interface{}{"retry_after_seconds": seconds}
 		}
+		writeErrorDetails(w, r, http.StatusTooManyRequests, codeMercadoPagoLimited, t(r, "api.mercadopago_rate_limited"), details)
+	case mercadopago.IsUnavailable(err):
+		writeError(w, r, http.StatusServiceUnavailable, codeMercadoPagoDown, t(r, "api.mercadopago_unavailable"))
+	case mercadopago.IsInvalidAmount(err):
+		writeError(w, r, http.StatusUnprocessableEntity, codeMercadoPagoRejected, t(r, "api.mercadopago_rejected"))
+	case mercadopago.IsUnauthorized(err):
+		writeError(w, r, http.StatusBadGateway, codeMercadoPagoAuthError, t(r, "api.mercadopago_unauthorized"))
+	default:
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.create_payment"))
+	}
+}
+
+// handleWebhook só grava a notificação no inbox; o processamento acontece
+// em segundo plano. Se nem isso der certo, o Mercado Pago recebe 500 e
+// tenta de novo. Corpos que não são uma notificação recebem 400
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if ok, wait := s.webhookIPLimiter.allow(clientIP(r), time.Now()); !ok {
+		writeRateLimited(w, r, "ip", wait)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		log.Printf("Erro ao ler webhook: %v", err)
+		writeBodyError(w, r, err)
+		return
+	}
+
+	entry, err := s.webhookService.Receive(service.ProviderMercadoPago, body, r.Header)
+	if errors.Is(err, service.ErrInvalidWebhook) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
 	}
+	if err != nil {
+		log.Printf("Erro ao gravar webhook: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.store_webhook"))
+		return
+	}
+	log.Printf("Webhook %d recebido", entry.ID)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
@@ -150,37 +439,88 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
 	discordID := r.URL.Query().Get("discord_id")
 	if discordID == "" {
-		http.Error(w, "discord_id é obrigatório", http.StatusBadRequest)
+		writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.field_required", "discord_id"), map[string]interface{}{"field": "discord_id"})
 		return
 	}
 
-	balance, err := s.walletService.GetUserBalance(discordID)
+	summary, err := s.walletService.GetUserSummary(discordID)
 	if err != nil {
 		log.Printf("Erro ao buscar saldo: %v", err)
-		http.Error(w, "Erro ao buscar saldo", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.balance"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiclient.Balance{
+		Balance:   summary.Contributed,
+		Spent:     summary.Spent,
+		Available: summary.Available,
+	})
+}
+
+func (s *Server) handleGetTotal(w http.ResponseWriter, r *http.Request) {
+	totals, err := s.walletService.GetTotals()
+	if err != nil {
+		log.Printf("Erro ao buscar saldo total: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.total"))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]float64{"balance": balance})
+	json.NewEncoder(w).Encode(apiclient.Total{
+		Raised:       totals.Raised,
+		Spent:        totals.Spent,
+		Available:    totals.Available,
+		Pending:      totals.Pending,
+		Contributors: totals.Contributors,
+	})
 }
 
 func (s *Server) handleGetRanking(w http.ResponseWriter, r *http.Request) {
-	limitStr := r.URL.Query().Get("limit")
+	q := r.URL.Query()
+
 	limit := 10
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	period, err := service.ParseRankingPeriod(q.Get("period"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	}
+
+	query := service.RankingQuery{
+		Period:    period,
+		Limit:     limit,
+		DiscordID: q.Get("discord_id"),
+	}
+	if query.From, query.To, err = parsePeriodParams(q.Get("from"), q.Get("to")); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
 	}
 
-	ranking, err := s.walletService.GetRanking(limit)
+	ranking, err := s.walletService.GetRanking(query, time.Now())
 	if err != nil {
 		log.Printf("Erro ao buscar ranking: %v", err)
-		http.Error(w, "Erro ao buscar ranking", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.ranking"))
 		return
 	}
 
+	response := apiclient.Ranking{
+		Period:  string(ranking.Period),
+		From:    ranking.From,
+		To:      ranking.To,
+		Entries: make([]apiclient.RankingEntry, 0, len(ranking.Entries)),
+	}
+	for _, e := range ranking.Entries {
+		response.Entries = append(response.Entries, apiclient.RankingEntry(e))
+	}
+	if ranking.Caller != nil {
+		caller := apiclient.RankingEntry(*ranking.Caller)
+		response.Caller = &caller
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ranking)
+	json.NewEncoder(w).Encode(response)
 }
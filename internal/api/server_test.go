@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+)
+
+// testServer monta o roteamento completo sem serviços; serve para as
+// respostas que saem antes de chegar neles
+func testServer() http.Handler {
+	s := New("0", "segredo", nil, PaymentRateLimits{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	return s.server.Handler
+}
+
+func TestCreatePaymentRequiresTheCaller(t *testing.T) {
+	handler := testServer()
+
+	tests := []struct {
+		name   string
+		token  string
+		caller string
+		body   string
+		want   int
+	}{
+		{"sem token", "", "1", `{"amount": 10}`, http.StatusUnauthorized},
+		{"sem o membro", "segredo", "", `{"amount": 10}`, http.StatusUnauthorized},
+		{"PIX para outro membro", "segredo", "1", `{"discord_id": "2", "amount": 10}`, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"/api/v1/payments/create", "/api/payments/create"} {
+				req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				if tt.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tt.token)
+				}
+				if tt.caller != "" {
+					req.Header.Set(apiclient.HeaderDiscordUserID, tt.caller)
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				if rec.Code != tt.want {
+					t.Errorf("%s: status %d, quer %d", path, rec.Code, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorsUseTheEnvelope(t *testing.T) {
+	handler := testServer()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		want     int
+		wantCode string
+	}{
+		{"rota inexistente", http.MethodGet, "/api/v1/nada", "", http.StatusNotFound, codeNotFound},
+		{"JSON inválido", http.MethodPost, "/api/v1/wallet/transfer", "{", http.StatusBadRequest, codeInvalidRequest},
+		{"corpo grande demais", http.MethodPost, "/api/v1/wallet/transfer", `{"amount": "` + strings.Repeat("a", maxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, codeBodyTooLarge},
+		{"sem token", http.MethodGet, "/api/v1/admin/audit", "", http.StatusUnauthorized, codeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Accept-Language", "en")
+			if tt.wantCode != codeUnauthorized {
+				req.Header.Set("Authorization", "Bearer segredo")
+				req.Header.Set(apiclient.HeaderDiscordUserID, "1")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status %d, quer %d", rec.Code, tt.want)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if lang := rec.Header().Get("Content-Language"); lang != "en" {
+				t.Errorf("Content-Language = %q, quer en", lang)
+			}
+
+			var body apiclient.ErrorBody
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("corpo sem envelope: %v", err)
+			}
+			if body.Code != tt.wantCode || body.Message == "" {
+				t.Errorf("envelope = %+v, quer code %s com mensagem", body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRouterMethodsAndLegacyPaths(t *testing.T) {
+	handler := testServer()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/wallet/transfer", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("método errado: status %d, quer 405", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); !strings.Contains(allow, http.MethodPost) {
+		t.Errorf("Allow = %q, quer POST", allow)
+	}
+	var body apiclient.ErrorBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Code != codeMethodNotAllowed {
+		t.Errorf("envelope = %+v (%v), quer %s", body, err, codeMethodNotAllowed)
+	}
+
+	// O caminho antigo responde como o novo, avisando do substituto
+	req = httptest.NewRequest(http.MethodPost, "/api/wallet/transfer", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("caminho antigo: status %d, quer 401", rec.Code)
+	}
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Error("caminho antigo sem Deprecation")
+	}
+	if link := rec.Header().Get("Link"); link != `</api/v1/wallet/transfer>; rel="successor-version"` {
+		t.Errorf("Link = %q", link)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	var req apiclient.SubscribeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	discordID, ok := callerID(w, r, req.DiscordID)
+	if !ok {
+		return
+	}
+
+	sub, err := s.subscriptionService.Subscribe(discordID, req.Username, req.Amount, req.DueDay)
+	if errors.Is(err, service.ErrInvalidAmount) || errors.Is(err, service.ErrInvalidDueDay) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao salvar mensalidade: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.save_subscription"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiclient.Subscription{
+		DiscordID: discordID,
+		Amount:    sub.Amount,
+		DueDay:    sub.DueDay,
+		Active:    sub.Active,
+	})
+}
+
+func (s *Server) handleCancelSubscription(w http.ResponseWriter, r *http.Request) {
+	var req apiclient.CancelSubscriptionRequest
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	discordID, ok := callerID(w, r, req.DiscordID)
+	if !ok {
+		return
+	}
+
+	err := s.subscriptionService.Cancel(discordID)
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		writeError(w, r, http.StatusNotFound, codeNotFound, errorMessage(r, err))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao cancelar mensalidade: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.cancel_subscription"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSubscriptionStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.subscriptionService.Status(time.Now())
+	if err != nil {
+		log.Printf("Erro ao buscar mensalidades: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.list_subscriptions"))
+		return
+	}
+
+	response := apiclient.SubscriptionStatus{
+		Period:  status.Period,
+		Members: make([]apiclient.SubscriptionMember, 0, len(status.Members)),
+	}
+	for _, m := range status.Members {
+		response.Members = append(response.Members, apiclient.SubscriptionMember(m))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	var req apiclient.TransferRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.FromDiscordID == "" || req.ToDiscordID == "" {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_request"))
+		return
+	}
+
+	// Só o próprio membro tira dinheiro da carteira dele
+	caller, ok := callerOwns(w, r, req.FromDiscordID)
+	if !ok {
+		return
+	}
+
+	// O crédito usa a chave com um sufixo, então sobra espaço para ele
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if len(idempotencyKey) > 240 {
+		writeErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.idempotency_key_too_long"), map[string]interface{}{"field": "Idempotency-Key", "max_length": 240})
+		return
+	}
+
+	transfer, err := s.transferService.Transfer(service.TransferRequest{
+		FromDiscordID:  req.FromDiscordID,
+		FromUsername:   req.FromUsername,
+		ToDiscordID:    req.ToDiscordID,
+		ToUsername:     req.ToUsername,
+		Amount:         req.Amount,
+		IdempotencyKey: idempotencyKey,
+	}, repository.AuditMeta{
+		Actor:  caller.DiscordID,
+		Source: repository.SourceAPI,
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrTransferSameUser):
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	case errors.Is(err, service.ErrInsufficientBalance):
+		writeError(w, r, http.StatusUnprocessableEntity, codeInsufficientFunds, errorMessage(r, err))
+		return
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		writeError(w, r, http.StatusConflict, codeIdempotencyReused, t(r, "api.idempotency_key_reused"))
+		return
+	case err != nil:
+		log.Printf("Erro ao transferir: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.transfer"))
+		return
+	}
+
+	if transfer.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiclient.Transfer{
+		DebitTransactionID:  transfer.DebitTransactionID,
+		CreditTransactionID: transfer.CreditTransactionID,
+		Amount:              transfer.Amount,
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+func newWebhookResponse(e *repository.InboxEntry) apiclient.WebhookEntry {
+	return apiclient.WebhookEntry{
+		ID:          e.ID,
+		Provider:    e.Provider,
+		Body:        string(e.Body),
+		Headers:     e.Headers,
+		PayloadHash: e.PayloadHash,
+		Status:      string(e.Status),
+		Attempts:    e.Attempts,
+		LastError:   e.LastError,
+		AvailableAt: e.AvailableAt,
+		ReceivedAt:  e.ReceivedAt,
+		ProcessedAt: e.ProcessedAt,
+	}
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter, err := service.ParseInboxFilter(q.Get("status"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, errorMessage(r, err))
+		return
+	}
+	filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+
+	entries, err := s.webhookService.List(filter)
+	if err != nil {
+		log.Printf("Erro ao listar webhooks: %v", err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.list_webhooks"))
+		return
+	}
+
+	response := make([]apiclient.WebhookEntry, 0, len(entries))
+	for i := range entries {
+		response = append(response, newWebhookResponse(&entries[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleReplayWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, t(r, "api.invalid_param", "id"))
+		return
+	}
+
+	entry, err := s.webhookService.Replay(id)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		writeError(w, r, http.StatusNotFound, codeNotFound, errorMessage(r, err))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao reprocessar webhook %d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, codeInternal, t(r, "api.internal.replay_webhook"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newWebhookResponse(entry))
+}
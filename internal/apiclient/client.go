@@ -0,0 +1,425 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 20 * time.Second
+
+type Options struct {
+	// Token enviado como "Authorization: Bearer" (exigido nas rotas
+	// administrativas)
+	Token   string
+	Timeout time.Duration
+}
+
+// Cabeçalhos com o membro do Discord em nome de quem o bot chama a API. As
+// rotas administrativas conferem as capacidades dele (sem eles vale só o
+// token); as rotas em que um membro altera os próprios dados os exigem
+const (
+	HeaderDiscordUserID  = "X-Discord-User-ID"
+	HeaderDiscordGuildID = "X-Discord-Guild-ID"
+	HeaderDiscordRoles   = "X-Discord-Roles"
+)
+
+// Client fala com a API HTTP da vaquinha. Erros de status viram *Error.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	caller     *Caller
+}
+
+// Caller é o membro do Discord que pediu a operação
+type Caller struct {
+	DiscordID string
+	GuildID   string
+	RoleIDs   []string
+}
+
+func New(baseURL string, opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      opts.Token,
+		httpClient: &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+// As devolve um Client que faz as chamadas em nome do membro
+func (c *Client) As(caller Caller) *Client {
+	clone := *c
+	clone.caller = &caller
+	return &clone
+}
+
+// CreatePayment gera uma cobrança PIX. Repetir a mesma idempotencyKey
+// devolve o pagamento original com Replayed = true.
+func (c *Client) CreatePayment(req CreatePaymentRequest, idempotencyKey string) (*Payment, error) {
+	var payment Payment
+	header, err := c.doJSON(http.MethodPost, "/api/v1/payments/create", nil, req, idempotencyHeader(idempotencyKey), &payment)
+	if err != nil {
+		return nil, err
+	}
+	payment.Replayed = header.Get("Idempotent-Replayed") == "true"
+	return &payment, nil
+}
+
+// AttachPaymentMessage registra a mensagem com o QR Code do PIX, que a API
+// edita quando o pagamento muda de status
+func (c *Client) AttachPaymentMessage(transactionID int64, req AttachPaymentMessageRequest) error {
+	_, err := c.doJSON(http.MethodPut, fmt.Sprintf("/api/v1/payments/%d/discord-message", transactionID), nil, req, nil, nil)
+	return err
+}
+
+func (c *Client) Balance(discordID string) (*Balance, error) {
+	var balance Balance
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/wallet/balance", url.Values{"discord_id": {discordID}}, nil, nil, &balance); err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+func (c *Client) Total() (*Total, error) {
+	var total Total
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/wallet/total", nil, nil, nil, &total); err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
+func (c *Client) Ranking(params RankingParams) (*Ranking, error) {
+	query := url.Values{}
+	setString(query, "period", params.Period)
+	setString(query, "from", params.From)
+	setString(query, "to", params.To)
+	setString(query, "discord_id", params.DiscordID)
+	setInt(query, "limit", int64(params.Limit))
+
+	var ranking Ranking
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/wallet/ranking", query, nil, nil, &ranking); err != nil {
+		return nil, err
+	}
+	return &ranking, nil
+}
+
+func (c *Client) Transfer(req TransferRequest, idempotencyKey string) (*Transfer, error) {
+	var transfer Transfer
+	header, err := c.doJSON(http.MethodPost, "/api/v1/wallet/transfer", nil, req, idempotencyHeader(idempotencyKey), &transfer)
+	if err != nil {
+		return nil, err
+	}
+	transfer.Replayed = header.Get("Idempotent-Replayed") == "true"
+	return &transfer, nil
+}
+
+func (c *Client) Subscribe(req SubscribeRequest) (*Subscription, error) {
+	var sub Subscription
+	if _, err := c.doJSON(http.MethodPost, "/api/v1/subscriptions", nil, req, nil, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (c *Client) CancelSubscription(discordID string) error {
+	_, err := c.doJSON(http.MethodPost, "/api/v1/subscriptions/cancel", nil, CancelSubscriptionRequest{DiscordID: discordID}, nil, nil)
+	return err
+}
+
+func (c *Client) SubscriptionStatus() (*SubscriptionStatus, error) {
+	var status SubscriptionStatus
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/subscriptions/status", nil, nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (c *Client) Locale(discordID, guildID string) (*LocaleSetting, error) {
+	query := url.Values{"discord_id": {discordID}}
+	setString(query, "guild_id", guildID)
+
+	var setting LocaleSetting
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/locale", query, nil, nil, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (c *Client) SetUserLocale(discordID, locale string) (*LocaleSetting, error) {
+	return c.setLocale("/api/v1/locale/users/"+url.PathEscape(discordID), locale)
+}
+
+func (c *Client) SetGuildLocale(guildID, locale string) (*LocaleSetting, error) {
+	return c.setLocale("/api/v1/locale/guilds/"+url.PathEscape(guildID), locale)
+}
+
+func (c *Client) setLocale(path, locale string) (*LocaleSetting, error) {
+	var setting LocaleSetting
+	if _, err := c.doJSON(http.MethodPut, path, nil, SetLocaleRequest{Locale: locale}, nil, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// Permissions lista as capacidades concedidas aos cargos do servidor
+func (c *Client) Permissions(guildID string) ([]RolePermission, error) {
+	var permissions []RolePermission
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/admin/guilds/"+url.PathEscape(guildID)+"/permissions", nil, nil, nil, &permissions); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (c *Client) GrantPermission(guildID, roleID, capability string) (*PermissionChange, error) {
+	return c.changePermission(http.MethodPut, guildID, roleID, capability)
+}
+
+func (c *Client) RevokePermission(guildID, roleID, capability string) (*PermissionChange, error) {
+	return c.changePermission(http.MethodDelete, guildID, roleID, capability)
+}
+
+func (c *Client) GuildSettings(guildID string) (*GuildSettings, error) {
+	var settings GuildSettings
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/admin/guilds/"+url.PathEscape(guildID)+"/settings", nil, nil, nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (c *Client) UpdateGuildSettings(guildID string, req UpdateGuildSettingsRequest) (*GuildSettings, error) {
+	var settings GuildSettings
+	if _, err := c.doJSON(http.MethodPatch, "/api/v1/admin/guilds/"+url.PathEscape(guildID)+"/settings", nil, req, nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (c *Client) changePermission(method, guildID, roleID, capability string) (*PermissionChange, error) {
+	path := "/api/v1/admin/guilds/" + url.PathEscape(guildID) + "/roles/" + url.PathEscape(roleID) +
+		"/permissions/" + url.PathEscape(capability)
+
+	var change PermissionChange
+	if _, err := c.doJSON(method, path, nil, nil, nil, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (c *Client) AuditEvents(params AuditParams) ([]AuditEvent, error) {
+	query := url.Values{}
+	setString(query, "actor", params.Actor)
+	setString(query, "source", params.Source)
+	setString(query, "action", params.Action)
+	setString(query, "entity_type", params.EntityType)
+	setInt(query, "entity_id", params.EntityID)
+	setInt(query, "transaction_id", params.TransactionID)
+	setString(query, "from", params.From)
+	setString(query, "to", params.To)
+	setInt(query, "limit", int64(params.Limit))
+
+	var events []AuditEvent
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/admin/audit", query, nil, nil, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (c *Client) OutboxEvents(params OutboxParams) ([]OutboxEvent, error) {
+	query := url.Values{}
+	setString(query, "status", params.Status)
+	setInt(query, "limit", int64(params.Limit))
+
+	var events []OutboxEvent
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/admin/outbox", query, nil, nil, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (c *Client) RetryOutboxEvent(id int64) (*OutboxEvent, error) {
+	var event OutboxEvent
+	if _, err := c.doJSON(http.MethodPost, "/api/v1/admin/outbox/"+strconv.FormatInt(id, 10)+"/retry", nil, nil, nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (c *Client) Webhooks(params WebhookParams) ([]WebhookEntry, error) {
+	query := url.Values{}
+	setString(query, "status", params.Status)
+	setInt(query, "limit", int64(params.Limit))
+
+	var entries []WebhookEntry
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/admin/webhooks", query, nil, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *Client) ReplayWebhook(id int64) (*WebhookEntry, error) {
+	var entry WebhookEntry
+	if _, err := c.doJSON(http.MethodPost, "/api/v1/admin/webhooks/"+strconv.FormatInt(id, 10)+"/replay", nil, nil, nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *Client) AdjustBalance(discordID string, req AdjustBalanceRequest) (*Transaction, error) {
+	var transaction Transaction
+	if _, err := c.doJSON(http.MethodPost, "/api/v1/admin/wallets/"+url.PathEscape(discordID)+"/adjustments", nil, req, nil, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// ApprovePayment libera um PIX que aguarda aprovação por causa de uma
+// contestação
+func (c *Client) ApprovePayment(transactionID int64) (*Transaction, error) {
+	var transaction Transaction
+	if _, err := c.doJSON(http.MethodPost, "/api/v1/admin/payments/"+strconv.FormatInt(transactionID, 10)+"/approve", nil, nil, nil, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (c *Client) CreatePurchase(req CreatePurchaseRequest) (*Purchase, error) {
+	var purchase Purchase
+	if _, err := c.doJSON(http.MethodPost, "/api/v1/admin/purchases", nil, req, nil, &purchase); err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+func (c *Client) Purchase(id int64) (*Purchase, error) {
+	var purchase Purchase
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/admin/purchases/"+strconv.FormatInt(id, 10), nil, nil, nil, &purchase); err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+func (c *Client) Purchases(limit int) ([]Purchase, error) {
+	query := url.Values{}
+	setInt(query, "limit", int64(limit))
+
+	var purchases []Purchase
+	if _, err := c.doJSON(http.MethodGet, "/api/v1/admin/purchases", query, nil, nil, &purchases); err != nil {
+		return nil, err
+	}
+	return purchases, nil
+}
+
+// ExportReport devolve o extrato sem ler o corpo, para que ele possa ser
+// repassado em streaming
+func (c *Client) ExportReport(params ExportParams) (*Export, error) {
+	query := url.Values{}
+	setString(query, "format", params.Format)
+	setString(query, "from", params.From)
+	setString(query, "to", params.To)
+
+	resp, err := c.do(http.MethodGet, "/api/v1/reports/export", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Export{
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        resp.Body,
+	}, nil
+}
+
+// do executa a requisição e transforma status >= 400 em *Error. Em caso de
+// sucesso quem chama fecha o corpo.
+func (c *Client) do(method, path string, query url.Values, body interface{}, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao serializar requisição: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.caller != nil {
+		req.Header.Set(HeaderDiscordUserID, c.caller.DiscordID)
+		req.Header.Set(HeaderDiscordGuildID, c.caller.GuildID)
+		req.Header.Set(HeaderDiscordRoles, strings.Join(c.caller.RoleIDs, ","))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao chamar %s %s: %w", method, path, err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, newError(resp)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) doJSON(method, path string, query url.Values, body interface{}, header http.Header, out interface{}) (http.Header, error) {
+	resp, err := c.do(method, path, query, body, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar resposta de %s: %w", path, err)
+		}
+	}
+
+	return resp.Header, nil
+}
+
+func idempotencyHeader(key string) http.Header {
+	if key == "" {
+		return nil
+	}
+	return http.Header{"Idempotency-Key": {key}}
+}
+
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setInt(query url.Values, key string, value int64) {
+	if value != 0 {
+		query.Set(key, strconv.FormatInt(value, 10))
+	}
+}
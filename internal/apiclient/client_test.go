@@ -0,0 +1,97 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreatePaymentSendsTheCaller(t *testing.T) {
+	var got *http.Request
+	var body CreatePaymentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Idempotent-Replayed", "true")
+		json.NewEncoder(w).Encode(Payment{TransactionID: 7, Amount: 10})
+	}))
+	defer server.Close()
+
+	client := New(server.URL+"/", Options{Token: "segredo"})
+	payment, err := client.As(Caller{DiscordID: "1", GuildID: "9", RoleIDs: []string{"a", "b"}}).
+		CreatePayment(CreatePaymentRequest{Username: "ana", Amount: 10}, "discord-message-5")
+	if err != nil {
+		t.Fatalf("CreatePayment = %v", err)
+	}
+
+	if got.Method != http.MethodPost || got.URL.Path != "/api/v1/payments/create" {
+		t.Errorf("requisição = %s %s", got.Method, got.URL.Path)
+	}
+	for header, want := range map[string]string{
+		"Authorization":      "Bearer segredo",
+		"Idempotency-Key":    "discord-message-5",
+		HeaderDiscordUserID:  "1",
+		HeaderDiscordGuildID: "9",
+		HeaderDiscordRoles:   "a,b",
+	} {
+		if v := got.Header.Get(header); v != want {
+			t.Errorf("%s = %q, quer %q", header, v, want)
+		}
+	}
+	if body.Amount != 10 || body.Username != "ana" {
+		t.Errorf("corpo = %+v", body)
+	}
+	if payment.TransactionID != 7 || !payment.Replayed {
+		t.Errorf("pagamento = %+v, quer #7 repetido", payment)
+	}
+
+	// As não altera o cliente original
+	if client.caller != nil {
+		t.Error("As alterou o cliente original")
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantCode    string
+		wantMessage string
+	}{
+		{"envelope da API", "application/json", `{"code": "rate_limited", "message": "Calma", "details": {"scope": "ip"}}`, "rate_limited", "Calma"},
+		{"resposta de proxy", "text/plain", "Bad Gateway\n", "", "Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Header().Set("Retry-After", "3")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := New(server.URL, Options{}).Total()
+			apiErr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("erro = %T %v, quer *Error", err, err)
+			}
+			if apiErr.StatusCode != http.StatusTooManyRequests || StatusCode(err) != http.StatusTooManyRequests {
+				t.Errorf("StatusCode = %d", apiErr.StatusCode)
+			}
+			if apiErr.Code != tt.wantCode || ErrorCode(err) != tt.wantCode {
+				t.Errorf("Code = %q, quer %q", apiErr.Code, tt.wantCode)
+			}
+			if apiErr.Message != tt.wantMessage {
+				t.Errorf("Message = %q, quer %q", apiErr.Message, tt.wantMessage)
+			}
+			if apiErr.RetryAfter != 3*time.Second {
+				t.Errorf("RetryAfter = %v, quer 3s", apiErr.RetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error é uma resposta de erro (status >= 400) da API
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    map[string]interface{}
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API respondeu com status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API respondeu com status %d: %s", e.StatusCode, e.Message)
+}
+
+func newError(resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	// Respostas que não vêm da API (proxy, balanceador) não têm o envelope
+	var envelope ErrorBody
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Code != "" {
+		apiErr.Code = envelope.Code
+		apiErr.Message = envelope.Message
+		apiErr.Details = envelope.Details
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// StatusCode devolve o status HTTP de um erro da API, ou 0 quando o erro
+// não veio de uma resposta (rede, timeout, JSON inválido)
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// ErrorCode devolve o código do envelope de erro, ou "" se não houver
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
@@ -0,0 +1,332 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Tipos trafegados pela API HTTP. O servidor (internal/api) codifica as
+// respostas com estes mesmos tipos, então cliente e servidor não divergem.
+
+type CreatePaymentRequest struct {
+	DiscordID string  `json:"discord_id"`
+	Username  string  `json:"username"`
+	Amount    float64 `json:"amount"`
+}
+
+type Payment struct {
+	TransactionID     int64   `json:"transaction_id"`
+	Amount            float64 `json:"amount"`
+	QRCode            string  `json:"qr_code"`
+	QRCodeBase64      string  `json:"qr_code_base64"`
+	ExternalReference string  `json:"external_reference"`
+
+	// RequiresApproval indica que o valor só entra no saldo depois de
+	// aprovado por um administrador (usuário sinalizado por contestação)
+	RequiresApproval bool `json:"requires_approval"`
+
+	// Replayed indica que a Idempotency-Key já tinha gerado este pagamento
+	Replayed bool `json:"-"`
+}
+
+// AttachPaymentMessageRequest informa a mensagem do Discord com o QR Code de
+// um PIX; GuildID fica vazio em mensagem privada
+type AttachPaymentMessageRequest struct {
+	DiscordID string `json:"discord_id"`
+	GuildID   string `json:"guild_id,omitempty"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+}
+
+type Balance struct {
+	Balance   float64 `json:"balance"`
+	Spent     float64 `json:"spent"`
+	Available float64 `json:"available"`
+}
+
+type Total struct {
+	Raised       float64 `json:"raised"`
+	Spent        float64 `json:"spent"`
+	Available    float64 `json:"available"`
+	Pending      float64 `json:"pending"`
+	Contributors int     `json:"contributors"`
+}
+
+// RankingParams filtra o ranking; From e To usam AAAA-MM-DD (To inclusivo)
+type RankingParams struct {
+	Period    string
+	From      string
+	To        string
+	Limit     int
+	DiscordID string
+}
+
+type RankingEntry struct {
+	Rank      int     `json:"rank"`
+	DiscordID string  `json:"discord_id"`
+	Username  string  `json:"username"`
+	Balance   float64 `json:"balance"`
+}
+
+type Ranking struct {
+	Period  string         `json:"period"`
+	From    *time.Time     `json:"from,omitempty"`
+	To      *time.Time     `json:"to,omitempty"`
+	Entries []RankingEntry `json:"entries"`
+	Caller  *RankingEntry  `json:"caller,omitempty"`
+}
+
+type TransferRequest struct {
+	FromDiscordID string  `json:"from_discord_id"`
+	FromUsername  string  `json:"from_username"`
+	ToDiscordID   string  `json:"to_discord_id"`
+	ToUsername    string  `json:"to_username"`
+	Amount        float64 `json:"amount"`
+}
+
+type Transfer struct {
+	DebitTransactionID  int64   `json:"debit_transaction_id"`
+	CreditTransactionID int64   `json:"credit_transaction_id"`
+	Amount              float64 `json:"amount"`
+
+	Replayed bool `json:"-"`
+}
+
+type SubscribeRequest struct {
+	DiscordID string  `json:"discord_id"`
+	Username  string  `json:"username"`
+	Amount    float64 `json:"amount"`
+	DueDay    int     `json:"due_day"`
+}
+
+type Subscription struct {
+	DiscordID string  `json:"discord_id"`
+	Amount    float64 `json:"amount"`
+	DueDay    int     `json:"due_day"`
+	Active    bool    `json:"active"`
+}
+
+type CancelSubscriptionRequest struct {
+	DiscordID string `json:"discord_id"`
+}
+
+type SubscriptionMember struct {
+	DiscordID string  `json:"discord_id"`
+	Username  string  `json:"username"`
+	Amount    float64 `json:"amount"`
+	DueDay    int     `json:"due_day"`
+	Paid      float64 `json:"paid"`
+	UpToDate  bool    `json:"up_to_date"`
+	Overdue   bool    `json:"overdue"`
+}
+
+type SubscriptionStatus struct {
+	Period  string               `json:"period"`
+	Members []SubscriptionMember `json:"members"`
+}
+
+// LocaleSetting é o idioma configurado; Source diz se veio do usuário
+// ("user") ou do servidor ("guild"). Sem configuração os dois vêm vazios
+type LocaleSetting struct {
+	Locale string `json:"locale"`
+	Source string `json:"source"`
+}
+
+// SetLocaleRequest com Locale vazio volta ao idioma automático
+type SetLocaleRequest struct {
+	Locale string `json:"locale"`
+}
+
+// Capacidades administrativas aceitas pela API, na ordem mostrada aos
+// usuários
+const (
+	CapabilityAudit       = "audit"
+	CapabilityExport      = "export"
+	CapabilityPurchase    = "purchase"
+	CapabilityAdjust      = "adjust"
+	CapabilityRefund      = "refund"
+	CapabilityOperations  = "operations"
+	CapabilitySettings    = "settings"
+	CapabilityPermissions = "permissions"
+)
+
+var Capabilities = []string{
+	CapabilityAudit,
+	CapabilityExport,
+	CapabilityPurchase,
+	CapabilityAdjust,
+	CapabilityRefund,
+	CapabilityOperations,
+	CapabilitySettings,
+	CapabilityPermissions,
+}
+
+type RolePermission struct {
+	GuildID    string    `json:"guild_id"`
+	RoleID     string    `json:"role_id"`
+	Capability string    `json:"capability"`
+	GrantedBy  string    `json:"granted_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PermissionChange diz se a concessão ou remoção mudou algo: Changed é false
+// quando o cargo já tinha (ou já não tinha) a capacidade
+type PermissionChange struct {
+	GuildID    string `json:"guild_id"`
+	RoleID     string `json:"role_id"`
+	Capability string `json:"capability"`
+	Changed    bool   `json:"changed"`
+}
+
+// GuildSettings são as configurações do bot em um servidor. Sem
+// configuração a API devolve os padrões (prefixo "!", todos os canais)
+type GuildSettings struct {
+	GuildID               string     `json:"guild_id"`
+	CommandPrefix         string     `json:"command_prefix"`
+	AllowedChannelIDs     []string   `json:"allowed_channel_ids"`
+	AnnouncementChannelID string     `json:"announcement_channel_id"`
+	UpdatedBy             string     `json:"updated_by,omitempty"`
+	UpdatedAt             *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateGuildSettingsRequest muda só os campos presentes. Lista de canais
+// vazia libera todos; canal de anúncios vazio desliga os anúncios
+type UpdateGuildSettingsRequest struct {
+	CommandPrefix         *string   `json:"command_prefix,omitempty"`
+	AllowedChannelIDs     *[]string `json:"allowed_channel_ids,omitempty"`
+	AnnouncementChannelID *string   `json:"announcement_channel_id,omitempty"`
+}
+
+// AuditParams filtra os eventos de auditoria; From e To usam AAAA-MM-DD
+// ou RFC 3339
+type AuditParams struct {
+	Actor         string
+	Source        string
+	Action        string
+	EntityType    string
+	EntityID      int64
+	TransactionID int64
+	From          string
+	To            string
+	Limit         int
+}
+
+type AuditEvent struct {
+	ID          int64           `json:"id"`
+	Action      string          `json:"action"`
+	Actor       string          `json:"actor"`
+	Source      string          `json:"source"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    int64           `json:"entity_id"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	PayloadHash string          `json:"payload_hash,omitempty"`
+	Reason      string          `json:"reason,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type OutboxParams struct {
+	// Status: stuck (padrão), pending, dead ou done
+	Status string
+	Limit  int
+}
+
+type OutboxEvent struct {
+	ID          int64           `json:"id"`
+	Topic       string          `json:"topic"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    int64           `json:"entity_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	Handled     []string        `json:"handled"`
+	AvailableAt time.Time       `json:"available_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+type WebhookParams struct {
+	// Status: all (padrão), stuck, pending, dead ou done
+	Status string
+	Limit  int
+}
+
+type WebhookEntry struct {
+	ID          int64               `json:"id"`
+	Provider    string              `json:"provider"`
+	Body        string              `json:"body"`
+	Headers     map[string][]string `json:"headers"`
+	PayloadHash string              `json:"payload_hash"`
+	Status      string              `json:"status"`
+	Attempts    int                 `json:"attempts"`
+	LastError   string              `json:"last_error,omitempty"`
+	AvailableAt time.Time           `json:"available_at"`
+	ReceivedAt  time.Time           `json:"received_at"`
+	ProcessedAt *time.Time          `json:"processed_at"`
+}
+
+// AdjustBalanceRequest credita (Amount positivo) ou debita (negativo) a
+// carteira de um membro; o motivo é obrigatório
+type AdjustBalanceRequest struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+type Transaction struct {
+	ID          int64      `json:"id"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	Type        string     `json:"type"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+}
+
+type CreatePurchaseRequest struct {
+	Title     string  `json:"title"`
+	Amount    float64 `json:"amount"`
+	SplitMode string  `json:"split_mode,omitempty"`
+	Actor     string  `json:"actor,omitempty"`
+}
+
+type PurchaseShare struct {
+	WalletID        int64   `json:"wallet_id"`
+	DiscordID       string  `json:"discord_id"`
+	Username        string  `json:"username"`
+	Amount          float64 `json:"amount"`
+	AvailableBefore float64 `json:"available_before"`
+}
+
+type Purchase struct {
+	ID        int64           `json:"id"`
+	Title     string          `json:"title"`
+	Amount    float64         `json:"amount"`
+	SplitMode string          `json:"split_mode"`
+	CreatedBy string          `json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+	Shares    []PurchaseShare `json:"shares,omitempty"`
+}
+
+// ExportParams escolhe o formato (csv, ofx ou json) e o período em
+// AAAA-MM-DD, com a data final inclusiva
+type ExportParams struct {
+	Format string
+	From   string
+	To     string
+}
+
+// Export é o extrato em streaming; quem chama fecha Body
+type Export struct {
+	ContentType string
+	Body        io.ReadCloser
+}
+
+// ErrorBody é o corpo de toda resposta de erro da API. Code é estável e
+// serve para decidir o que fazer; Message é texto para pessoas.
+type ErrorBody struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
@@ -0,0 +1,134 @@
+// Package app monta os processos da Família Steam a partir da configuração.
+// cmd/api e cmd/bot rodam cada parte sozinha; cmd/app roda as duas juntas.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/api"
+	"github.com/mateus/familia-steam/internal/bot"
+	"github.com/mateus/familia-steam/internal/config"
+	"github.com/mateus/familia-steam/internal/mercadopago"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+// API é o servidor HTTP junto com os trabalhos em segundo plano: agendador
+// de mensalidades, dispatcher do outbox, worker do inbox de webhooks e
+// polling dos PIX pendentes. Com mais de um processo da API todos rodam em
+// todos: cada cobrança do mês é reivindicada no banco, cada evento ou webhook
+// é reservado por um só e sincronizar um PIX duas vezes não muda nada.
+type API struct {
+	server         *api.Server
+	payments       *service.PaymentService
+	pollInterval   time.Duration
+	subscriptions  *service.SubscriptionService
+	outbox         *service.OutboxService
+	webhooks       *service.WebhookService
+	backgroundCtx  context.Context
+	stopBackground context.CancelFunc
+}
+
+func NewAPI(cfg *config.Config, database *sql.DB, notifier service.Notifier) (*API, error) {
+	userRepo := repository.NewUserRepository(database)
+	walletRepo := repository.NewWalletRepository(database)
+	txRepo := repository.NewTransactionRepository(database)
+	auditRepo := repository.NewAuditRepository(database)
+	subRepo := repository.NewSubscriptionRepository(database)
+	purchaseRepo := repository.NewPurchaseRepository(database)
+	outboxRepo := repository.NewOutboxRepository(database)
+	inboxRepo := repository.NewWebhookInboxRepository(database)
+	localeRepo := repository.NewLocaleRepository(database)
+	permissionRepo := repository.NewPermissionRepository(database)
+	guildSettingsRepo := repository.NewGuildSettingsRepository(database)
+
+	mpClient := mercadopago.NewClient(cfg.MercadoPagoToken, cfg.MercadoPagoOptions())
+
+	paymentService := service.NewPaymentService(mpClient, txRepo, userRepo, walletRepo, cfg.PaymentLimits())
+	walletService := service.NewWalletService(userRepo, walletRepo, txRepo, purchaseRepo)
+	auditService := service.NewAuditService(auditRepo)
+	localeService := service.NewLocaleService(localeRepo)
+	permissionService := service.NewPermissionService(permissionRepo, cfg.AdminDiscordIDs)
+	guildSettingsService := service.NewGuildSettingsService(guildSettingsRepo)
+	reportService := service.NewReportService(txRepo)
+	subscriptionService := service.NewSubscriptionService(subRepo, userRepo, paymentService, notifier, localeService)
+	transferService := service.NewTransferService(userRepo, walletRepo, txRepo, notifier, localeService)
+	webhookService := service.NewWebhookService(inboxRepo, paymentService)
+
+	outboxService := service.NewOutboxService(outboxRepo)
+	outboxService.Register(repository.TopicTransactionStatusChanged, "payment_confirmed_dm", service.NewPaymentConfirmedHandler(userRepo, walletRepo, notifier, localeService))
+	outboxService.Register(repository.TopicTransactionStatusChanged, "payment_announcement", service.NewPaymentAnnouncementHandler(userRepo, walletRepo, guildSettingsService, notifier, localeService))
+	outboxService.Register(repository.TopicTransactionStatusChanged, "pix_message", service.NewPixMessageHandler(userRepo, walletRepo, txRepo, notifier, localeService))
+	outboxService.Register(repository.TopicTransactionStatusChanged, "payment_review_alert", service.NewPaymentReviewAlertHandler(userRepo, walletRepo, notifier, localeService, cfg.AdminDiscordIDs))
+
+	splitMode, err := service.ParseSplitMode(cfg.PurchaseSplitMode)
+	if err != nil {
+		return nil, fmt.Errorf("PURCHASE_SPLIT_MODE: %w", err)
+	}
+	purchaseService := service.NewPurchaseService(purchaseRepo, splitMode)
+
+	server := api.New(
+		cfg.Port,
+		cfg.AdminAPIToken,
+		cfg.CORSAllowedOrigins,
+		api.PaymentRateLimits{
+			PerUser: cfg.PaymentRatePerUser,
+			PerIP:   cfg.PaymentRatePerIP,
+			Window:  cfg.PaymentRateWindow,
+		},
+		database,
+		paymentService,
+		walletService,
+		auditService,
+		reportService,
+		subscriptionService,
+		purchaseService,
+		transferService,
+		outboxService,
+		webhookService,
+		localeService,
+		permissionService,
+		guildSettingsService,
+	)
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+
+	return &API{
+		server:         server,
+		payments:       paymentService,
+		pollInterval:   cfg.PaymentPollInterval,
+		subscriptions:  subscriptionService,
+		outbox:         outboxService,
+		webhooks:       webhookService,
+		backgroundCtx:  backgroundCtx,
+		stopBackground: stopBackground,
+	}, nil
+}
+
+// Start inicia os trabalhos em segundo plano e bloqueia servindo HTTP até o
+// Shutdown
+func (a *API) Start() error {
+	go a.subscriptions.StartScheduler(a.backgroundCtx)
+	go a.outbox.Start(a.backgroundCtx)
+	go a.webhooks.Start(a.backgroundCtx)
+	go a.payments.StartPolling(a.backgroundCtx, a.pollInterval)
+
+	return a.server.Start()
+}
+
+func (a *API) Shutdown(ctx context.Context) error {
+	a.stopBackground()
+	return a.server.Shutdown(ctx)
+}
+
+func NewBot(cfg *config.Config) (*bot.Bot, error) {
+	return bot.New(bot.Config{
+		Token:    cfg.DiscordToken,
+		APIURL:   cfg.APIBaseURL,
+		APIToken: cfg.AdminAPIToken,
+		AdminIDs: cfg.AdminDiscordIDs,
+	})
+}
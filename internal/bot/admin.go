@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+func (b *Bot) handleAuditCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	params := apiclient.AuditParams{Limit: 10}
+	if len(args) > 1 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.audit.usage"))
+		return
+	}
+	if len(args) == 1 {
+		txID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || txID <= 0 {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.audit.invalid_id"))
+			return
+		}
+		params.TransactionID = txID
+	}
+
+	events, err := b.api.As(b.caller(m)).AuditEvents(params)
+	if err != nil {
+		log.Printf("Erro ao buscar auditoria: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.audit.error"))
+		return
+	}
+
+	if len(events) == 0 {
+		s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.audit.empty"))
+		return
+	}
+
+	message := i18n.T(loc, "bot.audit.header") + "\n\n"
+	for _, e := range events {
+		message += i18n.T(loc, "bot.audit.event",
+			e.ID, e.CreatedAt.Format("02/01 15:04"), e.Action, e.EntityType, e.EntityID, e.Actor, e.Source)
+		if e.Reason != "" {
+			message += fmt.Sprintf(" — %s", e.Reason)
+		}
+		message += "\n"
+	}
+
+	s.ChannelMessageSend(m.ChannelID, message)
+}
+
+func (b *Bot) handleExportCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	usage := i18n.T(loc, "bot.export.usage")
+
+	if len(args) > 3 {
+		b.sendError(s, m.ChannelID, usage)
+		return
+	}
+
+	format := "csv"
+	if len(args) > 0 {
+		format = strings.ToLower(args[0])
+		if format != "csv" && format != "ofx" && format != "json" {
+			b.sendError(s, m.ChannelID, usage)
+			return
+		}
+	}
+
+	params := apiclient.ExportParams{Format: format}
+	for i, date := range []*string{&params.From, &params.To} {
+		if len(args) > i+1 {
+			if _, err := time.Parse("2006-01-02", args[i+1]); err != nil {
+				b.sendError(s, m.ChannelID, usage)
+				return
+			}
+			*date = args[i+1]
+		}
+	}
+
+	export, err := b.api.As(b.caller(m)).ExportReport(params)
+	if err != nil {
+		log.Printf("Erro ao exportar relatório: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.export.error"))
+		return
+	}
+	defer export.Body.Close()
+
+	_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: i18n.T(loc, "bot.export.title", strings.ToUpper(format)),
+		Files: []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("familia-steam-%s.%s", time.Now().Format("20060102"), format),
+				ContentType: export.ContentType,
+				Reader:      export.Body,
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Erro ao enviar relatório ao Discord: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.export.send_error"))
+	}
+}
+
+func (b *Bot) handleAdjustCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	if len(args) < 3 || len(m.Mentions) != 1 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.adjust.usage"))
+		return
+	}
+
+	member := m.Mentions[0]
+	amount, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || amount == 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.adjust.invalid_amount"))
+		return
+	}
+	reason := strings.Join(args[2:], " ")
+
+	transaction, err := b.api.As(b.caller(m)).AdjustBalance(member.ID, apiclient.AdjustBalanceRequest{
+		Amount: amount,
+		Reason: reason,
+	})
+	if err != nil {
+		log.Printf("Erro ao ajustar saldo de %s: %v", member.ID, err)
+		switch apiclient.StatusCode(err) {
+		case http.StatusNotFound:
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.adjust.user_not_found", member.Mention()))
+			return
+		case http.StatusUnprocessableEntity:
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.adjust.insufficient_balance", member.Mention()))
+			return
+		}
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.adjust.error"))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.adjust.done",
+		member.Mention(), i18n.Money(transaction.Amount), reason, transaction.ID))
+}
+
+func (b *Bot) handleApproveCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	if len(args) != 1 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.approve.usage"))
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil || id <= 0 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.approve.usage"))
+		return
+	}
+
+	transaction, err := b.api.As(b.caller(m)).ApprovePayment(id)
+	if err != nil {
+		log.Printf("Erro ao aprovar PIX #%d: %v", id, err)
+		switch apiclient.StatusCode(err) {
+		case http.StatusNotFound:
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.approve.not_found", id))
+		case http.StatusConflict:
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.approve.not_awaiting", id))
+		default:
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.approve.error"))
+		}
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.approve.done", transaction.ID, i18n.Money(transaction.Amount)))
+}
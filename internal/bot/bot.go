@@ -1,37 +1,67 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
 )
 
 type Bot struct {
-	session *discordgo.Session
-	apiURL  string
+	session     *discordgo.Session
+	api         *apiclient.Client
+	adminIDs    map[string]bool
+	render      *renderer
+	locales     *ttlCache[apiclient.LocaleSetting]
+	permissions *ttlCache[map[string]map[string]bool]
+	settings    *ttlCache[apiclient.GuildSettings]
+	members     *ttlCache[membership]
+	commands    *registry
+	cooldowns   *cooldowns
 }
 
-func New(token, apiURL string) (*Bot, error) {
-	session, err := discordgo.New("Bot " + token)
+type Config struct {
+	Token    string
+	APIURL   string
+	APIToken string
+	AdminIDs []string
+}
+
+func New(cfg Config) (*Bot, error) {
+	session, err := discordgo.New("Bot " + cfg.Token)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao criar sessão do Discord: %w", err)
 	}
 
-	// Configura as intents necessárias para ler mensagens
-	session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent
+	// Configura as intents necessárias para ler mensagens. IntentsGuilds
+	// mantém no state o idioma preferido de cada servidor e a lista usada
+	// para achar o servidor de quem escreve por DM
+	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages |
+		discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent
 
 	bot := &Bot{
-		session: session,
-		apiURL:  apiURL,
+		session:     session,
+		api:         apiclient.New(cfg.APIURL, apiclient.Options{Token: cfg.APIToken}),
+		adminIDs:    make(map[string]bool),
+		render:      newRenderer(),
+		locales:     newTTLCache[apiclient.LocaleSetting](),
+		permissions: newTTLCache[map[string]map[string]bool](),
+		settings:    newTTLCache[apiclient.GuildSettings](),
+		members:     newTTLCache[membership](),
+		cooldowns:   newCooldowns(),
 	}
+	for _, id := range cfg.AdminIDs {
+		bot.adminIDs[id] = true
+	}
+	bot.commands = newRegistry(bot.commandList())
 
 	bot.registerHandlers()
 
@@ -65,192 +95,232 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 	if m.Author.ID == s.State.User.ID {
 		return
 	}
+	b.dispatch(s, m)
+}
 
-	content := strings.TrimSpace(m.Content)
-
-	if content == "!ping" {
-		s.ChannelMessageSend(m.ChannelID, "Pong!")
-		return
+// commandList define os comandos do bot, na ordem do !ajuda
+func (b *Bot) commandList() []*command {
+	return []*command{
+		{name: "pix", args: "<valor>", cooldown: 5 * time.Second, direct: true, handler: b.handlePixCommand},
+		{name: "saldo", aliases: []string{"carteira"}, args: "[geral]", direct: true, handler: b.handleBalanceCommand},
+		{name: "ranking", aliases: []string{"top"}, args: "[mes|30d|ano|sempre]", direct: true, handler: b.handleRankingCommand},
+		{name: "transferir", args: "@membro <valor>", cooldown: 5 * time.Second, handler: b.handleTransferCommand},
+		{name: "mensalidade", args: "<valor> <dia> | status | cancelar", direct: true, handler: b.handleSubscriptionCommand},
+		{name: "idioma", aliases: []string{"lang"}, args: "[pt|en|es|auto] | servidor <pt|en|es|auto>", direct: true, handler: b.handleLocaleCommand},
+		{name: "auditoria", args: "[id da transação]", capability: apiclient.CapabilityAudit, handler: b.handleAuditCommand},
+		{name: "exportar", aliases: []string{"extrato"}, args: "[csv|ofx|json] [de] [até]", capability: apiclient.CapabilityExport, cooldown: 30 * time.Second, handler: b.handleExportCommand},
+		{name: "ajustar", args: "@membro <valor> <motivo>", capability: apiclient.CapabilityAdjust, handler: b.handleAdjustCommand},
+		{name: "aprovar", args: "<id do PIX>", capability: apiclient.CapabilityRefund, handler: b.handleApproveCommand},
+		{name: "permissao", aliases: []string{"permissão", "permissoes", "permissões"}, args: "[listar] | capacidades | dar|tirar @cargo <capacidade>", capability: apiclient.CapabilityPermissions, handler: b.handlePermissionCommand},
+		{name: "config", aliases: []string{"configurar"}, args: "[prefixo <prefixo> | canais adicionar|remover #canal | canais todos | anuncios <#canal|desligar>]", capability: apiclient.CapabilitySettings, anyChannel: true, handler: b.handleConfigCommand},
+		{name: "ajuda", aliases: []string{"help", "comandos"}, args: "[comando]", direct: true, handler: b.handleHelpCommand},
+		{name: "ping", direct: true, handler: b.handlePingCommand},
 	}
+}
 
-	if strings.HasPrefix(content, "!pix ") {
-		b.handlePixCommand(s, m)
-		return
-	}
+func (b *Bot) handlePingCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	s.ChannelMessageSend(m.ChannelID, "Pong!")
+}
 
-	if content == "!saldo" {
-		b.handleBalanceCommand(s, m)
-		return
-	}
+func (b *Bot) isAdmin(discordID string) bool {
+	return b.adminIDs[discordID]
+}
 
-	if content == "!saldo geral" {
-		b.handleTotalBalanceCommand(s, m)
-		return
+func (b *Bot) sendEmbed(s *discordgo.Session, channelID string, embed *discordgo.MessageEmbed) {
+	if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+		log.Printf("Erro ao enviar resposta: %v", err)
 	}
+}
 
-	if content == "!ranking" {
-		b.handleRankingCommand(s, m)
-		return
-	}
+func (b *Bot) sendError(s *discordgo.Session, channelID, message string) {
+	b.sendEmbed(s, channelID, b.render.error(message))
 }
 
-func (b *Bot) handlePixCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	parts := strings.Fields(m.Content)
-	if len(parts) != 2 {
-		s.ChannelMessageSend(m.ChannelID, "❌ Uso correto: `!pix <valor>`\nExemplo: `!pix 10.50`")
+func (b *Bot) handlePixCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	if len(args) != 1 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.pix.usage"))
 		return
 	}
 
-	amount, err := strconv.ParseFloat(parts[1], 64)
+	amount, err := strconv.ParseFloat(args[0], 64)
 	if err != nil || amount <= 0 {
-		s.ChannelMessageSend(m.ChannelID, "❌ Valor inválido. Use um número maior que zero.\nExemplo: `!pix 10.50`")
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.pix.invalid_amount"))
 		return
 	}
 
-	reqBody := map[string]interface{}{
-		"discord_id": m.Author.ID,
-		"username":   m.Author.Username,
-		"amount":     amount,
-	}
-	jsonData, _ := json.Marshal(reqBody)
-
-	resp, err := http.Post(b.apiURL+"/api/payments/create", "application/json", bytes.NewBuffer(jsonData))
+	// Uma mesma mensagem do Discord nunca gera mais de uma cobrança
+	payment, err := b.api.As(b.caller(m)).CreatePayment(apiclient.CreatePaymentRequest{
+		DiscordID: m.Author.ID,
+		Username:  m.Author.Username,
+		Amount:    amount,
+	}, "discord-message-"+m.ID)
 	if err != nil {
 		log.Printf("Erro ao criar pagamento: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Erro ao criar pagamento. Tente novamente.")
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		s.ChannelMessageSend(m.ChannelID, "❌ Erro ao criar pagamento. Tente novamente.")
+		b.sendError(s, m.ChannelID, paymentErrorMessage(loc, err))
 		return
 	}
 
-	var payment struct {
-		TransactionID int64   `json:"transaction_id"`
-		Amount        float64 `json:"amount"`
-		QRCodeBase64  string  `json:"qr_code_base64"`
-	}
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	json.Unmarshal(bodyBytes, &payment)
-
-	log.Printf("Payment response: TransactionID=%d, Amount=%.2f, QRCodeBase64 length=%d",
-		payment.TransactionID, payment.Amount, len(payment.QRCodeBase64))
-
 	if payment.QRCodeBase64 == "" {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf(
-			"❌ QR Code PIX não disponível.\n"+
-				"Transação ID: `%d`\n"+
-				"Valor: R$ %.2f\n\n"+
-				"Possível causa: Token de teste não gera QR codes reais.",
-			payment.TransactionID, payment.Amount))
+		b.sendEmbed(s, m.ChannelID, b.render.in(loc).pixWithoutQRCode(payment))
 		return
 	}
 
-	qrCodeBytes, err := base64.StdEncoding.DecodeString(payment.QRCodeBase64)
+	qrCode, err := qrCodeFile(payment.QRCodeBase64)
 	if err != nil {
 		log.Printf("Erro ao decodificar QR code base64: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Erro ao processar imagem do QR Code.")
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.pix.qrcode_error"))
 		return
 	}
 
-	message := fmt.Sprintf("💰 **Pagamento PIX criado!**\n\n"+
-		"Valor: **R$ %.2f**\n"+
-		"ID da transação: `%d`\n\n"+
-		"📱 Escaneie o QR Code abaixo com seu app de pagamento:",
-		payment.Amount, payment.TransactionID)
-
-	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
-		Content: message,
-		Files: []*discordgo.File{
-			{
-				Name:        "qrcode.png",
-				ContentType: "image/png",
-				Reader:      bytes.NewReader(qrCodeBytes),
-			},
-		},
+	sent, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{b.render.in(loc).pix(m.Author, payment)},
+		Files:  []*discordgo.File{qrCode},
 	})
-}
-
-func (b *Bot) handleBalanceCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	resp, err := http.Get(fmt.Sprintf("%s/api/wallet/balance?discord_id=%s", b.apiURL, m.Author.ID))
 	if err != nil {
-		log.Printf("Erro ao buscar saldo: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Erro ao buscar saldo.")
+		log.Printf("Erro ao enviar QR code do PIX #%d: %v", payment.TransactionID, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	var result struct {
-		Balance float64 `json:"balance"`
+	// Com a mensagem registrada, a API a edita quando o PIX for pago,
+	// expirar ou falhar. Uma cobrança repetida (mesma mensagem do Discord)
+	// já tem a sua
+	if payment.Replayed {
+		return
+	}
+	guildID, _ := b.guildOf(m)
+	err = b.api.As(b.caller(m)).AttachPaymentMessage(payment.TransactionID, apiclient.AttachPaymentMessageRequest{
+		DiscordID: m.Author.ID,
+		GuildID:   guildID,
+		ChannelID: sent.ChannelID,
+		MessageID: sent.ID,
+	})
+	if err != nil {
+		log.Printf("Erro ao registrar mensagem do PIX #%d: %v", payment.TransactionID, err)
+	}
+}
+
+func paymentErrorMessage(loc i18n.Locale, err error) string {
+	var apiErr *apiclient.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case "rate_limited":
+			return i18n.T(loc, "bot.payment.rate_limited", waitText(loc, apiErr.RetryAfter))
+		case "too_many_pending":
+			return i18n.T(loc, "bot.payment.too_many_pending")
+		case "amount_out_of_range":
+			min, _ := apiErr.Details["min_amount"].(float64)
+			max, _ := apiErr.Details["max_amount"].(float64)
+			switch {
+			case min > 0 && max > 0:
+				return i18n.T(loc, "bot.payment.amount_between", i18n.Money(min), i18n.Money(max))
+			case max > 0:
+				return i18n.T(loc, "bot.payment.amount_max", i18n.Money(max))
+			default:
+				return i18n.T(loc, "bot.payment.amount_min", i18n.Money(min))
+			}
+		}
 	}
-	json.NewDecoder(resp.Body).Decode(&result)
 
-	message := fmt.Sprintf("💰 **Seu saldo:** R$ %.2f", result.Balance)
-	s.ChannelMessageSend(m.ChannelID, message)
+	switch apiclient.StatusCode(err) {
+	case http.StatusTooManyRequests:
+		return i18n.T(loc, "bot.payment.mercadopago_rate_limited")
+	case http.StatusServiceUnavailable:
+		return i18n.T(loc, "bot.payment.mercadopago_unavailable")
+	case http.StatusUnprocessableEntity:
+		return i18n.T(loc, "bot.payment.mercadopago_rejected")
+	case http.StatusBadGateway:
+		return i18n.T(loc, "bot.payment.mercadopago_unauthorized")
+	case http.StatusConflict:
+		return i18n.T(loc, "bot.payment.idempotency_key_reused")
+	case http.StatusBadRequest:
+		return i18n.T(loc, "bot.payment.invalid_request")
+	default:
+		return i18n.T(loc, "bot.payment.error")
+	}
 }
 
-func (b *Bot) handleTotalBalanceCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	resp, err := http.Get(fmt.Sprintf("%s/api/wallet/ranking?limit=1000", b.apiURL))
-	if err != nil {
-		log.Printf("Erro ao buscar saldo total: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Erro ao buscar saldo total.")
-		return
+// waitText descreve uma espera em segundos ou minutos
+func waitText(loc i18n.Locale, d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	switch {
+	case seconds <= 1:
+		return i18n.T(loc, "time.second")
+	case seconds < 60:
+		return i18n.T(loc, "time.seconds", seconds)
+	case seconds < 120:
+		return i18n.T(loc, "time.minute")
+	default:
+		return i18n.T(loc, "time.minutes", int(math.Ceil(float64(seconds)/60)))
 	}
-	defer resp.Body.Close()
+}
 
-	var ranking []struct {
-		Username string  `json:"username"`
-		Balance  float64 `json:"balance"`
+func (b *Bot) handleBalanceCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	switch {
+	case len(args) == 1 && strings.EqualFold(args[0], "geral"):
+		b.handleTotalBalanceCommand(s, m, loc)
+		return
+	case len(args) > 0:
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.balance.usage"))
+		return
 	}
-	json.NewDecoder(resp.Body).Decode(&ranking)
 
-	total := 0.0
-	for _, entry := range ranking {
-		total += entry.Balance
+	balance, err := b.api.Balance(m.Author.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar saldo: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.balance.error"))
+		return
 	}
 
-	message := fmt.Sprintf("💰 **Saldo total da vaquinha:** R$ %.2f", total)
-	s.ChannelMessageSend(m.ChannelID, message)
+	b.sendEmbed(s, m.ChannelID, b.render.in(loc).balance(m.Author, balance))
 }
 
-func (b *Bot) handleRankingCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	resp, err := http.Get(fmt.Sprintf("%s/api/wallet/ranking?limit=10", b.apiURL))
+func (b *Bot) handleTotalBalanceCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale) {
+	total, err := b.api.Total()
 	if err != nil {
-		log.Printf("Erro ao buscar ranking: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "❌ Erro ao buscar ranking.")
+		log.Printf("Erro ao buscar saldo total: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.total.error"))
 		return
 	}
-	defer resp.Body.Close()
 
-	var ranking []struct {
-		Username string  `json:"username"`
-		Balance  float64 `json:"balance"`
-	}
-	json.NewDecoder(resp.Body).Decode(&ranking)
+	b.sendEmbed(s, m.ChannelID, b.render.in(loc).total(total))
+}
+
+// rankingPeriods são os períodos aceitos pelo !ranking; o título de cada um
+// fica no catálogo em embed.ranking.title.<período>
+var rankingPeriods = map[string]bool{
+	"sempre": true,
+	"mes":    true,
+	"30d":    true,
+	"ano":    true,
+}
 
-	if len(ranking) == 0 {
-		s.ChannelMessageSend(m.ChannelID, "📊 **Ranking vazio!** Ninguém contribuiu ainda.")
+func (b *Bot) handleRankingCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	period := "sempre"
+	if len(args) > 1 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.ranking.usage"))
 		return
 	}
-
-	message := "📊 **Top 10 Contribuidores:**\n\n"
-	for i, entry := range ranking {
-		medal := ""
-		switch i {
-		case 0:
-			medal = "🥇"
-		case 1:
-			medal = "🥈"
-		case 2:
-			medal = "🥉"
-		default:
-			medal = fmt.Sprintf("%d.", i+1)
+	if len(args) == 1 {
+		period = strings.ToLower(args[0])
+		if period == "mês" {
+			period = "mes"
 		}
-		message += fmt.Sprintf("%s **%s** - R$ %.2f\n", medal, entry.Username, entry.Balance)
+		if !rankingPeriods[period] {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.ranking.invalid_period"))
+			return
+		}
+	}
+
+	ranking, err := b.api.Ranking(apiclient.RankingParams{
+		Period:    period,
+		Limit:     10,
+		DiscordID: m.Author.ID,
+	})
+	if err != nil {
+		log.Printf("Erro ao buscar ranking: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.ranking.error"))
+		return
 	}
 
-	s.ChannelMessageSend(m.ChannelID, message)
+	b.sendEmbed(s, m.ChannelID, b.render.in(loc).ranking(period, ranking, m.Author.ID))
 }
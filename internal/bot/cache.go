@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheTTL limita quanto tempo o bot confia no que buscou na API; mudanças
+// feitas por outro processo (CLI, API) aparecem depois disso
+const cacheTTL = 5 * time.Minute
+
+// ttlCache evita uma chamada à API por comando para dados que mudam pouco
+// (idioma, permissões, configurações do servidor)
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	entries map[string]ttlEntry[V]
+}
+
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+func newTTLCache[V any]() *ttlCache[V] {
+	return &ttlCache[V]{entries: make(map[string]ttlEntry[V])}
+}
+
+func (c *ttlCache[V]) get(key string, now time.Time) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expires) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[V]) set(key string, value V, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlEntry[V]{value: value, expires: now.Add(cacheTTL)}
+}
+
+func (c *ttlCache[V]) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// clear esquece tudo; usado quando uma mudança vale para várias chaves
+func (c *ttlCache[V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]ttlEntry[V])
+}
@@ -0,0 +1,296 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+// defaultCommandPrefix vale nos servidores sem prefixo próprio e sempre
+// nas mensagens privadas
+const defaultCommandPrefix = "!"
+
+// command descreve um comando do bot. A descrição fica no catálogo em
+// command.<nome>; args é só a sintaxe mostrada no !ajuda
+type command struct {
+	name    string
+	aliases []string
+	args    string
+	// capability exigida para usar o comando; vazia libera para todos
+	capability string
+	// cooldown é o intervalo mínimo entre dois usos pelo mesmo membro
+	cooldown time.Duration
+	// direct libera o comando em mensagens privadas
+	direct bool
+	// anyChannel ignora a lista de canais permitidos do servidor, para que
+	// um administrador sempre consiga corrigi-la
+	anyChannel bool
+	handler    func(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string)
+}
+
+func (c *command) usage(prefix string) string {
+	if c.args == "" {
+		return prefix + c.name
+	}
+	return prefix + c.name + " " + c.args
+}
+
+// registry guarda os comandos na ordem em que aparecem no !ajuda
+type registry struct {
+	commands []*command
+	byName   map[string]*command
+}
+
+// newRegistry entra em pânico com nomes repetidos: é erro de programação
+func newRegistry(commands []*command) *registry {
+	r := &registry{commands: commands, byName: make(map[string]*command)}
+	for _, c := range commands {
+		for _, name := range append([]string{c.name}, c.aliases...) {
+			if _, ok := r.byName[name]; ok {
+				panic(fmt.Sprintf("comando duplicado: %s", name))
+			}
+			r.byName[name] = c
+		}
+	}
+	return r
+}
+
+func (r *registry) lookup(name string) *command {
+	return r.byName[strings.ToLower(name)]
+}
+
+// direct devolve os comandos liberados em mensagens privadas
+func (r *registry) direct() []*command {
+	var commands []*command
+	for _, c := range r.commands {
+		if c.direct {
+			commands = append(commands, c)
+		}
+	}
+	return commands
+}
+
+// maxSuggestions limita o "você quis dizer"
+const maxSuggestions = 3
+
+// suggest devolve os comandos com nome ou atalho parecido, do mais próximo
+// para o mais distante
+func (r *registry) suggest(name string) []*command {
+	name = strings.ToLower(name)
+
+	distance := make(map[*command]int)
+	for candidate, c := range r.byName {
+		d := editDistance(name, candidate)
+		if len([]rune(name)) >= 3 && strings.HasPrefix(candidate, name) {
+			d = 1
+		}
+		if !similar(name, candidate, d) {
+			continue
+		}
+		if best, ok := distance[c]; !ok || d < best {
+			distance[c] = d
+		}
+	}
+
+	suggestions := make([]*command, 0, len(distance))
+	for c := range distance {
+		suggestions = append(suggestions, c)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if distance[suggestions[i]] != distance[suggestions[j]] {
+			return distance[suggestions[i]] < distance[suggestions[j]]
+		}
+		return suggestions[i].name < suggestions[j].name
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// similar tolera um erro de digitação em nomes curtos e dois nos demais, sem
+// passar de metade do que foi digitado
+func similar(typed, candidate string, distance int) bool {
+	if distance*2 > len([]rune(typed)) {
+		return false
+	}
+	if len([]rune(candidate)) < 5 {
+		return distance <= 1
+	}
+	return distance <= 2
+}
+
+// editDistance é a distância de Levenshtein entre a e b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// parseCommand separa "!nome arg1 arg2" em nome (minúsculo, sem o prefixo)
+// e argumentos. Aceita qualquer um dos prefixos
+func parseCommand(content string, prefixes ...string) (string, []string, bool) {
+	content = strings.TrimSpace(content)
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(content, prefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(content, prefix))
+		if len(fields) == 0 {
+			return "", nil, false
+		}
+		return strings.ToLower(fields[0]), fields[1:], true
+	}
+	return "", nil, false
+}
+
+// cooldowns lembra até quando cada membro espera para repetir um comando
+type cooldowns struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func newCooldowns() *cooldowns {
+	return &cooldowns{until: make(map[string]time.Time)}
+}
+
+// take registra o uso e devolve quanto falta esperar se o membro ainda
+// estiver no intervalo
+func (c *cooldowns) take(discordID string, cmd *command, now time.Time) (time.Duration, bool) {
+	if cmd.cooldown <= 0 {
+		return 0, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := discordID + "/" + cmd.name
+	if until, ok := c.until[key]; ok && now.Before(until) {
+		return until.Sub(now), false
+	}
+
+	// Aproveita para esquecer os intervalos vencidos
+	for k, until := range c.until {
+		if !now.Before(until) {
+			delete(c.until, k)
+		}
+	}
+	c.until[key] = now.Add(cmd.cooldown)
+	return 0, true
+}
+
+// dispatch executa o comando da mensagem. Comandos desconhecidos só têm
+// resposta quando há sugestão, para não atrapalhar outros bots com o mesmo
+// prefixo
+func (b *Bot) dispatch(s *discordgo.Session, m *discordgo.MessageCreate) {
+	direct := m.GuildID == ""
+
+	prefixes := b.prefixes(m)
+	name, args, ok := parseCommand(m.Content, prefixes...)
+	if !ok {
+		return
+	}
+	prefix := prefixes[0]
+
+	cmd := b.commands.lookup(name)
+	if !direct && (cmd == nil || !cmd.anyChannel) && !channelAllowed(s, b.guildSettings(m.GuildID), m.ChannelID) {
+		return
+	}
+
+	if cmd == nil {
+		if suggestions := b.commands.suggest(name); len(suggestions) > 0 {
+			loc := b.locale(s, m)
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.unknown_command", prefix+name, suggestionList(prefix, suggestions)))
+		}
+		return
+	}
+
+	loc := b.locale(s, m)
+	if direct {
+		if guildID, _ := b.guildOf(m); guildID == "" {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.dm.not_member"))
+			return
+		}
+		if !cmd.direct {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.dm.guild_only", prefix+cmd.name))
+			return
+		}
+	}
+	if cmd.capability != "" && !b.require(s, m, loc, cmd.capability) {
+		return
+	}
+	if wait, ok := b.cooldowns.take(m.Author.ID, cmd, time.Now()); !ok {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.cooldown", waitText(loc, wait), prefix+cmd.name))
+		return
+	}
+
+	cmd.handler(s, m, loc, args)
+}
+
+// prefixes devolve o prefixo do servidor da mensagem. Em mensagens privadas
+// o padrão também vale, já que não há outros bots para atrapalhar
+func (b *Bot) prefixes(m *discordgo.MessageCreate) []string {
+	guildID, _ := b.guildOf(m)
+	prefixes := []string{b.guildSettings(guildID).CommandPrefix}
+	if m.GuildID == "" && prefixes[0] != defaultCommandPrefix {
+		prefixes = append(prefixes, defaultCommandPrefix)
+	}
+	return prefixes
+}
+
+func suggestionList(prefix string, commands []*command) string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = "`" + prefix + c.name + "`"
+	}
+	return strings.Join(names, ", ")
+}
+
+// handleHelpCommand trata `!ajuda [comando]`, gerado a partir do registro.
+// Em mensagens privadas lista só os comandos liberados lá
+func (b *Bot) handleHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	prefix := b.prefixes(m)[0]
+	r := b.render.in(loc).withPrefix(prefix)
+
+	switch len(args) {
+	case 0:
+		commands := b.commands.commands
+		if m.GuildID == "" {
+			commands = b.commands.direct()
+		}
+		b.sendEmbed(s, m.ChannelID, r.help(commands))
+	case 1:
+		name := strings.TrimPrefix(args[0], prefix)
+		if cmd := b.commands.lookup(name); cmd != nil {
+			b.sendEmbed(s, m.ChannelID, r.commandHelp(cmd))
+			return
+		}
+		message := i18n.T(loc, "bot.help.unknown", prefix+name, prefix+"ajuda")
+		if suggestions := b.commands.suggest(name); len(suggestions) > 0 {
+			message += "\n" + i18n.T(loc, "bot.help.suggestions", suggestionList(prefix, suggestions))
+		}
+		b.sendError(s, m.ChannelID, message)
+	default:
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.help.usage", prefix+"ajuda"))
+	}
+}
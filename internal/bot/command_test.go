@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testRegistry() *registry {
+	return newRegistry((&Bot{}).commandList())
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		content  string
+		prefixes []string
+		name     string
+		args     []string
+		ok       bool
+	}{
+		{"!saldo", []string{"!"}, "saldo", []string{}, true},
+		{"  !SALDO   geral ", []string{"!"}, "saldo", []string{"geral"}, true},
+		{"!pix 10.50", []string{"!"}, "pix", []string{"10.50"}, true},
+		{"!", []string{"!"}, "", nil, false},
+		{"! pix", []string{"!"}, "pix", []string{}, true},
+		{"saldo", []string{"!"}, "", nil, false},
+		{"!saldo", []string{"$$"}, "", nil, false},
+		{"$$saldo", []string{"$$"}, "saldo", []string{}, true},
+		{"!saldo", []string{"?", "!"}, "saldo", []string{}, true},
+	}
+
+	for _, tt := range tests {
+		name, args, ok := parseCommand(tt.content, tt.prefixes...)
+		if name != tt.name || ok != tt.ok || (ok && !reflect.DeepEqual(args, tt.args)) {
+			t.Errorf("parseCommand(%q) = %q, %q, %v; quer %q, %q, %v", tt.content, name, args, ok, tt.name, tt.args, tt.ok)
+		}
+	}
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := testRegistry()
+	for name, want := range map[string]string{
+		"saldo":     "saldo",
+		"Saldo":     "saldo",
+		"carteira":  "saldo",
+		"permissão": "permissao",
+		"help":      "ajuda",
+	} {
+		if c := r.lookup(name); c == nil || c.name != want {
+			t.Errorf("lookup(%q) = %v, quer %s", name, c, want)
+		}
+	}
+	if c := r.lookup("nada"); c != nil {
+		t.Errorf("lookup(nada) = %s, quer nil", c.name)
+	}
+}
+
+func TestRegistrySuggest(t *testing.T) {
+	r := testRegistry()
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"sald", []string{"saldo"}},
+		{"trasnferir", []string{"transferir"}},
+		{"pux", []string{"pix"}},
+		{"aud", []string{"auditoria"}},
+		{"permisao", []string{"permissao"}},
+		{"xyz", []string{}},
+		{"oi", []string{}},
+	}
+
+	for _, tt := range tests {
+		got := []string{}
+		for _, c := range r.suggest(tt.name) {
+			got = append(got, c.name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggest(%q) = %v, quer %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"pix", "pix", 0},
+		{"pix", "pux", 1},
+		{"saldo", "sald", 1},
+		{"permissão", "permissao", 1},
+		{"ranking", "rnaking", 2},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, quer %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCooldowns(t *testing.T) {
+	c := newCooldowns()
+	cmd := &command{name: "pix", cooldown: 5 * time.Second}
+	now := time.Date(2024, 3, 15, 18, 30, 0, 0, time.UTC)
+
+	if _, ok := c.take("111", cmd, now); !ok {
+		t.Fatal("primeiro uso bloqueado")
+	}
+	if wait, ok := c.take("111", cmd, now.Add(2*time.Second)); ok || wait != 3*time.Second {
+		t.Errorf("segundo uso = %v, %v; quer 3s, false", wait, ok)
+	}
+	if _, ok := c.take("222", cmd, now.Add(2*time.Second)); !ok {
+		t.Error("outro membro bloqueado")
+	}
+	if _, ok := c.take("111", cmd, now.Add(5*time.Second)); !ok {
+		t.Error("uso depois do intervalo bloqueado")
+	}
+	if _, ok := c.take("111", &command{name: "saldo"}, now); !ok {
+		t.Error("comando sem intervalo bloqueado")
+	}
+}
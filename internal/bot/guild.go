@@ -0,0 +1,249 @@
+package bot
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+// guildSettings devolve as configurações do servidor. Se a API falhar o bot
+// segue com os padrões em vez de ficar mudo
+func (b *Bot) guildSettings(guildID string) apiclient.GuildSettings {
+	defaults := apiclient.GuildSettings{GuildID: guildID, CommandPrefix: defaultCommandPrefix}
+	if guildID == "" {
+		return defaults
+	}
+
+	now := time.Now()
+	if settings, ok := b.settings.get(guildID, now); ok {
+		return settings
+	}
+
+	settings, err := b.api.GuildSettings(guildID)
+	if err != nil {
+		log.Printf("Erro ao buscar configurações do servidor %s: %v", guildID, err)
+		return defaults
+	}
+	if settings.CommandPrefix == "" {
+		settings.CommandPrefix = defaultCommandPrefix
+	}
+	b.settings.set(guildID, *settings, now)
+	return *settings
+}
+
+// channelAllowed indica se o bot responde no canal. Em threads vale o canal
+// onde a thread foi aberta
+func channelAllowed(s *discordgo.Session, settings apiclient.GuildSettings, channelID string) bool {
+	if len(settings.AllowedChannelIDs) == 0 {
+		return true
+	}
+
+	candidates := []string{channelID}
+	if s.State != nil {
+		if channel, err := s.State.Channel(channelID); err == nil && channel.ParentID != "" {
+			candidates = append(candidates, channel.ParentID)
+		}
+	}
+	for _, allowed := range settings.AllowedChannelIDs {
+		for _, id := range candidates {
+			if allowed == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// membership é o servidor em que um membro é atendido nas mensagens
+// privadas; GuildID vazio quando ele não está em nenhum servidor do bot
+type membership struct {
+	GuildID string
+	Member  *discordgo.Member
+}
+
+// guildOf devolve o servidor e o membro por trás da mensagem. Em mensagem
+// privada o membro é atendido como no servidor dele: idioma, cargos e
+// prefixo vêm de lá
+func (b *Bot) guildOf(m *discordgo.MessageCreate) (string, *discordgo.Member) {
+	if m.GuildID != "" {
+		return m.GuildID, m.Member
+	}
+	found := b.memberGuild(m.Author.ID)
+	return found.GuildID, found.Member
+}
+
+// memberGuild procura, entre os servidores do bot, o primeiro (pelo ID) em
+// que o usuário é membro
+func (b *Bot) memberGuild(discordID string) membership {
+	s := b.session
+	now := time.Now()
+	if cached, ok := b.members.get(discordID, now); ok {
+		return cached
+	}
+
+	var guildIDs []string
+	if s.State != nil {
+		for _, guild := range s.State.Guilds {
+			guildIDs = append(guildIDs, guild.ID)
+		}
+	}
+	sort.Strings(guildIDs)
+
+	var found membership
+	for _, guildID := range guildIDs {
+		member, err := s.State.Member(guildID, discordID)
+		if err != nil {
+			// Sem a intent de membros o state só conhece quem já falou; a
+			// API REST responde 404 para quem não é membro
+			if member, err = s.GuildMember(guildID, discordID); err != nil {
+				continue
+			}
+		}
+		found = membership{GuildID: guildID, Member: member}
+		break
+	}
+
+	b.members.set(discordID, found, now)
+	return found
+}
+
+var channelMention = regexp.MustCompile(`^(?:<#(\d+)>|(\d+))$`)
+
+func parseChannel(value string) (string, bool) {
+	match := channelMention.FindStringSubmatch(value)
+	if match == nil {
+		return "", false
+	}
+	return match[1] + match[2], true
+}
+
+// handleConfigCommand trata `!config`, `!config prefixo <prefixo>`,
+// `!config canais [adicionar|remover #canal | todos]` e
+// `!config anuncios <#canal|desligar>`
+func (b *Bot) handleConfigCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	if m.GuildID == "" {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.config.guild_only"))
+		return
+	}
+	if len(args) == 0 {
+		b.showConfig(s, m, loc)
+		return
+	}
+
+	var req apiclient.UpdateGuildSettingsRequest
+	switch strings.ToLower(args[0]) {
+	case "prefixo":
+		if len(args) != 2 {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.config.usage"))
+			return
+		}
+		req.CommandPrefix = &args[1]
+
+	case "canais":
+		channels, ok := editChannels(b.guildSettings(m.GuildID).AllowedChannelIDs, args[1:])
+		if !ok {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.config.usage"))
+			return
+		}
+		req.AllowedChannelIDs = &channels
+
+	case "anuncios", "anúncios":
+		if len(args) != 2 {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.config.usage"))
+			return
+		}
+		channel := ""
+		if strings.ToLower(args[1]) != "desligar" {
+			id, ok := parseChannel(args[1])
+			if !ok {
+				b.sendError(s, m.ChannelID, i18n.T(loc, "bot.config.invalid_channel"))
+				return
+			}
+			channel = id
+		}
+		req.AnnouncementChannelID = &channel
+
+	default:
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.config.usage"))
+		return
+	}
+
+	if _, err := b.api.As(b.caller(m)).UpdateGuildSettings(m.GuildID, req); err != nil {
+		log.Printf("Erro ao salvar configurações do servidor: %v", err)
+		b.sendError(s, m.ChannelID, configErrorMessage(loc, err))
+		return
+	}
+	b.settings.forget(m.GuildID)
+
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.config.saved"))
+	b.showConfig(s, m, loc)
+}
+
+func configErrorMessage(loc i18n.Locale, err error) string {
+	var apiErr *apiclient.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return i18n.T(loc, "bot.config.error")
+	}
+	if apiErr.Details["field"] == "command_prefix" {
+		return i18n.T(loc, "bot.config.invalid_prefix")
+	}
+	return i18n.T(loc, "bot.config.invalid_channel")
+}
+
+// editChannels aplica `adicionar #canal`, `remover #canal` ou `todos` à
+// lista atual de canais permitidos
+func editChannels(current, args []string) ([]string, bool) {
+	if len(args) == 1 && strings.ToLower(args[0]) == "todos" {
+		return []string{}, true
+	}
+	if len(args) != 2 {
+		return nil, false
+	}
+	channel, ok := parseChannel(args[1])
+	if !ok {
+		return nil, false
+	}
+
+	channels := make([]string, 0, len(current)+1)
+	for _, id := range current {
+		if id != channel {
+			channels = append(channels, id)
+		}
+	}
+	switch strings.ToLower(args[0]) {
+	case "adicionar":
+		channels = append(channels, channel)
+	case "remover":
+	default:
+		return nil, false
+	}
+	return channels, true
+}
+
+func (b *Bot) showConfig(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale) {
+	settings := b.guildSettings(m.GuildID)
+
+	channels := i18n.T(loc, "bot.config.all_channels")
+	if len(settings.AllowedChannelIDs) > 0 {
+		mentions := make([]string, len(settings.AllowedChannelIDs))
+		for i, id := range settings.AllowedChannelIDs {
+			mentions[i] = "<#" + id + ">"
+		}
+		channels = strings.Join(mentions, ", ")
+	}
+
+	announcements := i18n.T(loc, "bot.config.no_announcements")
+	if settings.AnnouncementChannelID != "" {
+		announcements = "<#" + settings.AnnouncementChannelID + ">"
+	}
+
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.config.current", settings.CommandPrefix, channels, announcements))
+}
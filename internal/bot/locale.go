@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+// locale escolhe o idioma da resposta: o do membro, o do servidor, o
+// preferido do servidor no Discord e, por fim, o padrão
+func (b *Bot) locale(s *discordgo.Session, m *discordgo.MessageCreate) i18n.Locale {
+	guildID, _ := b.guildOf(m)
+	if setting := b.localeSetting(m.Author.ID, guildID); setting != nil {
+		if locale, ok := i18n.Parse(setting.Locale); ok {
+			return locale
+		}
+	}
+	if locale, ok := discordLocale(s, guildID); ok {
+		return locale
+	}
+	return i18n.Default
+}
+
+// localeSetting devolve nil se a API falhar; o bot segue com o idioma do
+// Discord em vez de deixar o comando sem resposta
+func (b *Bot) localeSetting(discordID, guildID string) *apiclient.LocaleSetting {
+	key := discordID + "/" + guildID
+	now := time.Now()
+	if setting, ok := b.locales.get(key, now); ok {
+		return &setting
+	}
+
+	setting, err := b.api.Locale(discordID, guildID)
+	if err != nil {
+		log.Printf("Erro ao buscar idioma de %s: %v", discordID, err)
+		return nil
+	}
+	b.locales.set(key, *setting, now)
+	return setting
+}
+
+// discordLocale usa o idioma preferido do servidor. Só servidores de
+// comunidade escolhem esse idioma; nos demais o Discord sempre informa en-US
+func discordLocale(s *discordgo.Session, guildID string) (i18n.Locale, bool) {
+	if guildID == "" || s.State == nil {
+		return "", false
+	}
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return "", false
+	}
+	for _, feature := range guild.Features {
+		if feature == discordgo.GuildFeatureCommunity {
+			return i18n.Parse(guild.PreferredLocale)
+		}
+	}
+	return "", false
+}
+
+// handleLocaleCommand trata `!idioma [pt|en|es|auto]` e, para quem tem a
+// capacidade settings, `!idioma servidor <pt|en|es|auto>`
+func (b *Bot) handleLocaleCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	switch {
+	case len(args) == 0:
+		b.showLocale(s, m, loc)
+	case len(args) == 1:
+		b.setLocale(s, m, loc, false, args[0])
+	case len(args) == 2 && args[0] == "servidor":
+		b.setLocale(s, m, loc, true, args[1])
+	default:
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.locale.usage"))
+	}
+}
+
+func (b *Bot) showLocale(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale) {
+	source := "bot.locale.source.auto"
+	guildID, _ := b.guildOf(m)
+	if setting := b.localeSetting(m.Author.ID, guildID); setting != nil && setting.Source != "" {
+		source = "bot.locale.source." + setting.Source
+	}
+
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.locale.current", loc, i18n.T(loc, source))+
+		"\n\n"+i18n.T(loc, "bot.locale.help"))
+}
+
+func (b *Bot) setLocale(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, guild bool, value string) {
+	if guild {
+		if m.GuildID == "" {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.locale.guild_only"))
+			return
+		}
+		if !b.require(s, m, loc, apiclient.CapabilitySettings) {
+			return
+		}
+	}
+
+	// "auto" apaga a configuração; a API valida o idioma
+	locale := strings.ToLower(value)
+	if locale == "auto" {
+		locale = ""
+	} else if _, ok := i18n.Parse(locale); !ok {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.locale.invalid"))
+		return
+	}
+
+	var err error
+	if guild {
+		_, err = b.api.As(b.caller(m)).SetGuildLocale(m.GuildID, locale)
+	} else {
+		_, err = b.api.As(b.caller(m)).SetUserLocale(m.Author.ID, locale)
+	}
+	if err != nil {
+		log.Printf("Erro ao salvar idioma: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.locale.error"))
+		return
+	}
+	b.locales.clear()
+
+	// A confirmação já sai no idioma novo
+	loc = b.locale(s, m)
+	key := "bot.locale.saved_user"
+	if guild {
+		key = "bot.locale.saved_guild"
+	}
+	if locale == "" {
+		key += "_auto"
+	}
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, key, loc))
+}
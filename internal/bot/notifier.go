@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+// Notifier envia mensagens pelo Discord em nome dos serviços (mensalidades,
+// avisos de pagamento, status do PIX...). Só usa a API REST, então funciona
+// com uma sessão que não abriu o gateway.
+type Notifier struct {
+	session *discordgo.Session
+}
+
+func NewNotifier(session *discordgo.Session) *Notifier {
+	return &Notifier{session: session}
+}
+
+// NewRESTNotifier cria um Notifier com sessão própria, para processos que
+// não rodam o bot (a API sozinha)
+func NewRESTNotifier(token string) (*Notifier, error) {
+	session, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar sessão do Discord: %w", err)
+	}
+	return NewNotifier(session), nil
+}
+
+func (b *Bot) Notifier() *Notifier {
+	return NewNotifier(b.session)
+}
+
+func (n *Notifier) SendDirectMessage(discordID, message string) error {
+	channel, err := n.session.UserChannelCreate(discordID)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir DM: %w", err)
+	}
+
+	if _, err := n.session.ChannelMessageSend(channel.ID, message); err != nil {
+		return dmError(err)
+	}
+	return nil
+}
+
+func (n *Notifier) SendChannelMessage(channelID, message string) error {
+	if _, err := n.session.ChannelMessageSend(channelID, message); err != nil {
+		return fmt.Errorf("erro ao enviar mensagem ao canal %s: %w", channelID, err)
+	}
+	return nil
+}
+
+func (n *Notifier) SendPixCharge(discordID, message string, payment *service.CreatePixPaymentResponse) error {
+	channel, err := n.session.UserChannelCreate(discordID)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir DM: %w", err)
+	}
+
+	msg := &discordgo.MessageSend{Content: message}
+	if payment.QRCodeBase64 != "" {
+		file, err := qrCodeFile(payment.QRCodeBase64)
+		if err != nil {
+			return err
+		}
+		msg.Files = []*discordgo.File{file}
+	} else if payment.QRCode != "" {
+		msg.Content += fmt.Sprintf("\n\nPIX copia e cola:\n```%s```", payment.QRCode)
+	}
+
+	if _, err := n.session.ChannelMessageSendComplex(channel.ID, msg); err != nil {
+		return dmError(err)
+	}
+	return nil
+}
+
+// dmError marca com service.ErrRecipientUnreachable a DM recusada porque o
+// membro fechou as mensagens diretas, que não adianta tentar de novo
+func dmError(err error) error {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Message != nil &&
+		restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser {
+		return fmt.Errorf("erro ao enviar DM: %w: %v", service.ErrRecipientUnreachable, err)
+	}
+	return fmt.Errorf("erro ao enviar DM: %w", err)
+}
+
+// UpdatePixMessage troca o embed do QR Code pelo status do pagamento e tira
+// o anexo com o QR Code, que não serve mais. Mensagem apagada não é erro
+func (n *Notifier) UpdatePixMessage(message repository.DiscordMessage, update service.PixMessageUpdate) error {
+	edit := discordgo.NewMessageEdit(message.ChannelID, message.MessageID)
+	edit.Embeds = []*discordgo.MessageEmbed{newRenderer().in(update.Locale).pixStatus(update)}
+	edit.Attachments = &[]*discordgo.MessageAttachment{}
+
+	_, err := n.session.ChannelMessageEditComplex(edit)
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Message != nil &&
+		(restErr.Message.Code == discordgo.ErrCodeUnknownMessage || restErr.Message.Code == discordgo.ErrCodeUnknownChannel) {
+		log.Printf("Mensagem do PIX #%d não existe mais: %v", update.TransactionID, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao editar mensagem do PIX #%d: %w", update.TransactionID, err)
+	}
+	return nil
+}
+
+func qrCodeFile(qrCodeBase64 string) (*discordgo.File, error) {
+	qrCodeBytes, err := base64.StdEncoding.DecodeString(qrCodeBase64)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar QR code base64: %w", err)
+	}
+
+	return &discordgo.File{
+		Name:        "qrcode.png",
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(qrCodeBytes),
+	}, nil
+}
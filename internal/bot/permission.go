@@ -0,0 +1,179 @@
+package bot
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+// rolesByCapability agrupa as permissões de um servidor por cargo
+func rolesByCapability(permissions []apiclient.RolePermission) map[string]map[string]bool {
+	roles := make(map[string]map[string]bool)
+	for _, p := range permissions {
+		if roles[p.RoleID] == nil {
+			roles[p.RoleID] = make(map[string]bool)
+		}
+		roles[p.RoleID][p.Capability] = true
+	}
+	return roles
+}
+
+// can indica se o autor da mensagem tem a capacidade. Os donos
+// (ADMIN_DISCORD_IDS) podem tudo; os demais dependem dos cargos no servidor.
+// A API confere de novo com os cabeçalhos de b.caller
+func (b *Bot) can(m *discordgo.MessageCreate, capability string) bool {
+	if b.isAdmin(m.Author.ID) {
+		return true
+	}
+	guildID, member := b.guildOf(m)
+	if guildID == "" || member == nil {
+		return false
+	}
+
+	now := time.Now()
+	roles, ok := b.permissions.get(guildID, now)
+	if !ok {
+		permissions, err := b.api.Permissions(guildID)
+		if err != nil {
+			log.Printf("Erro ao buscar permissões do servidor %s: %v", guildID, err)
+			return false
+		}
+		roles = rolesByCapability(permissions)
+		b.permissions.set(guildID, roles, now)
+	}
+
+	for _, roleID := range member.Roles {
+		if roles[roleID][capability] {
+			return true
+		}
+	}
+	return false
+}
+
+// require avisa o autor quando falta a capacidade
+func (b *Bot) require(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, capability string) bool {
+	if b.can(m, capability) {
+		return true
+	}
+	b.sendError(s, m.ChannelID, i18n.T(loc, "bot.forbidden", i18n.T(loc, "capability."+capability)))
+	return false
+}
+
+// caller identifica o autor da mensagem nas chamadas administrativas à API
+func (b *Bot) caller(m *discordgo.MessageCreate) apiclient.Caller {
+	guildID, member := b.guildOf(m)
+	caller := apiclient.Caller{DiscordID: m.Author.ID, GuildID: guildID}
+	if member != nil {
+		caller.RoleIDs = member.Roles
+	}
+	return caller
+}
+
+var roleMention = regexp.MustCompile(`^(?:<@&(\d+)>|(\d+))$`)
+
+// handlePermissionCommand trata `!permissao [listar]`, `!permissao
+// capacidades` e `!permissao dar|tirar @cargo <capacidade>`
+func (b *Bot) handlePermissionCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	if len(args) == 1 && args[0] == "capacidades" {
+		b.showCapabilities(s, m, loc)
+		return
+	}
+
+	if m.GuildID == "" {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.permission.guild_only"))
+		return
+	}
+
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "listar"):
+		b.listPermissions(s, m, loc)
+	case len(args) == 3 && (args[0] == "dar" || args[0] == "tirar"):
+		b.changePermission(s, m, loc, args[0] == "dar", args[1], strings.ToLower(args[2]))
+	default:
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.permission.usage"))
+	}
+}
+
+func (b *Bot) showCapabilities(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale) {
+	message := i18n.T(loc, "bot.permission.capabilities") + "\n"
+	for _, capability := range apiclient.Capabilities {
+		message += "\n" + i18n.T(loc, "bot.permission.capability", capability, i18n.T(loc, "capability."+capability))
+	}
+	s.ChannelMessageSend(m.ChannelID, message)
+}
+
+func (b *Bot) listPermissions(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale) {
+	permissions, err := b.api.As(b.caller(m)).Permissions(m.GuildID)
+	if err != nil {
+		log.Printf("Erro ao listar permissões: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.permission.error"))
+		return
+	}
+	b.permissions.set(m.GuildID, rolesByCapability(permissions), time.Now())
+
+	if len(permissions) == 0 {
+		s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.permission.empty"))
+		return
+	}
+
+	// A API devolve as permissões ordenadas por cargo
+	message := i18n.T(loc, "bot.permission.header") + "\n"
+	for i := 0; i < len(permissions); {
+		roleID := permissions[i].RoleID
+		var capabilities []string
+		for ; i < len(permissions) && permissions[i].RoleID == roleID; i++ {
+			capabilities = append(capabilities, "`"+permissions[i].Capability+"`")
+		}
+		message += "\n" + i18n.T(loc, "bot.permission.role", roleID, strings.Join(capabilities, ", "))
+	}
+	s.ChannelMessageSend(m.ChannelID, message)
+}
+
+func (b *Bot) changePermission(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, grant bool, role, capability string) {
+	match := roleMention.FindStringSubmatch(role)
+	if match == nil {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.permission.invalid_role"))
+		return
+	}
+	roleID := match[1] + match[2]
+
+	valid := false
+	for _, c := range apiclient.Capabilities {
+		valid = valid || c == capability
+	}
+	if !valid {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.permission.invalid_capability"))
+		return
+	}
+
+	api := b.api.As(b.caller(m))
+	var change *apiclient.PermissionChange
+	var err error
+	if grant {
+		change, err = api.GrantPermission(m.GuildID, roleID, capability)
+	} else {
+		change, err = api.RevokePermission(m.GuildID, roleID, capability)
+	}
+	if err != nil {
+		log.Printf("Erro ao alterar permissão: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.permission.error"))
+		return
+	}
+	b.permissions.forget(m.GuildID)
+
+	key := "bot.permission.revoked"
+	switch {
+	case grant && change.Changed:
+		key = "bot.permission.granted"
+	case grant:
+		key = "bot.permission.already_granted"
+	case !change.Changed:
+		key = "bot.permission.not_granted"
+	}
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, key, capability, roleID))
+}
@@ -0,0 +1,254 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+// Cores dos embeds, por tipo de resposta
+const (
+	colorSuccess = 0x2ecc71
+	colorInfo    = 0x3498db
+	colorWarning = 0xf1c40f
+	colorError   = 0xe74c3c
+)
+
+const embedFooter = "Família Steam"
+
+// renderer monta os embeds das respostas do bot no idioma de quem pediu.
+// now é injetável para que os testes golden gerem sempre o mesmo timestamp
+type renderer struct {
+	now    func() time.Time
+	locale i18n.Locale
+	prefix string
+}
+
+func newRenderer() *renderer {
+	return &renderer{now: time.Now, locale: i18n.Default, prefix: defaultCommandPrefix}
+}
+
+// in devolve uma cópia do renderer que escreve no idioma informado
+func (r *renderer) in(locale i18n.Locale) *renderer {
+	c := *r
+	c.locale = locale
+	return &c
+}
+
+// withPrefix devolve uma cópia do renderer que mostra os comandos com o
+// prefixo do servidor
+func (r *renderer) withPrefix(prefix string) *renderer {
+	c := *r
+	c.prefix = prefix
+	return &c
+}
+
+func (r *renderer) t(key string, args ...interface{}) string {
+	return i18n.T(r.locale, key, args...)
+}
+
+func (r *renderer) money(v float64) string {
+	return i18n.FormatMoney(r.locale, v)
+}
+
+func (r *renderer) embed(title, description string, color int) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: embedFooter},
+		Timestamp:   r.now().UTC().Format(time.RFC3339),
+	}
+}
+
+func avatarThumbnail(user *discordgo.User) *discordgo.MessageEmbedThumbnail {
+	if user == nil {
+		return nil
+	}
+	return &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("128")}
+}
+
+func (r *renderer) error(message string) *discordgo.MessageEmbed {
+	return r.embed("", message, colorError)
+}
+
+// pix mostra o PIX recém-criado; o QR Code vai como anexo qrcode.png
+func (r *renderer) pix(author *discordgo.User, payment *apiclient.Payment) *discordgo.MessageEmbed {
+	e := r.embed(r.t("embed.pix.title"), r.t("embed.pix.description"), colorSuccess)
+	e.Thumbnail = avatarThumbnail(author)
+	e.Image = &discordgo.MessageEmbedImage{URL: "attachment://qrcode.png"}
+	e.Fields = []*discordgo.MessageEmbedField{
+		{Name: r.t("embed.field.amount"), Value: "**" + r.money(payment.Amount) + "**", Inline: true},
+		{Name: r.t("embed.field.transaction"), Value: fmt.Sprintf("`%d`", payment.TransactionID), Inline: true},
+	}
+	if payment.QRCode != "" {
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name:  r.t("embed.pix.copy_paste"),
+			Value: "```" + payment.QRCode + "```",
+		})
+	}
+	if payment.RequiresApproval {
+		e.Color = colorWarning
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name:  r.t("embed.pix.requires_approval.title"),
+			Value: r.t("embed.pix.requires_approval.description"),
+		})
+	}
+	return e
+}
+
+func (r *renderer) pixWithoutQRCode(payment *apiclient.Payment) *discordgo.MessageEmbed {
+	e := r.embed(r.t("embed.pix_without_qrcode.title"), r.t("embed.pix_without_qrcode.description"), colorWarning)
+	e.Fields = []*discordgo.MessageEmbedField{
+		{Name: r.t("embed.field.amount"), Value: r.money(payment.Amount), Inline: true},
+		{Name: r.t("embed.field.transaction"), Value: fmt.Sprintf("`%d`", payment.TransactionID), Inline: true},
+	}
+	return e
+}
+
+// pixStatus substitui o embed do PIX quando ele é pago, expira ou falha;
+// sem o QR Code, que não pode mais ser usado
+func (r *renderer) pixStatus(update service.PixMessageUpdate) *discordgo.MessageEmbed {
+	var key string
+	color := colorSuccess
+	switch update.Status {
+	case repository.StatusConfirmed:
+		key = "embed.pix_status.confirmed"
+	case repository.StatusAwaitingApproval:
+		key = "embed.pix_status.awaiting_approval"
+		color = colorWarning
+	case repository.StatusExpired:
+		key = "embed.pix_status.expired"
+		color = colorWarning
+	default:
+		key = "embed.pix_status.failed"
+		color = colorError
+	}
+
+	e := r.embed(r.t(key+".title"), r.t(key+".description"), color)
+	e.Fields = []*discordgo.MessageEmbedField{
+		{Name: r.t("embed.field.amount"), Value: "**" + r.money(update.Amount) + "**", Inline: true},
+		{Name: r.t("embed.field.transaction"), Value: fmt.Sprintf("`%d`", update.TransactionID), Inline: true},
+	}
+	return e
+}
+
+func (r *renderer) balance(author *discordgo.User, balance *apiclient.Balance) *discordgo.MessageEmbed {
+	e := r.embed(r.t("embed.balance.title"), "", colorSuccess)
+	e.Thumbnail = avatarThumbnail(author)
+	e.Fields = []*discordgo.MessageEmbedField{
+		{Name: r.t("embed.balance.balance"), Value: r.money(balance.Balance), Inline: true},
+		{Name: r.t("embed.balance.available"), Value: "**" + r.money(balance.Available) + "**", Inline: true},
+	}
+	if balance.Spent > 0 {
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name: r.t("embed.balance.spent"), Value: r.money(balance.Spent), Inline: true,
+		})
+	}
+	return e
+}
+
+func (r *renderer) total(total *apiclient.Total) *discordgo.MessageEmbed {
+	e := r.embed(r.t("embed.total.title"), r.t("embed.total.description", i18n.Money(total.Available)), colorSuccess)
+	e.Fields = []*discordgo.MessageEmbedField{
+		{Name: r.t("embed.total.raised"), Value: r.money(total.Raised), Inline: true},
+		{Name: r.t("embed.total.spent"), Value: r.money(total.Spent), Inline: true},
+		{Name: r.t("embed.total.available"), Value: r.money(total.Available), Inline: true},
+		{Name: r.t("embed.total.pending"), Value: r.money(total.Pending), Inline: true},
+		{Name: r.t("embed.total.contributors"), Value: strconv.Itoa(total.Contributors), Inline: true},
+	}
+	return e
+}
+
+// ranking lista os contribuidores pelo ID (menções em embeds não notificam
+// ninguém) e, se quem pediu ficou fora da lista, mostra a posição dele
+func (r *renderer) ranking(period string, ranking *apiclient.Ranking, callerID string) *discordgo.MessageEmbed {
+	title := r.t("embed.ranking.title." + period)
+	if len(ranking.Entries) == 0 {
+		return r.embed(title, r.t("embed.ranking.empty"), colorInfo)
+	}
+
+	var lines []string
+	callerListed := false
+	for _, entry := range ranking.Entries {
+		var medal string
+		switch entry.Rank {
+		case 1:
+			medal = "🥇"
+		case 2:
+			medal = "🥈"
+		case 3:
+			medal = "🥉"
+		default:
+			medal = fmt.Sprintf("%d.", entry.Rank)
+		}
+		lines = append(lines, fmt.Sprintf("%s <@%s> — %s", medal, entry.DiscordID, r.money(entry.Balance)))
+		if entry.DiscordID == callerID {
+			callerListed = true
+		}
+	}
+
+	e := r.embed(title, strings.Join(lines, "\n"), colorInfo)
+	if ranking.Caller != nil && !callerListed {
+		e.Fields = []*discordgo.MessageEmbedField{{
+			Name:  r.t("embed.ranking.caller"),
+			Value: r.t("embed.ranking.caller_position", ranking.Caller.Rank, i18n.Money(ranking.Caller.Balance)),
+		}}
+	}
+	return e
+}
+
+// help lista os comandos do registro; os que exigem capacidade levam 🔒
+func (r *renderer) help(commands []*command) *discordgo.MessageEmbed {
+	var lines []string
+	for _, c := range commands {
+		line := fmt.Sprintf("`%s` — %s", c.usage(r.prefix), r.t("command."+c.name))
+		if c.capability != "" {
+			line += " 🔒"
+		}
+		lines = append(lines, line)
+	}
+
+	e := r.embed(r.t("embed.help.title"), strings.Join(lines, "\n"), colorInfo)
+	e.Fields = []*discordgo.MessageEmbedField{{
+		Name:  r.t("embed.help.more"),
+		Value: r.t("embed.help.more_description", r.prefix+"ajuda"),
+	}}
+	return e
+}
+
+func (r *renderer) commandHelp(c *command) *discordgo.MessageEmbed {
+	e := r.embed(r.prefix+c.name, r.t("command."+c.name), colorInfo)
+	e.Fields = []*discordgo.MessageEmbedField{
+		{Name: r.t("embed.help.usage"), Value: "`" + c.usage(r.prefix) + "`"},
+	}
+	if len(c.aliases) > 0 {
+		aliases := make([]string, len(c.aliases))
+		for i, alias := range c.aliases {
+			aliases[i] = "`" + r.prefix + alias + "`"
+		}
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name: r.t("embed.help.aliases"), Value: strings.Join(aliases, ", "), Inline: true,
+		})
+	}
+	if c.capability != "" {
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name:   r.t("embed.help.permission"),
+			Value:  fmt.Sprintf("`%s` — %s", c.capability, r.t("capability."+c.capability)),
+			Inline: true,
+		})
+	}
+	if c.cooldown > 0 {
+		e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+			Name: r.t("embed.help.cooldown"), Value: waitText(r.locale, c.cooldown), Inline: true,
+		})
+	}
+	return e
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+	"github.com/mateus/familia-steam/internal/service"
+)
+
+// go test ./internal/bot -update regrava os arquivos em testdata
+var update = flag.Bool("update", false, "regrava os arquivos golden")
+
+func testRenderer() *renderer {
+	return &renderer{
+		now: func() time.Time {
+			return time.Date(2024, 3, 15, 18, 30, 0, 0, time.UTC)
+		},
+		locale: i18n.Default,
+		prefix: defaultCommandPrefix,
+	}
+}
+
+var testAuthor = &discordgo.User{ID: "111", Username: "fulano", Avatar: "abc123"}
+
+func TestEmbedsGolden(t *testing.T) {
+	r := testRenderer()
+
+	payment := &apiclient.Payment{
+		TransactionID: 42,
+		Amount:        10.5,
+		QRCode:        "00020126580014br.gov.bcb.pix0136123e4567",
+		QRCodeBase64:  "iVBORw0KGgo=",
+	}
+	heldPayment := *payment
+	heldPayment.RequiresApproval = true
+
+	ranking := &apiclient.Ranking{
+		Period: "sempre",
+		Entries: []apiclient.RankingEntry{
+			{Rank: 1, DiscordID: "201", Username: "a", Balance: 1500},
+			{Rank: 2, DiscordID: "202", Username: "b", Balance: 320.4},
+			{Rank: 3, DiscordID: "203", Username: "c", Balance: 50},
+			{Rank: 4, DiscordID: "204", Username: "d", Balance: 10.5},
+		},
+		Caller: &apiclient.RankingEntry{Rank: 12, DiscordID: "111", Username: "fulano", Balance: 5},
+	}
+
+	amountOutOfRange := &apiclient.Error{
+		StatusCode: 400,
+		Code:       "amount_out_of_range",
+		Details:    map[string]interface{}{"min_amount": 1.0, "max_amount": 1000.0},
+	}
+	rateLimited := &apiclient.Error{
+		StatusCode: 429,
+		Code:       "rate_limited",
+		RetryAfter: 90 * time.Second,
+	}
+	balance := &apiclient.Balance{Balance: 1234.5, Spent: 200, Available: 1034.5}
+	pixStatus := func(status repository.TransactionStatus) service.PixMessageUpdate {
+		return service.PixMessageUpdate{TransactionID: 42, Amount: 10.5, Status: status}
+	}
+	commands := testRegistry()
+
+	tests := []struct {
+		name  string
+		embed *discordgo.MessageEmbed
+	}{
+		{"pix", r.pix(testAuthor, payment)},
+		{"pix_requires_approval", r.pix(testAuthor, &heldPayment)},
+		{"pix_without_qrcode", r.pixWithoutQRCode(&apiclient.Payment{TransactionID: 43, Amount: 25})},
+		{"pix_status_confirmed", r.pixStatus(pixStatus(repository.StatusConfirmed))},
+		{"pix_status_awaiting_approval", r.pixStatus(pixStatus(repository.StatusAwaitingApproval))},
+		{"pix_status_expired", r.pixStatus(pixStatus(repository.StatusExpired))},
+		{"pix_status_failed", r.pixStatus(pixStatus(repository.StatusFailed))},
+		{"balance", r.balance(testAuthor, balance)},
+		{"balance_nothing_spent", r.balance(testAuthor, &apiclient.Balance{Balance: 30, Available: 30})},
+		{"total", r.total(&apiclient.Total{Raised: 5000, Spent: 1234.56, Available: 3765.44, Pending: 20, Contributors: 7})},
+		{"ranking", r.ranking("sempre", ranking, "111")},
+		{"ranking_empty", r.ranking("mes", &apiclient.Ranking{Period: "mes"}, "111")},
+		{"error_amount_out_of_range", r.error(paymentErrorMessage(i18n.PtBR, amountOutOfRange))},
+		{"error_rate_limited", r.error(paymentErrorMessage(i18n.PtBR, rateLimited))},
+		{"help", r.help(commands.commands)},
+		{"help_command", r.commandHelp(commands.lookup("exportar"))},
+		{"help_command_simple", r.commandHelp(commands.lookup("ping"))},
+
+		{"en/pix", r.in(i18n.EN).pix(testAuthor, &heldPayment)},
+		{"en/balance", r.in(i18n.EN).balance(testAuthor, balance)},
+		{"en/pix_status_expired", r.in(i18n.EN).pixStatus(pixStatus(repository.StatusExpired))},
+		{"en/ranking", r.in(i18n.EN).ranking("mes", ranking, "111")},
+		{"en/error_amount_out_of_range", r.error(paymentErrorMessage(i18n.EN, amountOutOfRange))},
+		{"en/help", r.in(i18n.EN).help(commands.commands)},
+		{"en/help_command", r.in(i18n.EN).commandHelp(commands.lookup("pix"))},
+		{"es/pix", r.in(i18n.ES).pix(testAuthor, &heldPayment)},
+		{"es/pix_status_confirmed", r.in(i18n.ES).pixStatus(pixStatus(repository.StatusConfirmed))},
+		{"es/total", r.in(i18n.ES).total(&apiclient.Total{Raised: 5000, Spent: 1234.56, Available: 3765.44, Pending: 20, Contributors: 7})},
+		{"es/error_rate_limited", r.error(paymentErrorMessage(i18n.ES, rateLimited))},
+		{"es/help_command", r.in(i18n.ES).commandHelp(commands.lookup("permissao"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(tt.embed); err != nil {
+				t.Fatalf("erro ao serializar embed: %v", err)
+			}
+			got := buf.Bytes()
+
+			path := filepath.Join("testdata", tt.name+".golden.json")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("erro ao gravar %s: %v", path, err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("erro ao ler %s (rode com -update para criar): %v", path, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("embed diferente de %s\n--- obtido\n%s\n--- esperado\n%s", path, got, want)
+			}
+		})
+	}
+}
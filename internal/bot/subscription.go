@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+func (b *Bot) handleSubscriptionCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	switch {
+	case len(args) == 1 && args[0] == "status":
+		b.handleSubscriptionStatus(s, m, loc)
+	case len(args) == 1 && args[0] == "cancelar":
+		b.handleSubscriptionCancel(s, m, loc)
+	case len(args) == 2:
+		b.handleSubscribe(s, m, loc, args[0], args[1])
+	default:
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.subscription.usage"))
+	}
+}
+
+func (b *Bot) handleSubscribe(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, amountStr, dayStr string) {
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err != nil || amount <= 0 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.subscription.invalid_amount"))
+		return
+	}
+
+	day, err := strconv.Atoi(dayStr)
+	if err != nil || day < 1 || day > 31 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.subscription.invalid_day"))
+		return
+	}
+
+	if _, err := b.api.As(b.caller(m)).Subscribe(apiclient.SubscribeRequest{
+		DiscordID: m.Author.ID,
+		Username:  m.Author.Username,
+		Amount:    amount,
+		DueDay:    day,
+	}); err != nil {
+		log.Printf("Erro ao salvar mensalidade: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.subscription.save_error"))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.subscription.saved", i18n.Money(amount), day))
+}
+
+func (b *Bot) handleSubscriptionCancel(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale) {
+	err := b.api.As(b.caller(m)).CancelSubscription(m.Author.ID)
+	switch {
+	case err == nil:
+		s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.subscription.cancelled"))
+	case apiclient.IsNotFound(err):
+		s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.subscription.not_found"))
+	default:
+		log.Printf("Erro ao cancelar mensalidade: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.subscription.cancel_error"))
+	}
+}
+
+func (b *Bot) handleSubscriptionStatus(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale) {
+	status, err := b.api.SubscriptionStatus()
+	if err != nil {
+		log.Printf("Erro ao buscar mensalidades: %v", err)
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.subscription.status_error"))
+		return
+	}
+
+	if len(status.Members) == 0 {
+		s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.subscription.status_empty"))
+		return
+	}
+
+	message := i18n.T(loc, "bot.subscription.status_header", status.Period) + "\n\n"
+	for _, member := range status.Members {
+		icon := "⏳"
+		switch {
+		case member.UpToDate:
+			icon = "✅"
+		case member.Overdue:
+			icon = "❌"
+		}
+		message += i18n.T(loc, "bot.subscription.status_member",
+			icon, member.Username, i18n.Money(member.Paid), i18n.Money(member.Amount), member.DueDay) + "\n"
+	}
+
+	s.ChannelMessageSend(m.ChannelID, message)
+}
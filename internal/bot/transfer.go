@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mateus/familia-steam/internal/apiclient"
+	"github.com/mateus/familia-steam/internal/i18n"
+)
+
+func (b *Bot) handleTransferCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc i18n.Locale, args []string) {
+	if len(args) != 2 || len(m.Mentions) != 1 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.transfer.usage"))
+		return
+	}
+
+	recipient := m.Mentions[0]
+	if recipient.Bot {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.transfer.to_bot"))
+		return
+	}
+	if recipient.ID == m.Author.ID {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.transfer.same_user"))
+		return
+	}
+
+	amount, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || amount <= 0 {
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.transfer.invalid_amount"))
+		return
+	}
+
+	transfer, err := b.api.As(b.caller(m)).Transfer(apiclient.TransferRequest{
+		FromDiscordID: m.Author.ID,
+		FromUsername:  m.Author.Username,
+		ToDiscordID:   recipient.ID,
+		ToUsername:    recipient.Username,
+		Amount:        amount,
+	}, "discord-message-"+m.ID)
+	if err != nil {
+		log.Printf("Erro ao transferir: %v", err)
+		if apiclient.StatusCode(err) == http.StatusUnprocessableEntity {
+			b.sendError(s, m.ChannelID, i18n.T(loc, "bot.transfer.insufficient_balance"))
+			return
+		}
+		b.sendError(s, m.ChannelID, i18n.T(loc, "bot.transfer.error"))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, i18n.T(loc, "bot.transfer.done",
+		m.Author.Mention(), recipient.Mention(), i18n.Money(transfer.Amount), transfer.DebitTransactionID))
+}
@@ -3,6 +3,13 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/api"
+	"github.com/mateus/familia-steam/internal/mercadopago"
+	"github.com/mateus/familia-steam/internal/service"
 )
 
 type Config struct {
@@ -10,33 +17,263 @@ type Config struct {
 	DatabaseURL      string
 	DiscordToken     string
 	MercadoPagoToken string
+
+	// Endereço da API usado pelo bot
+	APIBaseURL string
+
+	// Token do bot, exigido nas rotas administrativas e nas que agem em nome
+	// de um membro, e Discord IDs com acesso aos comandos administrativos
+	AdminAPIToken   string
+	AdminDiscordIDs []string
+
+	// Origens liberadas para chamar a API pelo navegador ("*" libera todas)
+	CORSAllowedOrigins []string
+
+	// Como o valor de uma compra é dividido entre os membros quando a
+	// compra não informa o modo: pro_rata ou equal
+	PurchaseSplitMode string
+
+	// Limites para criar PIX: valores aceitos, PIX pendentes por carteira e
+	// cobranças por Discord ID e por IP a cada PaymentRateWindow
+	PaymentMinAmount     float64
+	PaymentMaxAmount     float64
+	PaymentMaxPending    int
+	PaymentPendingWindow time.Duration
+	PaymentRatePerUser   int
+	PaymentRatePerIP     int
+	PaymentRateWindow    time.Duration
+
+	// De quanto em quanto tempo os PIX pendentes são consultados no Mercado
+	// Pago caso o webhook não chegue; zero desliga
+	PaymentPollInterval time.Duration
+
+	MercadoPagoTimeout          time.Duration
+	MercadoPagoDeadline         time.Duration
+	MercadoPagoMaxRetries       int
+	MercadoPagoRetryBaseDelay   time.Duration
+	MercadoPagoRetryMaxDelay    time.Duration
+	MercadoPagoBreakerThreshold int
+	MercadoPagoBreakerCooldown  time.Duration
 }
 
+// Load carrega a configuração da API, sozinha ou junto com o bot
 func Load() (*Config, error) {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	cfg, err := load()
+	if err != nil {
+		return nil, err
 	}
 
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
+	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL é obrigatória")
 	}
 
-	discordToken := os.Getenv("DISCORD_TOKEN")
-	if discordToken == "" {
+	if cfg.DiscordToken == "" {
 		return nil, fmt.Errorf("DISCORD_TOKEN é obrigatória")
 	}
 
-	mercadoPagoToken := os.Getenv("MERCADOPAGO_ACCESS_TOKEN")
-	if mercadoPagoToken == "" {
+	if cfg.MercadoPagoToken == "" {
 		return nil, fmt.Errorf("MERCADOPAGO_ACCESS_TOKEN é obrigatória")
 	}
 
-	return &Config{
+	// Criar um PIX espera o Mercado Pago dentro da requisição HTTP
+	if cfg.MercadoPagoDeadline <= 0 || cfg.MercadoPagoDeadline >= api.WriteTimeout {
+		return nil, fmt.Errorf("MERCADOPAGO_DEADLINE deve ser maior que zero e menor que %s, o prazo de resposta da API", api.WriteTimeout)
+	}
+
+	// Sem o token nenhuma rota do bot aceita chamadas
+	if cfg.AdminAPIToken == "" {
+		return nil, fmt.Errorf("ADMIN_API_TOKEN é obrigatória")
+	}
+
+	return cfg, nil
+}
+
+// LoadAdmin carrega a configuração da CLI administrativa, que só exige o
+// banco; o token do Mercado Pago é necessário apenas para ressincronizar
+func LoadAdmin() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DatabaseURL == "" {
+		return nil, fmt.Errorf("DATABASE_URL é obrigatória")
+	}
+
+	return cfg, nil
+}
+
+// LoadBot carrega a configuração do bot rodando sozinho: ele não acessa o
+// banco, só a API em API_BASE_URL
+func LoadBot() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DiscordToken == "" {
+		return nil, fmt.Errorf("DISCORD_TOKEN é obrigatória")
+	}
+
+	if os.Getenv("API_BASE_URL") == "" {
+		return nil, fmt.Errorf("API_BASE_URL é obrigatória")
+	}
+
+	if cfg.AdminAPIToken == "" {
+		return nil, fmt.Errorf("ADMIN_API_TOKEN é obrigatória")
+	}
+
+	return cfg, nil
+}
+
+func load() (*Config, error) {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// Rodando junto com a API, o bot a encontra na própria máquina
+	apiBaseURL := strings.TrimSuffix(os.Getenv("API_BASE_URL"), "/")
+	if apiBaseURL == "" {
+		apiBaseURL = "http://localhost:" + port
+	}
+
+	cfg := &Config{
 		Port:             port,
-		DatabaseURL:      databaseURL,
-		DiscordToken:     discordToken,
-		MercadoPagoToken: mercadoPagoToken,
-	}, nil
+		DatabaseURL:      os.Getenv("DATABASE_URL"),
+		DiscordToken:     os.Getenv("DISCORD_TOKEN"),
+		MercadoPagoToken: os.Getenv("MERCADOPAGO_ACCESS_TOKEN"),
+		APIBaseURL:       apiBaseURL,
+		AdminAPIToken:    os.Getenv("ADMIN_API_TOKEN"),
+		AdminDiscordIDs:  listEnv("ADMIN_DISCORD_IDS"),
+
+		CORSAllowedOrigins: listEnv("CORS_ALLOWED_ORIGINS"),
+		PurchaseSplitMode:  os.Getenv("PURCHASE_SPLIT_MODE"),
+	}
+	if cfg.PurchaseSplitMode == "" {
+		cfg.PurchaseSplitMode = "pro_rata"
+	}
+
+	var err error
+	if cfg.MercadoPagoTimeout, err = durationEnv("MERCADOPAGO_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.MercadoPagoDeadline, err = durationEnv("MERCADOPAGO_DEADLINE", 12*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.MercadoPagoMaxRetries, err = intEnv("MERCADOPAGO_MAX_RETRIES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.MercadoPagoRetryBaseDelay, err = durationEnv("MERCADOPAGO_RETRY_BASE_DELAY", 500*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if cfg.MercadoPagoRetryMaxDelay, err = durationEnv("MERCADOPAGO_RETRY_MAX_DELAY", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.MercadoPagoBreakerThreshold, err = intEnv("MERCADOPAGO_BREAKER_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if cfg.MercadoPagoBreakerCooldown, err = durationEnv("MERCADOPAGO_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.PaymentMinAmount, err = floatEnv("PAYMENT_MIN_AMOUNT", 1); err != nil {
+		return nil, err
+	}
+	if cfg.PaymentMaxAmount, err = floatEnv("PAYMENT_MAX_AMOUNT", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.PaymentMaxAmount > 0 && cfg.PaymentMaxAmount < cfg.PaymentMinAmount {
+		return nil, fmt.Errorf("PAYMENT_MAX_AMOUNT deve ser maior que PAYMENT_MIN_AMOUNT")
+	}
+	if cfg.PaymentMaxPending, err = intEnv("PAYMENT_MAX_PENDING", 3); err != nil {
+		return nil, err
+	}
+	if cfg.PaymentPendingWindow, err = durationEnv("PAYMENT_PENDING_WINDOW", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.PaymentPollInterval, err = durationEnv("PAYMENT_POLL_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.PaymentRatePerUser, err = intEnv("PAYMENT_RATE_PER_USER", 3); err != nil {
+		return nil, err
+	}
+	if cfg.PaymentRatePerIP, err = intEnv("PAYMENT_RATE_PER_IP", 10); err != nil {
+		return nil, err
+	}
+	if cfg.PaymentRateWindow, err = durationEnv("PAYMENT_RATE_WINDOW", time.Minute); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) MercadoPagoOptions() mercadopago.Options {
+	return mercadopago.Options{
+		Timeout:          c.MercadoPagoTimeout,
+		Deadline:         c.MercadoPagoDeadline,
+		MaxRetries:       c.MercadoPagoMaxRetries,
+		RetryBaseDelay:   c.MercadoPagoRetryBaseDelay,
+		RetryMaxDelay:    c.MercadoPagoRetryMaxDelay,
+		BreakerThreshold: c.MercadoPagoBreakerThreshold,
+		BreakerCooldown:  c.MercadoPagoBreakerCooldown,
+	}
+}
+
+func (c *Config) PaymentLimits() service.PaymentLimits {
+	return service.PaymentLimits{
+		MinAmount:     c.PaymentMinAmount,
+		MaxAmount:     c.PaymentMaxAmount,
+		MaxPending:    c.PaymentMaxPending,
+		PendingWindow: c.PaymentPendingWindow,
+	}
+}
+
+func listEnv(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func intEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s deve ser um inteiro não negativo", key)
+	}
+	return n, nil
+}
+
+func floatEnv(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%s deve ser um número não negativo", key)
+	}
+	return f, nil
+}
+
+func durationEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s deve ser uma duração válida (ex: 500ms, 30s)", key)
+	}
+	return d, nil
 }
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, key := range []string{"DATABASE_URL", "DISCORD_TOKEN", "MERCADOPAGO_ACCESS_TOKEN", "ADMIN_API_TOKEN", "API_BASE_URL", "PORT", "MERCADOPAGO_DEADLINE"} {
+		t.Setenv(key, env[key])
+	}
+}
+
+func TestLoadRequirements(t *testing.T) {
+	complete := map[string]string{
+		"DATABASE_URL":             "postgres://localhost/vaquinha",
+		"DISCORD_TOKEN":            "discord",
+		"MERCADOPAGO_ACCESS_TOKEN": "mp",
+		"ADMIN_API_TOKEN":          "segredo",
+	}
+	without := func(key string) map[string]string {
+		env := map[string]string{}
+		for k, v := range complete {
+			if k != key {
+				env[k] = v
+			}
+		}
+		return env
+	}
+	with := func(key, value string) map[string]string {
+		env := without("")
+		env[key] = value
+		return env
+	}
+
+	tests := []struct {
+		name    string
+		load    func() (*Config, error)
+		env     map[string]string
+		wantErr string
+	}{
+		{"API completa", Load, complete, ""},
+		{"API sem token admin", Load, without("ADMIN_API_TOKEN"), "ADMIN_API_TOKEN"},
+		{"API com prazo acima do da resposta", Load, with("MERCADOPAGO_DEADLINE", "20s"), "MERCADOPAGO_DEADLINE"},
+		{"bot sozinho", LoadBot, map[string]string{"DISCORD_TOKEN": "discord", "API_BASE_URL": "http://api", "ADMIN_API_TOKEN": "segredo"}, ""},
+		{"bot sem API_BASE_URL", LoadBot, map[string]string{"DISCORD_TOKEN": "discord", "ADMIN_API_TOKEN": "segredo"}, "API_BASE_URL"},
+		{"bot sem token admin", LoadBot, map[string]string{"DISCORD_TOKEN": "discord", "API_BASE_URL": "http://api"}, "ADMIN_API_TOKEN"},
+		{"CLI só com o banco", LoadAdmin, map[string]string{"DATABASE_URL": "postgres://localhost/vaquinha"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			_, err := tt.load()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("erro inesperado: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("erro = %v, quer menção a %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIBaseURLDefaultsToTheLocalPort(t *testing.T) {
+	setEnv(t, map[string]string{"DISCORD_TOKEN": "discord", "PORT": "9090"})
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("load = %v", err)
+	}
+	if cfg.APIBaseURL != "http://localhost:9090" {
+		t.Errorf("APIBaseURL = %q", cfg.APIBaseURL)
+	}
+
+	setEnv(t, map[string]string{"API_BASE_URL": "https://api.exemplo/"})
+	if cfg, _ = load(); cfg.APIBaseURL != "https://api.exemplo" {
+		t.Errorf("APIBaseURL = %q, quer sem a barra final", cfg.APIBaseURL)
+	}
+}
@@ -0,0 +1,194 @@
+// Package i18n guarda o catálogo de mensagens do bot e da API em pt-BR,
+// inglês e espanhol. As traduções ficam em locales/*.json, uma chave por
+// mensagem, com verbos do fmt para os valores.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Locale string
+
+const (
+	PtBR Locale = "pt-BR"
+	EN   Locale = "en"
+	ES   Locale = "es"
+
+	// Default é usado quando nem o usuário, nem o servidor, nem o Discord
+	// indicam um idioma suportado
+	Default = PtBR
+)
+
+// Supported lista os idiomas do catálogo, o padrão primeiro
+var Supported = []Locale{PtBR, EN, ES}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalog = loadCatalog()
+
+func loadCatalog() map[Locale]map[string]string {
+	c := make(map[Locale]map[string]string, len(Supported))
+	for _, locale := range Supported {
+		data, err := localeFiles.ReadFile("locales/" + string(locale) + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: catálogo %s ausente: %v", locale, err))
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: catálogo %s inválido: %v", locale, err))
+		}
+		c[locale] = messages
+	}
+	return c
+}
+
+// Parse aceita o idioma como o Discord e o Accept-Language o informam
+// (pt-BR, en-US, es-419...) ou só o idioma (pt, en, es)
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+
+	switch tag {
+	case "pt":
+		return PtBR, true
+	case "en":
+		return EN, true
+	case "es":
+		return ES, true
+	}
+	return "", false
+}
+
+// Negotiate escolhe o idioma de um cabeçalho Accept-Language, respeitando
+// os pesos (q=). Sem nenhum idioma suportado, devolve Default
+func Negotiate(acceptLanguage string) Locale {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if locale, ok := Parse(tag); ok && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+// Money é um valor em reais; como argumento de T ele é formatado no padrão
+// do idioma (R$ 1.234,56 ou R$ 1,234.56)
+type Money float64
+
+// FormatMoney formata um valor em reais no padrão do idioma
+func FormatMoney(locale Locale, v float64) string {
+	thousands, decimal := ".", ","
+	if locale == EN {
+		thousands, decimal = ",", "."
+	}
+
+	cents := int64(math.Round(math.Abs(v) * 100))
+	reais := strconv.FormatInt(cents/100, 10)
+
+	var b strings.Builder
+	for i, digit := range reais {
+		if i > 0 && (len(reais)-i)%3 == 0 {
+			b.WriteString(thousands)
+		}
+		b.WriteRune(digit)
+	}
+
+	sign := ""
+	if v < 0 && cents > 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%sR$ %s%s%02d", sign, b.String(), decimal, cents%100)
+}
+
+// T traduz key para o idioma, caindo para Default e, em último caso, para
+// a própria chave
+func T(locale Locale, key string, args ...interface{}) string {
+	message, ok := catalog[locale][key]
+	if !ok {
+		if message, ok = catalog[Default][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+
+	formatted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case Money:
+			formatted[i] = FormatMoney(locale, float64(v))
+		case Locale:
+			formatted[i] = T(locale, "locale."+string(v))
+		default:
+			formatted[i] = arg
+		}
+	}
+	return fmt.Sprintf(message, formatted...)
+}
+
+// Keys devolve as chaves do catálogo de um idioma
+func Keys(locale Locale) []string {
+	keys := make([]string, 0, len(catalog[locale]))
+	for key := range catalog[locale] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Error é um erro cuja mensagem vem do catálogo. Error() usa o idioma
+// padrão (para logs); Message traduz para o idioma de quem vai ler
+type Error struct {
+	Key  string
+	Args []interface{}
+
+	// Err é o erro sentinela embrulhado, para errors.Is
+	Err error
+}
+
+func NewError(key string, args ...interface{}) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+// Wrap cria um erro traduzível que continua sendo err para errors.Is, como
+// um limite violado com os valores do limite
+func Wrap(err error, key string, args ...interface{}) *Error {
+	return &Error{Key: key, Args: args, Err: err}
+}
+
+func (e *Error) Error() string {
+	return T(Default, e.Key, e.Args...)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Message traduz err se ele (ou algum erro embrulhado nele) vier do
+// catálogo; os demais erros ficam como estão
+func Message(locale Locale, err error) string {
+	var translatable *Error
+	if errors.As(err, &translatable) {
+		return T(locale, translatable.Key, translatable.Args...)
+	}
+	return err.Error()
+}
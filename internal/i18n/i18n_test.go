@@ -0,0 +1,159 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+// Todo idioma precisa ter as mesmas chaves do padrão, com os mesmos verbos
+// na mesma ordem; senão a mensagem cai para pt-BR ou sai com %!d(MISSING)
+func TestCatalogsHaveEveryKey(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+	keys := map[string]bool{}
+	for _, locale := range Supported {
+		for _, key := range Keys(locale) {
+			keys[key] = true
+		}
+	}
+	if len(keys) == 0 {
+		t.Fatal("catálogo vazio")
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		want := verbs.FindAllString(catalog[Default][key], -1)
+		for _, locale := range Supported {
+			message, ok := catalog[locale][key]
+			if !ok {
+				t.Errorf("%s: chave %q ausente", locale, key)
+				continue
+			}
+			if message == "" {
+				t.Errorf("%s: chave %q vazia", locale, key)
+			}
+
+			got := verbs.FindAllString(message, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s: chave %q tem verbos %v, %s tem %v", locale, key, got, Default, want)
+				continue
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s: chave %q tem verbos %v, %s tem %v", locale, key, got, Default, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+// Os nomes dos idiomas são usados quando um Locale é argumento de T
+func TestCatalogsNameEveryLocale(t *testing.T) {
+	for _, locale := range Supported {
+		for _, name := range Supported {
+			if _, ok := catalog[locale]["locale."+string(name)]; !ok {
+				t.Errorf("%s: falta o nome do idioma %s", locale, name)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want Locale
+		ok   bool
+	}{
+		{"pt-BR", PtBR, true},
+		{"pt", PtBR, true},
+		{"PT_br", PtBR, true},
+		{"en-US", EN, true},
+		{"en", EN, true},
+		{"es-419", ES, true},
+		{" es ", ES, true},
+		{"fr", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Parse(tt.tag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Parse(%q) = %q, %v; quer %q, %v", tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{"", Default},
+		{"fr-FR", Default},
+		{"en-US,en;q=0.9", EN},
+		{"fr;q=1, es;q=0.8, en;q=0.5", ES},
+		{"en;q=0.2, pt-BR;q=0.9", PtBR},
+		{"en;q=abc, es", ES},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, quer %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		locale Locale
+		value  float64
+		want   string
+	}{
+		{PtBR, 0, "R$ 0,00"},
+		{PtBR, 10.5, "R$ 10,50"},
+		{PtBR, 0.1 + 0.2, "R$ 0,30"},
+		{PtBR, 999.999, "R$ 1.000,00"},
+		{PtBR, 1234.56, "R$ 1.234,56"},
+		{PtBR, 1234567.8, "R$ 1.234.567,80"},
+		{PtBR, -15, "-R$ 15,00"},
+		{PtBR, -0.001, "R$ 0,00"},
+		{EN, 1234567.8, "R$ 1,234,567.80"},
+		{EN, -15, "-R$ 15.00"},
+		{ES, 1234.56, "R$ 1.234,56"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMoney(tt.locale, tt.value); got != tt.want {
+			t.Errorf("FormatMoney(%s, %v) = %q, quer %q", tt.locale, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got, want := T(EN, "dm.payment.confirmed", Money(1500)), "✅ Your PIX of **R$ 1,500.00** was confirmed! Thanks for chipping in to the fund."; got != want {
+		t.Errorf("T com Money = %q, quer %q", got, want)
+	}
+	if got, want := T(ES, "bot.locale.saved_user", EN), "🌐 ¡Listo! Te responderé en **inglés**."; got != want {
+		t.Errorf("T com Locale = %q, quer %q", got, want)
+	}
+	if got := T(EN, "chave.inexistente"); got != "chave.inexistente" {
+		t.Errorf("chave inexistente = %q, quer a própria chave", got)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := NewError("error.invalid_amount")
+	if got, want := err.Error(), "valor inválido"; got != want {
+		t.Errorf("Error() = %q, quer %q", got, want)
+	}
+	if got, want := Message(EN, err), "invalid amount"; got != want {
+		t.Errorf("Message(en) = %q, quer %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package mercadopago
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// expire faz o cooldown do breaker aberto já ter passado
+func expire(b *circuitBreaker) {
+	b.openedAt = time.Now().Add(-b.cooldown)
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := newCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		b.failure()
+	}
+	if got := b.current(); got != BreakerClosed {
+		t.Fatalf("depois de 2 falhas: %s, quer %s", got, BreakerClosed)
+	}
+
+	// Um sucesso zera a contagem: só falhas seguidas abrem o breaker
+	b.success()
+	for i := 0; i < 2; i++ {
+		b.failure()
+	}
+	if got := b.current(); got != BreakerClosed {
+		t.Fatalf("depois de sucesso e 2 falhas: %s, quer %s", got, BreakerClosed)
+	}
+
+	b.failure()
+	if got := b.current(); got != BreakerOpen {
+		t.Fatalf("depois de 3 falhas seguidas: %s, quer %s", got, BreakerOpen)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow com breaker aberto = %v, quer ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	b := newCircuitBreaker(1, time.Minute)
+	b.failure()
+	expire(b)
+
+	if got := b.current(); got != BreakerHalfOpen {
+		t.Fatalf("depois do cooldown: %s, quer %s", got, BreakerHalfOpen)
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("primeira chamada depois do cooldown = %v, quer nil", err)
+	}
+	// Só uma chamada de teste passa por vez
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("segunda chamada durante o teste = %v, quer ErrCircuitOpen", err)
+	}
+
+	b.success()
+	if got := b.current(); got != BreakerClosed {
+		t.Fatalf("depois do teste com sucesso: %s, quer %s", got, BreakerClosed)
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow com breaker fechado = %v, quer nil", err)
+	}
+}
+
+func TestBreakerReopensWhenProbeFails(t *testing.T) {
+	b := newCircuitBreaker(3, time.Minute)
+	for i := 0; i < 3; i++ {
+		b.failure()
+	}
+	expire(b)
+
+	if err := b.allow(); err != nil {
+		t.Fatalf("chamada de teste = %v, quer nil", err)
+	}
+	// No meio-aberto uma falha basta para abrir de novo, sem esperar o threshold
+	b.failure()
+	if got := b.current(); got != BreakerOpen {
+		t.Fatalf("depois do teste com falha: %s, quer %s", got, BreakerOpen)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow logo depois de reabrir = %v, quer ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerDisabledWithZeroThreshold(t *testing.T) {
+	b := newCircuitBreaker(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		b.failure()
+	}
+	if got := b.current(); got != BreakerClosed {
+		t.Fatalf("threshold zero: %s, quer %s", got, BreakerClosed)
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow com threshold zero = %v, quer nil", err)
+	}
+}
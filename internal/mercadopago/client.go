@@ -2,38 +2,60 @@ package mercadopago
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	neturl "net/url"
 	"time"
 )
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+type Options struct {
+	// Timeout vale para cada tentativa; Deadline para a chamada inteira,
+	// somando tentativas e esperas entre elas
+	Timeout          time.Duration
+	Deadline         time.Duration
+	MaxRetries       int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 type Client struct {
 	accessToken string
 	baseURL     string
 	httpClient  *http.Client
+	deadline    time.Duration
+	retry       retryPolicy
+	breaker     *circuitBreaker
 }
 
-func NewClient(accessToken string) *Client {
+func NewClient(accessToken string, opts Options) *Client {
 	return &Client{
 		accessToken: accessToken,
 		baseURL:     "https://api.mercadopago.com",
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: opts.Timeout,
+		},
+		deadline: opts.Deadline,
+		retry: retryPolicy{
+			maxRetries: opts.MaxRetries,
+			baseDelay:  opts.RetryBaseDelay,
+			maxDelay:   opts.RetryMaxDelay,
 		},
+		breaker: newCircuitBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
 	}
 }
 
+func (c *Client) BreakerState() BreakerState {
+	return c.breaker.current()
+}
+
 type PixPaymentRequest struct {
 	TransactionAmount float64 `json:"transaction_amount"`
 	Description       string  `json:"description"`
@@ -63,7 +85,11 @@ type TransactionData struct {
 	TicketURL    string `json:"ticket_url"`
 }
 
-func (c *Client) CreatePixPayment(amount float64, description string) (*PixPaymentResponse, error) {
+func (c *Client) CreatePixPayment(ctx context.Context, amount float64, description, idempotencyKey string) (*PixPaymentResponse, error) {
+	if idempotencyKey == "" {
+		idempotencyKey = generateIdempotencyKey()
+	}
+
 	reqBody := PixPaymentRequest{
 		TransactionAmount: amount,
 		Description:       description,
@@ -78,76 +104,172 @@ func (c *Client) CreatePixPayment(amount float64, description string) (*PixPayme
 		return nil, fmt.Errorf("erro ao serializar requisição: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL+"/v1/payments", bytes.NewBuffer(jsonData))
+	// Como todas as tentativas usam a mesma X-Idempotency-Key, repetir o POST
+	// nunca gera uma segunda cobrança no Mercado Pago.
+	statusCode, body, retryAfter, err := c.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/payments", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		req.Header.Set("X-Idempotency-Key", idempotencyKey)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	req.Header.Set("X-Idempotency-Key", generateIdempotencyKey())
+	if statusCode != http.StatusCreated && statusCode != http.StatusOK {
+		return nil, newAPIError(statusCode, body, retryAfter)
+	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer requisição: %w", err)
+	var payment PixPaymentResponse
+	if err := json.Unmarshal(body, &payment); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	log.Printf("Mercado Pago: pagamento %d criado (%s)", payment.ID, payment.Status)
+
+	return &payment, nil
+}
+
+func (c *Client) GetPayment(ctx context.Context, paymentID int64) (*PixPaymentResponse, error) {
+	url := fmt.Sprintf("%s/v1/payments/%d", c.baseURL, paymentID)
+
+	statusCode, body, retryAfter, err := c.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("erro ao ler resposta: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API Mercado Pago [%d]: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return nil, newAPIError(statusCode, body, retryAfter)
 	}
 
-	fmt.Printf("Mercado Pago Response [%d]: %s\n", resp.StatusCode, string(body))
-
 	var payment PixPaymentResponse
 	if err := json.Unmarshal(body, &payment); err != nil {
 		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
 	}
 
-	fmt.Printf("Parsed Payment: ID=%d, Status=%s, QRCode=%s, QRCodeBase64=%s\n",
-		payment.ID, payment.Status,
-		payment.PointOfInteraction.TransactionData.QRCode[:min(50, len(payment.PointOfInteraction.TransactionData.QRCode))],
-		payment.PointOfInteraction.TransactionData.QRCodeBase64[:min(50, len(payment.PointOfInteraction.TransactionData.QRCodeBase64))])
-
 	return &payment, nil
 }
 
-func (c *Client) GetPayment(paymentID int64) (*PixPaymentResponse, error) {
-	url := fmt.Sprintf("%s/v1/payments/%d", c.baseURL, paymentID)
+// Chargeback é uma contestação (chargeback ou MED do PIX) aberta contra um
+// ou mais pagamentos
+type Chargeback struct {
+	ID                  string  `json:"id"`
+	Payments            []int64 `json:"payments"`
+	Amount              float64 `json:"amount"`
+	DocumentationStatus string  `json:"documentation_status"`
+}
+
+func (c *Client) GetChargeback(ctx context.Context, chargebackID string) (*Chargeback, error) {
+	url := fmt.Sprintf("%s/v1/chargebacks/%s", c.baseURL, neturl.PathEscape(chargebackID))
 
-	req, err := http.NewRequest("GET", url, nil)
+	statusCode, body, retryAfter, err := c.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, newAPIError(statusCode, body, retryAfter)
+	}
+
+	var chargeback Chargeback
+	if err := json.Unmarshal(body, &chargeback); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	return &chargeback, nil
+}
+
+// do executa a requisição passando pelo circuit breaker e repetindo falhas
+// transitórias (erros de rede, 429 e 5xx). newRequest é chamada a cada
+// tentativa para que o corpo possa ser relido. Nenhuma tentativa ou espera
+// passa do prazo de ctx nem de Deadline.
+func (c *Client) do(ctx context.Context, newRequest func(context.Context) (*http.Request, error)) (int, []byte, time.Duration, error) {
+	if c.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.deadline)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.breaker.allow(); err != nil {
+			return 0, nil, 0, err
+		}
+
+		req, err := newRequest(ctx)
+		if err != nil {
+			return 0, nil, 0, fmt.Errorf("erro ao criar requisição: %w", err)
+		}
+
+		statusCode, body, retryAfter, err := c.send(req)
+		transient := err != nil || isTransientStatus(statusCode)
+
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// Acabou o prazo da chamada, não foi o Mercado Pago que falhou
+			return statusCode, body, retryAfter, err
+		case err != nil || statusCode >= 500:
+			c.breaker.failure()
+		default:
+			c.breaker.success()
+		}
+
+		if !transient || attempt >= c.retry.maxRetries {
+			return statusCode, body, retryAfter, err
+		}
+
+		delay, ok := c.retry.delay(attempt, retryAfter)
+		if !ok {
+			return statusCode, body, retryAfter, err
+		}
 
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return statusCode, body, retryAfter, err
+		}
+
+		log.Printf("Mercado Pago: tentativa %d falhou (status=%d, erro=%v), repetindo em %s",
+			attempt+1, statusCode, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return statusCode, body, retryAfter, err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(req *http.Request) (int, []byte, time.Duration, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer requisição: %w", err)
+		return 0, nil, 0, fmt.Errorf("erro ao fazer requisição: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao ler resposta: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API Mercado Pago [%d]: %s", resp.StatusCode, string(body))
+		return 0, nil, 0, fmt.Errorf("erro ao ler resposta: %w", err)
 	}
 
-	var payment PixPaymentResponse
-	if err := json.Unmarshal(body, &payment); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
-	}
-
-	return &payment, nil
+	return resp.StatusCode, body, parseRetryAfter(resp.Header.Get("Retry-After")), nil
 }
 
 func generateIdempotencyKey() string {
@@ -0,0 +1,116 @@
+package mercadopago
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIError representa uma resposta de erro do Mercado Pago, no formato
+// {"message": "...", "error": "...", "status": 400, "cause": [...]}.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Code       string
+	Causes     []Cause
+	RetryAfter time.Duration
+	Body       string
+}
+
+type Cause struct {
+	Code        interface{} `json:"code"`
+	Description string      `json:"description"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Body
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("erro na API Mercado Pago [%d] %s: %s", e.StatusCode, e.Code, msg)
+	}
+	return fmt.Sprintf("erro na API Mercado Pago [%d]: %s", e.StatusCode, msg)
+}
+
+func newAPIError(statusCode int, body []byte, retryAfter time.Duration) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		RetryAfter: retryAfter,
+		Body:       string(body),
+	}
+
+	var payload struct {
+		Message string  `json:"message"`
+		Error   string  `json:"error"`
+		Status  int     `json:"status"`
+		Cause   []Cause `json:"cause"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		apiErr.Message = payload.Message
+		apiErr.Code = payload.Error
+		apiErr.Causes = payload.Cause
+	}
+
+	return apiErr
+}
+
+// mentions indica se a mensagem ou alguma causa do erro cita o termo
+func (e *APIError) mentions(term string) bool {
+	if strings.Contains(strings.ToLower(e.Message), term) {
+		return true
+	}
+	for _, cause := range e.Causes {
+		if strings.Contains(strings.ToLower(cause.Description), term) {
+			return true
+		}
+	}
+	return false
+}
+
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// IsUnauthorized indica token inválido, expirado ou sem permissão
+func IsUnauthorized(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+func IsRateLimited(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// IsInvalidAmount indica que o Mercado Pago recusou o valor (fora dos limites
+// da conta ou do meio de pagamento)
+func IsInvalidAmount(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.StatusCode < 400 || apiErr.StatusCode >= 500 {
+		return false
+	}
+	return apiErr.mentions("amount") || apiErr.mentions("valor")
+}
+
+// IsUnavailable cobre indisponibilidade do Mercado Pago: 5xx, falhas de rede
+// e chamadas barradas pelo circuit breaker
+func IsUnavailable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode >= 500
+}
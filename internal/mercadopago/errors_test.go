@@ -0,0 +1,85 @@
+package mercadopago
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewAPIError(t *testing.T) {
+	body := []byte(`{"message":"Invalid transaction_amount","error":"bad_request","status":400,` +
+		`"cause":[{"code":4020,"description":"transaction_amount must be positive"}]}`)
+
+	err := newAPIError(http.StatusBadRequest, body, 3*time.Second)
+	want := &APIError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Invalid transaction_amount",
+		Code:       "bad_request",
+		Causes:     []Cause{{Code: float64(4020), Description: "transaction_amount must be positive"}},
+		RetryAfter: 3 * time.Second,
+		Body:       string(body),
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Fatalf("newAPIError = %+v, quer %+v", err, want)
+	}
+	if got := err.Error(); got != "erro na API Mercado Pago [400] bad_request: Invalid transaction_amount" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestNewAPIErrorWithoutJSON(t *testing.T) {
+	err := newAPIError(http.StatusBadGateway, []byte("<html>Bad Gateway</html>"), 0)
+	if err.Message != "" || err.Code != "" || err.Causes != nil {
+		t.Fatalf("corpo que não é JSON não deveria preencher os campos: %+v", err)
+	}
+	// Sem mensagem, o erro mostra o corpo bruto
+	if got := err.Error(); got != "erro na API Mercado Pago [502]: <html>Bad Gateway</html>" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestErrorClassifiers(t *testing.T) {
+	apiErr := func(status int, body string) error {
+		return newAPIError(status, []byte(body), 0)
+	}
+
+	tests := []struct {
+		name                                   string
+		err                                    error
+		invalidAmount, unauthorized, rateLimit bool
+		unavailable                            bool
+	}{
+		{name: "valor na mensagem", err: apiErr(400, `{"message":"Invalid transaction_amount"}`), invalidAmount: true},
+		{name: "valor na causa", err: apiErr(400, `{"message":"bad request","cause":[{"description":"O valor excede o limite"}]}`), invalidAmount: true},
+		{name: "embrulhado com %w", err: fmt.Errorf("erro ao criar PIX: %w", apiErr(400, `{"message":"amount too high"}`)), invalidAmount: true},
+		{name: "4xx que não fala de valor", err: apiErr(400, `{"message":"payer.email is required"}`)},
+		{name: "5xx que fala de valor", err: apiErr(500, `{"message":"amount service down"}`), unavailable: true},
+		{name: "token inválido", err: apiErr(401, `{"message":"invalid access token"}`), unauthorized: true},
+		{name: "sem permissão", err: apiErr(403, `{"message":"forbidden"}`), unauthorized: true},
+		{name: "rate limit", err: apiErr(429, `{"message":"too many requests"}`), rateLimit: true},
+		{name: "breaker aberto", err: ErrCircuitOpen, unavailable: true},
+		{name: "falha de rede", err: fmt.Errorf("erro ao fazer requisição: %w", &url.Error{Op: "Get", URL: "https://api.mercadopago.com", Err: errors.New("timeout")}), unavailable: true},
+		{name: "erro qualquer", err: errors.New("amount")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsInvalidAmount(tt.err); got != tt.invalidAmount {
+				t.Errorf("IsInvalidAmount = %v, quer %v", got, tt.invalidAmount)
+			}
+			if got := IsUnauthorized(tt.err); got != tt.unauthorized {
+				t.Errorf("IsUnauthorized = %v, quer %v", got, tt.unauthorized)
+			}
+			if got := IsRateLimited(tt.err); got != tt.rateLimit {
+				t.Errorf("IsRateLimited = %v, quer %v", got, tt.rateLimit)
+			}
+			if got := IsUnavailable(tt.err); got != tt.unavailable {
+				t.Errorf("IsUnavailable = %v, quer %v", got, tt.unavailable)
+			}
+		})
+	}
+}
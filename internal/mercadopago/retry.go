@@ -0,0 +1,56 @@
+package mercadopago
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// delay devolve quanto esperar antes da próxima tentativa. Um Retry-After
+// enviado pelo Mercado Pago tem prioridade; se ele pedir mais que maxDelay,
+// desistimos em vez de segurar o usuário esperando.
+func (p retryPolicy) delay(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter > 0 {
+		if retryAfter > p.maxDelay {
+			return 0, false
+		}
+		return retryAfter, true
+	}
+
+	backoff := p.baseDelay << attempt
+	if backoff <= 0 || backoff > p.maxDelay {
+		backoff = p.maxDelay
+	}
+
+	// Full jitter: espalha as tentativas de vários clientes no tempo
+	return time.Duration(rand.Int63n(int64(backoff) + 1)), true
+}
+
+func isTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,240 @@
+package mercadopago
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelayJitter(t *testing.T) {
+	p := retryPolicy{maxRetries: 3, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		// O deslocamento estoura e fica negativo: vale maxDelay
+		{70, time.Second},
+	}
+
+	for _, tt := range tests {
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 200; i++ {
+			d, ok := p.delay(tt.attempt, 0)
+			if !ok {
+				t.Fatalf("delay(%d, 0) desistiu", tt.attempt)
+			}
+			if d < 0 || d > tt.ceiling {
+				t.Fatalf("delay(%d, 0) = %s, fora de [0, %s]", tt.attempt, d, tt.ceiling)
+			}
+			seen[d] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("delay(%d, 0) sem jitter: sempre %v", tt.attempt, seen)
+		}
+	}
+}
+
+func TestRetryDelayHonorsRetryAfter(t *testing.T) {
+	p := retryPolicy{maxRetries: 3, baseDelay: 100 * time.Millisecond, maxDelay: 5 * time.Second}
+
+	if d, ok := p.delay(0, 2*time.Second); !ok || d != 2*time.Second {
+		t.Errorf("delay com Retry-After de 2s = %s, %v; quer 2s, true", d, ok)
+	}
+	if d, ok := p.delay(0, 5*time.Second); !ok || d != 5*time.Second {
+		t.Errorf("delay com Retry-After igual ao teto = %s, %v; quer 5s, true", d, ok)
+	}
+	// Mais que maxDelay: desiste em vez de segurar o usuário esperando
+	if _, ok := p.delay(0, 6*time.Second); ok {
+		t.Error("delay com Retry-After acima do teto deveria desistir")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"-5", 0},
+		{"abc", 0},
+		{"120", 2 * time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, quer %s", tt.value, got, tt.want)
+		}
+	}
+
+	// Data HTTP no futuro: a espera é o tempo até ela (com folga para a
+	// precisão de segundos do formato)
+	future := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got < 80*time.Second || got > 90*time.Second {
+		t.Errorf("parseRetryAfter(%q) = %s, quer ~90s", future, got)
+	}
+}
+
+func TestIsTransientStatus(t *testing.T) {
+	for status, want := range map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusBadRequest:          false,
+		http.StatusUnauthorized:        false,
+		http.StatusNotFound:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+	} {
+		if got := isTransientStatus(status); got != want {
+			t.Errorf("isTransientStatus(%d) = %v, quer %v", status, got, want)
+		}
+	}
+}
+
+// testClient aponta um Client para um servidor de teste que responde com
+// respond e conta as chamadas
+func testClient(t *testing.T, opts Options, respond func(w http.ResponseWriter, call int32)) (*Client, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, atomic.AddInt32(&calls, 1))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient("token", opts)
+	c.baseURL = srv.URL
+	return c, &calls
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	opts := Options{MaxRetries: 3, RetryBaseDelay: time.Millisecond, RetryMaxDelay: time.Millisecond, BreakerThreshold: 5, BreakerCooldown: time.Minute}
+	c, calls := testClient(t, opts, func(w http.ResponseWriter, call int32) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"Invalid transaction_amount","error":"bad_request","status":400}`))
+	})
+
+	_, err := c.CreatePixPayment(context.Background(), 10, "teste", "chave")
+	if _, ok := AsAPIError(err); !ok {
+		t.Fatalf("CreatePixPayment = %v, quer *APIError", err)
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Errorf("POST com 400 chamou %d vezes, quer 1", n)
+	}
+	if got := c.BreakerState(); got != BreakerClosed {
+		t.Errorf("um 4xx não deveria contar para o breaker: %s", got)
+	}
+}
+
+func TestClientRetriesTransientErrors(t *testing.T) {
+	opts := Options{MaxRetries: 3, RetryBaseDelay: time.Millisecond, RetryMaxDelay: time.Millisecond, BreakerThreshold: 5, BreakerCooldown: time.Minute}
+	c, calls := testClient(t, opts, func(w http.ResponseWriter, call int32) {
+		if call < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":42,"status":"pending"}`))
+	})
+
+	payment, err := c.GetPayment(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("GetPayment = %v", err)
+	}
+	if payment.ID != 42 {
+		t.Errorf("GetPayment devolveu o pagamento %d, quer 42", payment.ID)
+	}
+	if n := atomic.LoadInt32(calls); n != 3 {
+		t.Errorf("GetPayment chamou %d vezes, quer 3", n)
+	}
+}
+
+func TestClientGivesUpWhenRetryAfterIsTooLong(t *testing.T) {
+	opts := Options{MaxRetries: 3, RetryBaseDelay: time.Millisecond, RetryMaxDelay: time.Second, BreakerThreshold: 5, BreakerCooldown: time.Minute}
+	c, calls := testClient(t, opts, func(w http.ResponseWriter, call int32) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := c.GetPayment(context.Background(), 42)
+	if !IsRateLimited(err) {
+		t.Fatalf("GetPayment = %v, quer erro de rate limit", err)
+	}
+	if apiErr, _ := AsAPIError(err); apiErr.RetryAfter != time.Minute {
+		t.Errorf("RetryAfter = %s, quer 1m", apiErr.RetryAfter)
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Errorf("GetPayment chamou %d vezes, quer 1", n)
+	}
+}
+
+func TestClientOpensBreakerOnServerErrors(t *testing.T) {
+	opts := Options{MaxRetries: 0, BreakerThreshold: 2, BreakerCooldown: time.Minute}
+	c, calls := testClient(t, opts, func(w http.ResponseWriter, call int32) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetPayment(context.Background(), 42); !IsUnavailable(err) {
+			t.Fatalf("chamada %d = %v, quer indisponível", i+1, err)
+		}
+	}
+	if got := c.BreakerState(); got != BreakerOpen {
+		t.Fatalf("depois de 2 erros 500: %s, quer %s", got, BreakerOpen)
+	}
+
+	// Com o breaker aberto a chamada nem chega ao Mercado Pago
+	if _, err := c.GetPayment(context.Background(), 42); err != ErrCircuitOpen {
+		t.Fatalf("com breaker aberto = %v, quer ErrCircuitOpen", err)
+	}
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("servidor recebeu %d chamadas, quer 2", n)
+	}
+}
+
+func TestClientStopsRetryingAtTheDeadline(t *testing.T) {
+	opts := Options{Deadline: 50 * time.Millisecond, MaxRetries: 10, RetryBaseDelay: 20 * time.Millisecond, RetryMaxDelay: 20 * time.Millisecond, BreakerThreshold: 100, BreakerCooldown: time.Minute}
+	c, calls := testClient(t, opts, func(w http.ResponseWriter, call int32) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	start := time.Now()
+	_, err := c.GetPayment(context.Background(), 42)
+	if !IsUnavailable(err) {
+		t.Fatalf("GetPayment = %v, quer indisponível", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetPayment levou %s, quer menos que o prazo mais uma espera", elapsed)
+	}
+	if n := atomic.LoadInt32(calls); n > 5 {
+		t.Errorf("GetPayment chamou %d vezes depois do prazo", n)
+	}
+}
+
+func TestClientStopsRetryingWhenTheCallerGivesUp(t *testing.T) {
+	opts := Options{MaxRetries: 3, RetryBaseDelay: time.Minute, RetryMaxDelay: time.Minute, BreakerThreshold: 100, BreakerCooldown: time.Minute}
+	c, calls := testClient(t, opts, func(w http.ResponseWriter, call int32) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.GetPayment(ctx, 42); err == nil {
+		t.Fatal("GetPayment deveria falhar")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetPayment esperou %s depois de o chamador desistir", elapsed)
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Errorf("GetPayment chamou %d vezes, quer 1", n)
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AuditSource string
+
+const (
+	SourceWebhook AuditSource = "webhook"
+	SourcePoll    AuditSource = "poll"
+	SourceAdmin   AuditSource = "admin"
+	SourceAPI     AuditSource = "api"
+)
+
+const (
+	AuditTransactionStatusChanged = "transaction.status_changed"
+	AuditBalanceAdjusted          = "balance.adjusted"
+	AuditUserMerged               = "user.merged"
+	AuditPurchaseRecorded         = "purchase.recorded"
+	AuditBalanceTransferred       = "balance.transferred"
+	AuditUserPaymentHold          = "user.payment_hold"
+	AuditUserPaymentReleased      = "user.payment_released"
+	AuditPermissionGranted        = "permission.granted"
+	AuditPermissionRevoked        = "permission.revoked"
+	AuditGuildSettingsUpdated     = "guild_settings.updated"
+)
+
+// AuditMeta identifica quem causou uma alteração e por qual caminho ela
+// chegou. Os repositories gravam o evento na mesma transação da alteração.
+type AuditMeta struct {
+	Actor       string
+	Source      AuditSource
+	PayloadHash string
+	Reason      string
+}
+
+type AuditEvent struct {
+	ID          int64
+	Action      string
+	Actor       string
+	Source      AuditSource
+	EntityType  string
+	EntityID    int64
+	Before      json.RawMessage
+	After       json.RawMessage
+	PayloadHash string
+	Reason      string
+	CreatedAt   time.Time
+}
+
+type AuditFilter struct {
+	Actor      string
+	Source     AuditSource
+	Action     string
+	EntityType string
+	EntityID   int64
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// insertAuditEvent grava o evento dentro da transação do chamador; não há
+// Update nem Delete para audit_events (a tabela rejeita ambos)
+func insertAuditEvent(tx *sql.Tx, meta AuditMeta, action, entityType string, entityID int64, before, after interface{}) error {
+	beforeJSON, err := marshalState(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalState(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO audit_events (action, actor, source, entity_type, entity_id, before_state, after_state, payload_hash, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, action, meta.Actor, meta.Source, entityType, entityID, beforeJSON, afterJSON,
+		nullString(meta.PayloadHash), nullString(meta.Reason))
+	if err != nil {
+		return fmt.Errorf("erro ao gravar auditoria: %w", err)
+	}
+
+	return nil
+}
+
+func marshalState(state interface{}) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar estado da auditoria: %w", err)
+	}
+	return data, nil
+}
+
+func (r *AuditRepository) List(filter AuditFilter) ([]AuditEvent, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.Source != "" {
+		add("source = $%d", filter.Source)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.EntityType != "" {
+		add("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		add("entity_id = $%d", filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	query := `
+		SELECT id, action, actor, source, entity_type, entity_id, before_state, after_state,
+		       COALESCE(payload_hash, ''), COALESCE(reason, ''), created_at
+		FROM audit_events`
+	if len(conditions) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(conditions, " AND ")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf("\n\t\tORDER BY id DESC\n\t\tLIMIT $%d", len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar auditoria: %w", err)
+	}
+	defer rows.Close()
+
+	var events []AuditEvent
+	for rows.Next() {
+		var e AuditEvent
+		var before, after []byte
+		if err := rows.Scan(
+			&e.ID, &e.Action, &e.Actor, &e.Source, &e.EntityType, &e.EntityID,
+			&before, &after, &e.PayloadHash, &e.Reason, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao ler auditoria: %w", err)
+		}
+		e.Before = before
+		e.After = after
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStatusChangeIsAudited(t *testing.T) {
+	db := testDB(t)
+	repo := NewTransactionRepository(db)
+	_, wallet := testWallet(t, db, "1")
+	pix := testPix(t, db, wallet.ID, 10, StatusPending)
+
+	meta := AuditMeta{Actor: "mercadopago", Source: SourceWebhook, Reason: "pago"}
+	if err := repo.UpdateStatus(pix.ID, StatusConfirmed, meta); err != nil {
+		t.Fatalf("UpdateStatus = %v", err)
+	}
+	// Repetir o status não gera outro evento
+	if err := repo.UpdateStatus(pix.ID, StatusConfirmed, meta); err != nil {
+		t.Fatalf("UpdateStatus repetido = %v", err)
+	}
+
+	events, err := NewAuditRepository(db).List(AuditFilter{EntityType: "transaction", EntityID: pix.ID, Action: AuditTransactionStatusChanged})
+	if err != nil {
+		t.Fatalf("List = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("%d eventos, quer 1", len(events))
+	}
+
+	event := events[0]
+	if event.Actor != meta.Actor || event.Source != meta.Source || event.Reason != meta.Reason {
+		t.Errorf("evento = %s/%s/%q, quer %s/%s/%q", event.Actor, event.Source, event.Reason, meta.Actor, meta.Source, meta.Reason)
+	}
+
+	var before, after struct {
+		Status TransactionStatus `json:"status"`
+	}
+	if err := json.Unmarshal(event.Before, &before); err != nil {
+		t.Fatalf("before inválido: %v", err)
+	}
+	if err := json.Unmarshal(event.After, &after); err != nil {
+		t.Fatalf("after inválido: %v", err)
+	}
+	if before.Status != StatusPending || after.Status != StatusConfirmed {
+		t.Errorf("before/after = %s/%s, quer %s/%s", before.Status, after.Status, StatusPending, StatusConfirmed)
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// testDB abre TEST_DATABASE_URL num schema novo com todas as migrations
+// aplicadas; sem a variável os testes de banco são pulados
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL não configurada")
+	}
+
+	admin, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de teste: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("erro ao criar schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		t.Fatalf("erro ao parsear TEST_DATABASE_URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de teste: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil {
+		t.Fatalf("erro ao listar migrations: %v", err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("erro ao ler %s: %v", file, err)
+		}
+		if _, err := db.Exec(string(content)); err != nil {
+			t.Fatalf("erro ao aplicar %s: %v", file, err)
+		}
+	}
+	return db
+}
+
+// testWallet cria um usuário com carteira
+func testWallet(t *testing.T, db *sql.DB, discordID string) (*User, *Wallet) {
+	t.Helper()
+	user, err := NewUserRepository(db).Create(discordID, "user-"+discordID)
+	if err != nil {
+		t.Fatalf("Create(%s) = %v", discordID, err)
+	}
+	wallet, err := NewWalletRepository(db).Create(user.ID)
+	if err != nil {
+		t.Fatalf("Create(wallet %s) = %v", discordID, err)
+	}
+	return user, wallet
+}
+
+// testPix cria um PIX PENDING e, se status não for PENDING, leva-o até lá
+func testPix(t *testing.T, db *sql.DB, walletID int64, amount float64, status TransactionStatus) *Transaction {
+	t.Helper()
+	repo := NewTransactionRepository(db)
+	ref := fmt.Sprintf("ref-%d-%d", walletID, time.Now().UnixNano())
+	transaction, err := repo.Create(walletID, amount, ref, "", nil)
+	if err != nil {
+		t.Fatalf("Create(PIX) = %v", err)
+	}
+	if status != StatusPending {
+		if err := repo.UpdateStatus(transaction.ID, status, AuditMeta{Actor: "test", Source: SourceAdmin}); err != nil {
+			t.Fatalf("UpdateStatus(%s) = %v", status, err)
+		}
+		transaction.Status = status
+	}
+	return transaction
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GuildSettings são as configurações do bot em um servidor do Discord
+type GuildSettings struct {
+	ID                    int64
+	GuildID               string
+	CommandPrefix         string
+	AllowedChannelIDs     []string
+	AnnouncementChannelID string
+	UpdatedBy             string
+	UpdatedAt             time.Time
+}
+
+func (g *GuildSettings) auditState() map[string]interface{} {
+	return map[string]interface{}{
+		"guild_id":                g.GuildID,
+		"command_prefix":          g.CommandPrefix,
+		"allowed_channel_ids":     g.AllowedChannelIDs,
+		"announcement_channel_id": g.AnnouncementChannelID,
+	}
+}
+
+type GuildSettingsRepository struct {
+	db *sql.DB
+}
+
+func NewGuildSettingsRepository(db *sql.DB) *GuildSettingsRepository {
+	return &GuildSettingsRepository{db: db}
+}
+
+const guildSettingsColumns = `id, guild_id, command_prefix, allowed_channel_ids, announcement_channel_id, updated_by, updated_at`
+
+func scanGuildSettings(row interface{ Scan(...interface{}) error }) (*GuildSettings, error) {
+	var g GuildSettings
+	if err := row.Scan(&g.ID, &g.GuildID, &g.CommandPrefix, pq.Array(&g.AllowedChannelIDs),
+		&g.AnnouncementChannelID, &g.UpdatedBy, &g.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Find devolve nil se o servidor nunca foi configurado
+func (r *GuildSettingsRepository) Find(guildID string) (*GuildSettings, error) {
+	g, err := scanGuildSettings(r.db.QueryRow(`
+		SELECT `+guildSettingsColumns+` FROM guild_settings WHERE guild_id = $1
+	`, guildID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar configurações do servidor: %w", err)
+	}
+	return g, nil
+}
+
+// ListAnnouncing devolve os servidores com canal de anúncios configurado
+func (r *GuildSettingsRepository) ListAnnouncing() ([]GuildSettings, error) {
+	rows, err := r.db.Query(`
+		SELECT ` + guildSettingsColumns + ` FROM guild_settings
+		WHERE announcement_channel_id <> ''
+		ORDER BY guild_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar canais de anúncios: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []GuildSettings
+	for rows.Next() {
+		g, err := scanGuildSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler configurações do servidor: %w", err)
+		}
+		settings = append(settings, *g)
+	}
+	return settings, rows.Err()
+}
+
+// Save grava as configurações do servidor, com o evento de auditoria na
+// mesma transação
+func (r *GuildSettingsRepository) Save(settings GuildSettings, meta AuditMeta) (*GuildSettings, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := scanGuildSettings(tx.QueryRow(`
+		SELECT `+guildSettingsColumns+` FROM guild_settings WHERE guild_id = $1 FOR UPDATE
+	`, settings.GuildID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("erro ao buscar configurações do servidor: %w", err)
+	}
+
+	if settings.AllowedChannelIDs == nil {
+		settings.AllowedChannelIDs = []string{}
+	}
+	saved, err := scanGuildSettings(tx.QueryRow(`
+		INSERT INTO guild_settings (guild_id, command_prefix, allowed_channel_ids, announcement_channel_id, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (guild_id) DO UPDATE SET
+			command_prefix = EXCLUDED.command_prefix,
+			allowed_channel_ids = EXCLUDED.allowed_channel_ids,
+			announcement_channel_id = EXCLUDED.announcement_channel_id,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING `+guildSettingsColumns,
+		settings.GuildID, settings.CommandPrefix, pq.Array(settings.AllowedChannelIDs),
+		settings.AnnouncementChannelID, meta.Actor))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar configurações do servidor: %w", err)
+	}
+
+	var beforeState interface{}
+	if before != nil {
+		beforeState = before.auditState()
+	}
+	if err := insertAuditEvent(tx, meta, AuditGuildSettingsUpdated, "guild_settings", saved.ID, beforeState, saved.auditState()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar configurações do servidor: %w", err)
+	}
+	return saved, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type LocaleScope string
+
+const (
+	LocaleScopeUser  LocaleScope = "user"
+	LocaleScopeGuild LocaleScope = "guild"
+)
+
+type LocaleRepository struct {
+	db *sql.DB
+}
+
+func NewLocaleRepository(db *sql.DB) *LocaleRepository {
+	return &LocaleRepository{db: db}
+}
+
+// Find devolve os idiomas configurados para o usuário e para o servidor;
+// vazio quando não há configuração. guildID vazio ignora o servidor
+func (r *LocaleRepository) Find(discordID, guildID string) (user, guild string, err error) {
+	rows, err := r.db.Query(`
+		SELECT scope, locale FROM locale_settings
+		WHERE (scope = $1 AND scope_id = $2) OR (scope = $3 AND scope_id = $4)
+	`, LocaleScopeUser, discordID, LocaleScopeGuild, guildID)
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao buscar idioma: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var scope LocaleScope
+		var locale string
+		if err := rows.Scan(&scope, &locale); err != nil {
+			return "", "", fmt.Errorf("erro ao ler idioma: %w", err)
+		}
+		if scope == LocaleScopeUser {
+			user = locale
+		} else {
+			guild = locale
+		}
+	}
+	return user, guild, rows.Err()
+}
+
+// Set grava o idioma do usuário ou do servidor; locale vazio apaga a
+// configuração
+func (r *LocaleRepository) Set(scope LocaleScope, scopeID, locale string) error {
+	var err error
+	if locale == "" {
+		_, err = r.db.Exec(`DELETE FROM locale_settings WHERE scope = $1 AND scope_id = $2`, scope, scopeID)
+	} else {
+		_, err = r.db.Exec(`
+			INSERT INTO locale_settings (scope, scope_id, locale)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (scope, scope_id) DO UPDATE SET locale = EXCLUDED.locale, updated_at = CURRENT_TIMESTAMP
+		`, scope, scopeID, locale)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao salvar idioma: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "PENDING"
+	OutboxDone    OutboxStatus = "DONE"
+	OutboxDead    OutboxStatus = "DEAD"
+)
+
+const TopicTransactionStatusChanged = "transaction.status_changed"
+
+type OutboxEvent struct {
+	ID          int64
+	Topic       string
+	EntityType  string
+	EntityID    int64
+	Payload     json.RawMessage
+	Status      OutboxStatus
+	Attempts    int
+	LastError   string
+	Handled     []string // handlers que já trataram o evento
+	AvailableAt time.Time
+	CreatedAt   time.Time
+	ProcessedAt *time.Time
+}
+
+// TransactionStatusChanged é o payload de TopicTransactionStatusChanged
+type TransactionStatusChanged struct {
+	TransactionID int64             `json:"transaction_id"`
+	WalletID      int64             `json:"wallet_id"`
+	Type          TransactionType   `json:"type"`
+	Amount        float64           `json:"amount"`
+	From          TransactionStatus `json:"from"`
+	To            TransactionStatus `json:"to"`
+}
+
+// OutboxFilter seleciona eventos para a visão administrativa. Stuck traz os
+// mortos e os pendentes que já falharam pelo menos uma vez
+type OutboxFilter struct {
+	Status OutboxStatus
+	Stuck  bool
+	Limit  int
+}
+
+const outboxColumns = `id, topic, entity_type, entity_id, payload, status, attempts, COALESCE(last_error, ''), handled, available_at, created_at, processed_at`
+
+type OutboxRepository struct {
+	db    *sql.DB
+	queue leaseQueue[OutboxEvent]
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+		queue: leaseQueue[OutboxEvent]{
+			db:      db,
+			table:   "outbox",
+			columns: outboxColumns,
+			orderBy: "created_at",
+			one:     "evento do outbox",
+			many:    "eventos do outbox",
+			scan:    scanOutboxEvent,
+		},
+	}
+}
+
+// insertOutboxEvent grava o evento dentro da transação do chamador, então ele
+// só existe se a alteração que o causou for confirmada
+func insertOutboxEvent(tx *sql.Tx, topic, entityType string, entityID int64, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evento do outbox: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO outbox (topic, entity_type, entity_id, payload)
+		VALUES ($1, $2, $3, $4)
+	`, topic, entityType, entityID, data)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar evento do outbox: %w", err)
+	}
+
+	return nil
+}
+
+func scanOutboxEvent(row interface{ Scan(...interface{}) error }) (*OutboxEvent, error) {
+	e := &OutboxEvent{}
+	var payload []byte
+	err := row.Scan(&e.ID, &e.Topic, &e.EntityType, &e.EntityID, &payload, &e.Status,
+		&e.Attempts, &e.LastError, pq.Array(&e.Handled), &e.AvailableAt, &e.CreatedAt, &e.ProcessedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Payload = payload
+	return e, nil
+}
+
+// Claim reserva até limit eventos prontos para entrega e conta a tentativa.
+// Cada evento fica invisível por lease; se o processo morrer antes de
+// MarkDone ou MarkFailed, ele volta a ser entregue depois disso.
+func (r *OutboxRepository) Claim(limit int, lease time.Duration) ([]OutboxEvent, error) {
+	return r.queue.claim(limit, lease)
+}
+
+func (r *OutboxRepository) MarkDone(id int64) error {
+	return r.queue.markDone(id)
+}
+
+// MarkHandled registra que o handler tratou o evento, para que uma nova
+// tentativa não o rode de novo
+func (r *OutboxRepository) MarkHandled(id int64, handler string) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox
+		SET handled = array_append(handled, $2::text)
+		WHERE id = $1 AND NOT ($2::text = ANY(handled))
+	`, id, handler)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar handler do evento do outbox: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed agenda nova tentativa depois de retryIn ou, com dead, desiste
+// do evento até um administrador reenfileirá-lo
+func (r *OutboxRepository) MarkFailed(id int64, reason string, retryIn time.Duration, dead bool) error {
+	return r.queue.markFailed(id, reason, retryIn, dead)
+}
+
+// Requeue devolve um evento morto (ou pendente) para a fila, com as
+// tentativas zeradas. Os handlers que já trataram o evento não rodam de
+// novo. Devolve false se o evento não existe ou já foi entregue
+func (r *OutboxRepository) Requeue(id int64) (bool, error) {
+	return r.queue.requeue(id, false)
+}
+
+func (r *OutboxRepository) FindByID(id int64) (*OutboxEvent, error) {
+	return r.queue.findByID(id)
+}
+
+func (r *OutboxRepository) List(filter OutboxFilter) ([]OutboxEvent, error) {
+	return r.queue.list(string(filter.Status), filter.Stuck, filter.Limit)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStatusChangeEnqueuesOutboxEvent(t *testing.T) {
+	db := testDB(t)
+	outbox := NewOutboxRepository(db)
+	_, wallet := testWallet(t, db, "1")
+	pix := testPix(t, db, wallet.ID, 10, StatusConfirmed)
+
+	events, err := outbox.Claim(10, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim = %v", err)
+	}
+	if len(events) != 1 || events[0].Topic != TopicTransactionStatusChanged || events[0].EntityID != pix.ID {
+		t.Fatalf("Claim = %+v, quer o evento da confirmação do PIX %d", events, pix.ID)
+	}
+
+	var payload TransactionStatusChanged
+	if err := json.Unmarshal(events[0].Payload, &payload); err != nil {
+		t.Fatalf("payload inválido: %v", err)
+	}
+	if payload.From != StatusPending || payload.To != StatusConfirmed || payload.Amount != 10 {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	// Registrar o mesmo handler duas vezes não o repete em Handled
+	for i := 0; i < 2; i++ {
+		if err := outbox.MarkHandled(events[0].ID, "dm"); err != nil {
+			t.Fatalf("MarkHandled = %v", err)
+		}
+	}
+	event, err := outbox.FindByID(events[0].ID)
+	if err != nil {
+		t.Fatalf("FindByID = %v", err)
+	}
+	if !slices.Equal(event.Handled, []string{"dm"}) {
+		t.Errorf("Handled = %v, quer [dm]", event.Handled)
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RolePermission concede uma capacidade administrativa a um cargo do
+// Discord em um servidor
+type RolePermission struct {
+	ID         int64
+	GuildID    string
+	RoleID     string
+	Capability string
+	GrantedBy  string
+	CreatedAt  time.Time
+}
+
+func (p *RolePermission) auditState() map[string]interface{} {
+	return map[string]interface{}{
+		"guild_id":   p.GuildID,
+		"role_id":    p.RoleID,
+		"capability": p.Capability,
+	}
+}
+
+type PermissionRepository struct {
+	db *sql.DB
+}
+
+func NewPermissionRepository(db *sql.DB) *PermissionRepository {
+	return &PermissionRepository{db: db}
+}
+
+const permissionColumns = `id, guild_id, role_id, capability, granted_by, created_at`
+
+func scanPermission(row interface{ Scan(...interface{}) error }) (*RolePermission, error) {
+	var p RolePermission
+	if err := row.Scan(&p.ID, &p.GuildID, &p.RoleID, &p.Capability, &p.GrantedBy, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List devolve as permissões do servidor, agrupadas por cargo
+func (r *PermissionRepository) List(guildID string) ([]RolePermission, error) {
+	rows, err := r.db.Query(`
+		SELECT `+permissionColumns+`
+		FROM role_permissions
+		WHERE guild_id = $1
+		ORDER BY role_id, capability
+	`, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar permissões: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []RolePermission{}
+	for rows.Next() {
+		p, err := scanPermission(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler permissão: %w", err)
+		}
+		permissions = append(permissions, *p)
+	}
+	return permissions, rows.Err()
+}
+
+// HasAny indica se algum dos cargos tem a capacidade no servidor
+func (r *PermissionRepository) HasAny(guildID string, roleIDs []string, capability string) (bool, error) {
+	if len(roleIDs) == 0 {
+		return false, nil
+	}
+
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM role_permissions
+			WHERE guild_id = $1 AND capability = $2 AND role_id = ANY($3)
+		)
+	`, guildID, capability, pq.Array(roleIDs)).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar permissão: %w", err)
+	}
+	return exists, nil
+}
+
+// Grant concede a capacidade ao cargo, com o evento de auditoria na mesma
+// transação. Devolve nil se o cargo já tinha a capacidade
+func (r *PermissionRepository) Grant(guildID, roleID, capability string, meta AuditMeta) (*RolePermission, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	p, err := scanPermission(tx.QueryRow(`
+		INSERT INTO role_permissions (guild_id, role_id, capability, granted_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (guild_id, role_id, capability) DO NOTHING
+		RETURNING `+permissionColumns,
+		guildID, roleID, capability, meta.Actor))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conceder permissão: %w", err)
+	}
+
+	if err := insertAuditEvent(tx, meta, AuditPermissionGranted, "role_permission", p.ID, nil, p.auditState()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar permissão: %w", err)
+	}
+	return p, nil
+}
+
+// Revoke retira a capacidade do cargo. Devolve false se ele não a tinha
+func (r *PermissionRepository) Revoke(guildID, roleID, capability string, meta AuditMeta) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	p, err := scanPermission(tx.QueryRow(`
+		DELETE FROM role_permissions
+		WHERE guild_id = $1 AND role_id = $2 AND capability = $3
+		RETURNING `+permissionColumns,
+		guildID, roleID, capability))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("erro ao retirar permissão: %w", err)
+	}
+
+	if err := insertAuditEvent(tx, meta, AuditPermissionRevoked, "role_permission", p.ID, p.auditState(), nil); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar remoção da permissão: %w", err)
+	}
+	return true, nil
+}
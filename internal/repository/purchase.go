@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type SplitMode string
+
+const (
+	SplitProRata SplitMode = "PRO_RATA"
+	SplitEqual   SplitMode = "EQUAL"
+)
+
+type Purchase struct {
+	ID        int64
+	Title     string
+	Amount    float64
+	SplitMode SplitMode
+	CreatedBy string
+	CreatedAt time.Time
+	Shares    []PurchaseShare
+}
+
+type PurchaseShare struct {
+	WalletID        int64   `json:"wallet_id"`
+	DiscordID       string  `json:"discord_id"`
+	Username        string  `json:"username"`
+	Amount          float64 `json:"amount"`
+	AvailableBefore float64 `json:"available_before"`
+}
+
+// AvailableBalance separa o que a carteira já contribuiu (transações
+// confirmadas) do que já foi gasto em compras
+type AvailableBalance struct {
+	WalletID    int64
+	DiscordID   string
+	Username    string
+	Contributed float64
+	Spent       float64
+}
+
+func (b AvailableBalance) Available() float64 {
+	return b.Contributed - b.Spent
+}
+
+// Covers diz se o saldo disponível paga amount, tolerando meio centavo de
+// arredondamento das somas em float
+func (b AvailableBalance) Covers(amount float64) bool {
+	return b.Available()+0.005 >= amount
+}
+
+// Allocator divide o valor da compra a partir dos saldos disponíveis no
+// momento da compra
+type Allocator func(amount float64, balances []AvailableBalance) ([]PurchaseShare, error)
+
+type PurchaseRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseRepository(db *sql.DB) *PurchaseRepository {
+	return &PurchaseRepository{db: db}
+}
+
+const availableBalanceQuery = `
+	SELECT w.id, u.discord_id, u.username,
+	       COALESCE((SELECT SUM(t.amount) FROM transactions t
+	                 WHERE t.wallet_id = w.id AND t.status = 'CONFIRMED'), 0) as contributed,
+	       COALESCE((SELECT SUM(ps.amount) FROM purchase_shares ps
+	                 WHERE ps.wallet_id = w.id), 0) as spent
+	FROM wallets w
+	INNER JOIN users u ON u.id = w.user_id`
+
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func listAvailableBalances(q querier) ([]AvailableBalance, error) {
+	rows, err := q.Query(availableBalanceQuery + `
+	ORDER BY w.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar saldos disponíveis: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []AvailableBalance
+	for rows.Next() {
+		var b AvailableBalance
+		if err := rows.Scan(&b.WalletID, &b.DiscordID, &b.Username, &b.Contributed, &b.Spent); err != nil {
+			return nil, fmt.Errorf("erro ao ler saldo disponível: %w", err)
+		}
+		balances = append(balances, b)
+	}
+
+	return balances, rows.Err()
+}
+
+func (r *PurchaseRepository) ListAvailableBalances() ([]AvailableBalance, error) {
+	return listAvailableBalances(r.db)
+}
+
+func (r *PurchaseRepository) GetAvailableBalance(walletID int64) (*AvailableBalance, error) {
+	b := &AvailableBalance{}
+	err := r.db.QueryRow(availableBalanceQuery+`
+	WHERE w.id = $1
+	`, walletID).Scan(&b.WalletID, &b.DiscordID, &b.Username, &b.Contributed, &b.Spent)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar saldo disponível: %w", err)
+	}
+
+	return b, nil
+}
+
+// Create registra a compra e a parte de cada carteira. A tabela de partes
+// fica travada durante a transação para que duas compras simultâneas não
+// gastem o mesmo saldo disponível.
+func (r *PurchaseRepository) Create(title string, amount float64, mode SplitMode, allocate Allocator, meta AuditMeta) (*Purchase, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`LOCK TABLE purchase_shares IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("erro ao travar partes das compras: %w", err)
+	}
+
+	balances, err := listAvailableBalances(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := allocate(amount, balances)
+	if err != nil {
+		return nil, err
+	}
+
+	purchase := &Purchase{}
+	err = tx.QueryRow(`
+		INSERT INTO purchases (title, amount, split_mode, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, title, amount, split_mode, created_by, created_at
+	`, title, amount, mode, meta.Actor).Scan(
+		&purchase.ID, &purchase.Title, &purchase.Amount, &purchase.SplitMode, &purchase.CreatedBy, &purchase.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar compra: %w", err)
+	}
+
+	for _, share := range shares {
+		_, err := tx.Exec(`
+			INSERT INTO purchase_shares (purchase_id, wallet_id, amount, available_before)
+			VALUES ($1, $2, $3, $4)
+		`, purchase.ID, share.WalletID, share.Amount, share.AvailableBefore)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao registrar parte da compra: %w", err)
+		}
+	}
+	purchase.Shares = shares
+
+	after := map[string]interface{}{
+		"title":      purchase.Title,
+		"amount":     purchase.Amount,
+		"split_mode": purchase.SplitMode,
+		"shares":     shares,
+	}
+	if err := insertAuditEvent(tx, meta, AuditPurchaseRecorded, "purchase", purchase.ID, nil, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar compra: %w", err)
+	}
+
+	return purchase, nil
+}
+
+func (r *PurchaseRepository) FindByID(id int64) (*Purchase, error) {
+	purchase := &Purchase{}
+	err := r.db.QueryRow(`
+		SELECT id, title, amount, split_mode, created_by, created_at
+		FROM purchases
+		WHERE id = $1
+	`, id).Scan(&purchase.ID, &purchase.Title, &purchase.Amount, &purchase.SplitMode, &purchase.CreatedBy, &purchase.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar compra: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT ps.wallet_id, u.discord_id, u.username, ps.amount, ps.available_before
+		FROM purchase_shares ps
+		INNER JOIN wallets w ON w.id = ps.wallet_id
+		INNER JOIN users u ON u.id = w.user_id
+		WHERE ps.purchase_id = $1
+		ORDER BY ps.amount DESC, u.username
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar partes da compra: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var share PurchaseShare
+		if err := rows.Scan(&share.WalletID, &share.DiscordID, &share.Username, &share.Amount, &share.AvailableBefore); err != nil {
+			return nil, fmt.Errorf("erro ao ler parte da compra: %w", err)
+		}
+		purchase.Shares = append(purchase.Shares, share)
+	}
+
+	return purchase, rows.Err()
+}
+
+func (r *PurchaseRepository) List(limit int) ([]Purchase, error) {
+	rows, err := r.db.Query(`
+		SELECT id, title, amount, split_mode, created_by, created_at
+		FROM purchases
+		ORDER BY id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar compras: %w", err)
+	}
+	defer rows.Close()
+
+	var purchases []Purchase
+	for rows.Next() {
+		var p Purchase
+		if err := rows.Scan(&p.ID, &p.Title, &p.Amount, &p.SplitMode, &p.CreatedBy, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler compra: %w", err)
+		}
+		purchases = append(purchases, p)
+	}
+
+	return purchases, rows.Err()
+}
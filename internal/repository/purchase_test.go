@@ -0,0 +1,26 @@
+package repository
+
+import "testing"
+
+func TestAvailableBalanceCovers(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance AvailableBalance
+		amount  float64
+		want    bool
+	}{
+		{"sobra saldo", AvailableBalance{Contributed: 50, Spent: 20}, 10, true},
+		{"saldo exato", AvailableBalance{Contributed: 50, Spent: 20}, 30, true},
+		{"arredondamento das somas", AvailableBalance{Contributed: 0.1 + 0.2, Spent: 0}, 0.3, true},
+		{"um centavo a mais", AvailableBalance{Contributed: 50, Spent: 20}, 30.01, false},
+		{"carteira zerada", AvailableBalance{}, 0.01, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.balance.Covers(tt.amount); got != tt.want {
+				t.Errorf("Covers(%v) = %v, quer %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// leaseQueue é a fila com lease do outbox e do inbox de webhooks: as duas
+// tabelas têm status PENDING/DONE/DEAD, attempts, last_error, processed_at e
+// um available_at que marca a próxima tentativa ou o fim do lease
+type leaseQueue[T any] struct {
+	db      *sql.DB
+	table   string
+	columns string
+	orderBy string // coluna de data da listagem, mais recentes primeiro
+	one     string // nome da linha nas mensagens de erro
+	many    string
+	scan    func(row interface{ Scan(...interface{}) error }) (*T, error)
+}
+
+// claim reserva até limit linhas prontas e conta a tentativa. Cada linha
+// fica invisível por lease; se o processo morrer antes de markDone ou
+// markFailed, ela volta para a fila depois disso.
+func (q leaseQueue[T]) claim(limit int, lease time.Duration) ([]T, error) {
+	rows, err := q.db.Query(`
+		UPDATE `+q.table+`
+		SET attempts = attempts + 1,
+		    available_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM `+q.table+`
+			WHERE status = 'PENDING' AND available_at <= CURRENT_TIMESTAMP
+			ORDER BY available_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+q.columns, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao reservar %s: %w", q.many, err)
+	}
+	return q.collect(rows)
+}
+
+func (q leaseQueue[T]) markDone(id int64) error {
+	_, err := q.db.Exec(`
+		UPDATE `+q.table+`
+		SET status = 'DONE', processed_at = CURRENT_TIMESTAMP, last_error = NULL
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao concluir %s: %w", q.one, err)
+	}
+	return nil
+}
+
+// markFailed agenda nova tentativa depois de retryIn ou, com dead, tira a
+// linha da fila até um administrador devolvê-la
+func (q leaseQueue[T]) markFailed(id int64, reason string, retryIn time.Duration, dead bool) error {
+	status := "PENDING"
+	if dead {
+		status = "DEAD"
+	}
+
+	_, err := q.db.Exec(`
+		UPDATE `+q.table+`
+		SET status = $2, last_error = $3, available_at = CURRENT_TIMESTAMP + make_interval(secs => $4)
+		WHERE id = $1
+	`, id, status, reason, retryIn.Seconds())
+	if err != nil {
+		return fmt.Errorf("erro ao registrar falha do %s: %w", q.one, err)
+	}
+	return nil
+}
+
+// requeue devolve a linha para a fila com as tentativas zeradas; com
+// includeDone, também uma já concluída. Devolve false se nenhuma mudou
+func (q leaseQueue[T]) requeue(id int64, includeDone bool) (bool, error) {
+	query := `
+		UPDATE ` + q.table + `
+		SET status = 'PENDING', attempts = 0, available_at = CURRENT_TIMESTAMP, processed_at = NULL
+		WHERE id = $1`
+	if !includeDone {
+		query += ` AND status IN ('PENDING', 'DEAD')`
+	}
+
+	result, err := q.db.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao reenfileirar %s: %w", q.one, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao reenfileirar %s: %w", q.one, err)
+	}
+	return n > 0, nil
+}
+
+// pruneDone apaga as linhas concluídas há mais de olderThan
+func (q leaseQueue[T]) pruneDone(olderThan time.Duration) (int64, error) {
+	result, err := q.db.Exec(`
+		DELETE FROM `+q.table+`
+		WHERE status = 'DONE' AND processed_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+	`, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("erro ao limpar %s: %w", q.many, err)
+	}
+	return result.RowsAffected()
+}
+
+func (q leaseQueue[T]) findByID(id int64) (*T, error) {
+	item, err := q.scan(q.db.QueryRow(`SELECT `+q.columns+` FROM `+q.table+` WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar %s: %w", q.one, err)
+	}
+	return item, nil
+}
+
+// list serve a visão administrativa. stuck traz as linhas mortas e as
+// pendentes que já falharam pelo menos uma vez
+func (q leaseQueue[T]) list(status string, stuck bool, limit int) ([]T, error) {
+	query := `SELECT ` + q.columns + ` FROM ` + q.table
+	var args []interface{}
+
+	switch {
+	case stuck:
+		query += ` WHERE status = 'DEAD' OR (status = 'PENDING' AND attempts > 0)`
+	case status != "":
+		query += ` WHERE status = $1`
+		args = append(args, status)
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	query += fmt.Sprintf(` ORDER BY %s DESC, id DESC LIMIT %d`, q.orderBy, limit)
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar %s: %w", q.many, err)
+	}
+	return q.collect(rows)
+}
+
+func (q leaseQueue[T]) collect(rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+
+	var items []T
+	for rows.Next() {
+		item, err := q.scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", q.one, err)
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLeaseQueue(t *testing.T) {
+	db := testDB(t)
+	repo := NewWebhookInboxRepository(db)
+
+	entry, err := repo.Insert("mercadopago", []byte(`{}`), http.Header{}, "hash")
+	if err != nil {
+		t.Fatalf("Insert = %v", err)
+	}
+
+	claimed, err := repo.Claim(10, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != entry.ID || claimed[0].Attempts != 1 {
+		t.Fatalf("Claim = %+v, quer a entrada %d com 1 tentativa", claimed, entry.ID)
+	}
+
+	// Durante o lease a entrada não volta a ser reservada
+	if again, err := repo.Claim(10, time.Minute); err != nil || len(again) != 0 {
+		t.Fatalf("Claim durante o lease = %d entradas, %v", len(again), err)
+	}
+
+	if err := repo.MarkFailed(entry.ID, "falhou", 0, true); err != nil {
+		t.Fatalf("MarkFailed = %v", err)
+	}
+	stuck, err := repo.List(InboxFilter{Stuck: true})
+	if err != nil {
+		t.Fatalf("List = %v", err)
+	}
+	if len(stuck) != 1 || stuck[0].Status != InboxDead || stuck[0].LastError != "falhou" {
+		t.Fatalf("List(stuck) = %+v, quer a entrada morta", stuck)
+	}
+
+	if ok, err := repo.Requeue(entry.ID); err != nil || !ok {
+		t.Fatalf("Requeue = %v, %v", ok, err)
+	}
+	claimed, err = repo.Claim(10, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].Attempts != 1 {
+		t.Fatalf("Claim depois do Requeue = %+v, %v", claimed, err)
+	}
+
+	if err := repo.MarkDone(entry.ID); err != nil {
+		t.Fatalf("MarkDone = %v", err)
+	}
+	done, err := repo.FindByID(entry.ID)
+	if err != nil || done.Status != InboxDone || done.ProcessedAt == nil {
+		t.Fatalf("FindByID = %+v, %v, quer DONE", done, err)
+	}
+
+	// Só as concluídas há mais tempo que a retenção são apagadas
+	if n, err := repo.PruneDone(time.Hour); err != nil || n != 0 {
+		t.Fatalf("PruneDone(1h) = %d, %v, quer 0", n, err)
+	}
+	if n, err := repo.PruneDone(-time.Hour); err != nil || n != 1 {
+		t.Fatalf("PruneDone(-1h) = %d, %v, quer 1", n, err)
+	}
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type Subscription struct {
+	ID        int64
+	UserID    int64
+	DiscordID string
+	Username  string
+	Amount    float64
+	DueDay    int
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type SubscriptionCharge struct {
+	ID             int64
+	SubscriptionID int64
+	Period         string
+	TransactionID  int64
+	RemindersSent  int
+	LastNotifiedAt time.Time
+	CreatedAt      time.Time
+}
+
+// SubscriptionStatus é a mensalidade junto com o quanto o membro já
+// contribuiu (PIX confirmados) no período consultado
+type SubscriptionStatus struct {
+	Subscription
+	Paid float64
+}
+
+type SubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+func (r *SubscriptionRepository) Upsert(userID int64, amount float64, dueDay int) (*Subscription, error) {
+	sub := &Subscription{}
+	err := r.db.QueryRow(`
+		INSERT INTO subscriptions (user_id, amount, due_day)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET amount = EXCLUDED.amount, due_day = EXCLUDED.due_day, active = TRUE, updated_at = CURRENT_TIMESTAMP
+		RETURNING id, user_id, amount, due_day, active, created_at, updated_at
+	`, userID, amount, dueDay).Scan(
+		&sub.ID, &sub.UserID, &sub.Amount, &sub.DueDay, &sub.Active, &sub.CreatedAt, &sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar mensalidade: %w", err)
+	}
+
+	return sub, nil
+}
+
+// Deactivate desliga a mensalidade do usuário; devolve false se não havia
+// mensalidade ativa
+func (r *SubscriptionRepository) Deactivate(userID int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE subscriptions
+		SET active = FALSE, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND active
+	`, userID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao cancelar mensalidade: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// ListStatus devolve as mensalidades ativas com o total de PIX confirmados
+// de cada membro entre from e to
+func (r *SubscriptionRepository) ListStatus(from, to time.Time) ([]SubscriptionStatus, error) {
+	rows, err := r.db.Query(`
+		SELECT s.id, s.user_id, u.discord_id, u.username, s.amount, s.due_day, s.active,
+		       s.created_at, s.updated_at, COALESCE(SUM(t.amount), 0) as paid
+		FROM subscriptions s
+		INNER JOIN users u ON u.id = s.user_id
+		LEFT JOIN wallets w ON w.user_id = u.id
+		LEFT JOIN transactions t ON t.wallet_id = w.id
+			AND t.status = 'CONFIRMED'
+			AND t.type = 'PIX'
+			AND t.confirmed_at >= $1
+			AND t.confirmed_at < $2
+		WHERE s.active
+		GROUP BY s.id, u.id
+		ORDER BY u.username
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensalidades: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []SubscriptionStatus
+	for rows.Next() {
+		var st SubscriptionStatus
+		if err := rows.Scan(
+			&st.ID, &st.UserID, &st.DiscordID, &st.Username, &st.Amount, &st.DueDay, &st.Active,
+			&st.CreatedAt, &st.UpdatedAt, &st.Paid,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao ler mensalidade: %w", err)
+		}
+		statuses = append(statuses, st)
+	}
+
+	return statuses, rows.Err()
+}
+
+// ClaimCharge cria a cobrança do período se ela ainda não existir. Como a
+// combinação (mensalidade, período) é única, só um processo a reivindica.
+func (r *SubscriptionRepository) ClaimCharge(subscriptionID int64, period string) (*SubscriptionCharge, bool, error) {
+	charge := &SubscriptionCharge{}
+	var txID sql.NullInt64
+
+	err := r.db.QueryRow(`
+		INSERT INTO subscription_charges (subscription_id, period)
+		VALUES ($1, $2)
+		ON CONFLICT (subscription_id, period) DO NOTHING
+		RETURNING id, subscription_id, period, transaction_id, reminders_sent, last_notified_at, created_at
+	`, subscriptionID, period).Scan(
+		&charge.ID, &charge.SubscriptionID, &charge.Period, &txID,
+		&charge.RemindersSent, &charge.LastNotifiedAt, &charge.CreatedAt,
+	)
+	if err == nil {
+		charge.TransactionID = txID.Int64
+		return charge, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("erro ao criar cobrança: %w", err)
+	}
+
+	charge, err = r.FindCharge(subscriptionID, period)
+	return charge, false, err
+}
+
+func (r *SubscriptionRepository) FindCharge(subscriptionID int64, period string) (*SubscriptionCharge, error) {
+	charge := &SubscriptionCharge{}
+	var txID sql.NullInt64
+
+	err := r.db.QueryRow(`
+		SELECT id, subscription_id, period, transaction_id, reminders_sent, last_notified_at, created_at
+		FROM subscription_charges
+		WHERE subscription_id = $1 AND period = $2
+	`, subscriptionID, period).Scan(
+		&charge.ID, &charge.SubscriptionID, &charge.Period, &txID,
+		&charge.RemindersSent, &charge.LastNotifiedAt, &charge.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar cobrança: %w", err)
+	}
+
+	charge.TransactionID = txID.Int64
+	return charge, nil
+}
+
+func (r *SubscriptionRepository) AttachTransaction(chargeID, transactionID int64) error {
+	_, err := r.db.Exec(`
+		UPDATE subscription_charges
+		SET transaction_id = $1, last_notified_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, transactionID, chargeID)
+	if err != nil {
+		return fmt.Errorf("erro ao vincular transação à cobrança: %w", err)
+	}
+	return nil
+}
+
+// ClaimReminder reivindica o lembrete de uma cobrança cujo último aviso tem
+// mais de interval. Como ClaimCharge, a condição fica no UPDATE: com vários
+// processos só um envia o lembrete
+func (r *SubscriptionRepository) ClaimReminder(chargeID int64, interval time.Duration) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE subscription_charges
+		SET reminders_sent = reminders_sent + 1, last_notified_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND transaction_id IS NOT NULL
+		  AND last_notified_at <= CURRENT_TIMESTAMP - $2 * INTERVAL '1 second'
+	`, chargeID, int64(interval.Seconds()))
+	if err != nil {
+		return false, fmt.Errorf("erro ao registrar lembrete: %w", err)
+	}
+	return claimedRow(result)
+}
+
+// ClaimStaleCharge retoma uma cobrança que ficou sem PIX: o processo que a
+// criou caiu antes de gerá-lo. Só vale depois de lease sem novidades, para não
+// disputar com quem ainda está gerando
+func (r *SubscriptionRepository) ClaimStaleCharge(chargeID int64, lease time.Duration) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE subscription_charges
+		SET last_notified_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND transaction_id IS NULL
+		  AND last_notified_at <= CURRENT_TIMESTAMP - $2 * INTERVAL '1 second'
+	`, chargeID, int64(lease.Seconds()))
+	if err != nil {
+		return false, fmt.Errorf("erro ao retomar cobrança: %w", err)
+	}
+	return claimedRow(result)
+}
+
+// ClaimRecharge reivindica a troca do PIX expirado ou falho da cobrança por
+// outro. O PIX antigo continua vinculado até o novo ser gerado, então se o
+// processo cair outro retoma depois de lease
+func (r *SubscriptionRepository) ClaimRecharge(chargeID, transactionID int64, lease time.Duration) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE subscription_charges
+		SET last_notified_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND transaction_id = $2
+		  AND last_notified_at <= CURRENT_TIMESTAMP - $3 * INTERVAL '1 second'
+	`, chargeID, transactionID, int64(lease.Seconds()))
+	if err != nil {
+		return false, fmt.Errorf("erro ao renovar cobrança: %w", err)
+	}
+	return claimedRow(result)
+}
+
+func claimedRow(result sql.Result) (bool, error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao ler linhas afetadas: %w", err)
+	}
+	return affected > 0, nil
+}
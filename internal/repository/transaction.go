@@ -3,8 +3,11 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type TransactionStatus string
@@ -13,6 +16,31 @@ const (
 	StatusPending   TransactionStatus = "PENDING"
 	StatusConfirmed TransactionStatus = "CONFIRMED"
 	StatusFailed    TransactionStatus = "FAILED"
+
+	// StatusExpired é um PIX cujo QR Code expirou sem ser pago
+	StatusExpired TransactionStatus = "EXPIRED"
+
+	// StatusDisputed é um PIX confirmado em mediação (MED) e StatusReversed
+	// um devolvido por chargeback; nenhum dos dois conta no saldo
+	StatusDisputed TransactionStatus = "DISPUTED"
+	StatusReversed TransactionStatus = "REVERSED"
+
+	// StatusAwaitingApproval é um PIX pago por um usuário sinalizado, que só
+	// entra no saldo depois de aprovado por um administrador
+	StatusAwaitingApproval TransactionStatus = "AWAITING_APPROVAL"
+)
+
+type TransactionType string
+
+const (
+	TypePix        TransactionType = "PIX"
+	TypeAdjustment TransactionType = "ADJUSTMENT"
+	TypeTransfer   TransactionType = "TRANSFER"
+)
+
+var (
+	ErrDuplicateTransaction = errors.New("transação já existe")
+	ErrInsufficientBalance  = errors.New("saldo disponível insuficiente")
 )
 
 type Transaction struct {
@@ -20,12 +48,17 @@ type Transaction struct {
 	WalletID          int64
 	Amount            float64
 	Status            TransactionStatus
+	Type              TransactionType
+	Description       string
 	ExternalReference string
+	IdempotencyKey    string
 	PaymentData       map[string]interface{}
 	CreatedAt         time.Time
 	ConfirmedAt       *time.Time
 }
 
+const transactionColumns = `id, wallet_id, amount, status, type, COALESCE(description, ''), COALESCE(external_reference, ''), COALESCE(idempotency_key, ''), payment_data, created_at, confirmed_at`
+
 type TransactionRepository struct {
 	db *sql.DB
 }
@@ -34,42 +67,210 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (r *TransactionRepository) Create(walletID int64, amount float64, externalRef string, paymentData map[string]interface{}) (*Transaction, error) {
+func scanTransaction(row interface{ Scan(...interface{}) error }) (*Transaction, error) {
+	tx := &Transaction{}
+	var paymentJSON []byte
+
+	err := row.Scan(
+		&tx.ID, &tx.WalletID, &tx.Amount, &tx.Status, &tx.Type, &tx.Description,
+		&tx.ExternalReference, &tx.IdempotencyKey, &paymentJSON, &tx.CreatedAt, &tx.ConfirmedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	json.Unmarshal(paymentJSON, &tx.PaymentData)
+	return tx, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (r *TransactionRepository) Create(walletID int64, amount float64, externalRef, idempotencyKey string, paymentData map[string]interface{}) (*Transaction, error) {
 	paymentJSON, err := json.Marshal(paymentData)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao serializar payment_data: %w", err)
 	}
 
-	tx := &Transaction{}
-	err = r.db.QueryRow(`
-		INSERT INTO transactions (wallet_id, amount, status, external_reference, payment_data)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, wallet_id, amount, status, external_reference, payment_data, created_at, confirmed_at
-	`, walletID, amount, StatusPending, externalRef, paymentJSON).Scan(
-		&tx.ID, &tx.WalletID, &tx.Amount, &tx.Status,
-		&tx.ExternalReference, &paymentJSON, &tx.CreatedAt, &tx.ConfirmedAt,
-	)
+	tx, err := scanTransaction(r.db.QueryRow(`
+		INSERT INTO transactions (wallet_id, amount, status, external_reference, idempotency_key, payment_data)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+transactionColumns,
+		walletID, amount, StatusPending, nullString(externalRef), nullString(idempotencyKey), paymentJSON,
+	))
 
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicateTransaction
+	}
 	if err != nil {
 		return nil, fmt.Errorf("erro ao criar transação: %w", err)
 	}
 
-	json.Unmarshal(paymentJSON, &tx.PaymentData)
 	return tx, nil
 }
 
-func (r *TransactionRepository) FindByExternalReference(externalRef string) (*Transaction, error) {
-	tx := &Transaction{}
-	var paymentJSON []byte
+// CreateAdjustment registra um crédito (amount > 0) ou débito (amount < 0)
+// manual, já confirmado, junto com o evento de auditoria. Um débito trava a
+// carteira como Transfer e devolve ErrInsufficientBalance se o saldo
+// disponível não cobrir
+func (r *TransactionRepository) CreateAdjustment(walletID int64, amount float64, reason string, meta AuditMeta) (*Transaction, error) {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer dbTx.Rollback()
 
-	err := r.db.QueryRow(`
-		SELECT id, wallet_id, amount, status, external_reference, payment_data, created_at, confirmed_at
+	if amount < 0 {
+		if _, err := dbTx.Exec(`SELECT id FROM wallets WHERE id = $1 FOR UPDATE`, walletID); err != nil {
+			return nil, fmt.Errorf("erro ao travar carteira: %w", err)
+		}
+		if err := requireAvailable(dbTx, walletID, -amount); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := scanTransaction(dbTx.QueryRow(`
+		INSERT INTO transactions (wallet_id, amount, status, type, description, confirmed_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		RETURNING `+transactionColumns,
+		walletID, amount, StatusConfirmed, TypeAdjustment, reason,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar ajuste: %w", err)
+	}
+
+	meta.Reason = reason
+	if err := insertAuditEvent(dbTx, meta, AuditBalanceAdjusted, "transaction", tx.ID, nil, tx.auditState()); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar ajuste: %w", err)
+	}
+
+	return tx, nil
+}
+
+// Transfer move saldo confirmado de uma carteira para outra, registrando um
+// débito e um crédito do tipo TRANSFER. As duas carteiras ficam travadas (em
+// ordem de ID, para não haver deadlock) e as compras não podem ser
+// registradas no meio, então o saldo disponível verificado é o que vale.
+// Com idempotencyKey, o débito guarda a chave e o crédito a chave + ":credito".
+func (r *TransactionRepository) Transfer(fromWalletID, toWalletID int64, amount float64, debitDescription, creditDescription, idempotencyKey string, meta AuditMeta) (*Transaction, *Transaction, error) {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if _, err := dbTx.Exec(`
+		SELECT id FROM wallets
+		WHERE id IN ($1, $2)
+		ORDER BY id
+		FOR UPDATE
+	`, fromWalletID, toWalletID); err != nil {
+		return nil, nil, fmt.Errorf("erro ao travar carteiras: %w", err)
+	}
+
+	if err := requireAvailable(dbTx, fromWalletID, amount); err != nil {
+		return nil, nil, err
+	}
+
+	var creditKey string
+	if idempotencyKey != "" {
+		creditKey = idempotencyKey + ":credito"
+	}
+
+	insert := func(walletID int64, amount float64, description, key string) (*Transaction, error) {
+		return scanTransaction(dbTx.QueryRow(`
+			INSERT INTO transactions (wallet_id, amount, status, type, description, idempotency_key, confirmed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+			RETURNING `+transactionColumns,
+			walletID, amount, StatusConfirmed, TypeTransfer, description, nullString(key),
+		))
+	}
+
+	debit, err := insert(fromWalletID, -amount, debitDescription, idempotencyKey)
+	if isUniqueViolation(err) {
+		return nil, nil, ErrDuplicateTransaction
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao registrar débito da transferência: %w", err)
+	}
+
+	credit, err := insert(toWalletID, amount, creditDescription, creditKey)
+	if isUniqueViolation(err) {
+		return nil, nil, ErrDuplicateTransaction
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao registrar crédito da transferência: %w", err)
+	}
+
+	after := map[string]interface{}{
+		"debit":  debit.auditState(),
+		"credit": credit.auditState(),
+	}
+	if err := insertAuditEvent(dbTx, meta, AuditBalanceTransferred, "transaction", debit.ID, nil, after); err != nil {
+		return nil, nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("erro ao confirmar transferência: %w", err)
+	}
+
+	return debit, credit, nil
+}
+
+// requireAvailable confere que o saldo disponível da carteira, já travada
+// pelo chamador, cobre amount. Trava purchase_shares até o commit para que
+// nenhuma compra seja registrada entre a conferência e o débito
+func requireAvailable(dbTx *sql.Tx, walletID int64, amount float64) error {
+	if _, err := dbTx.Exec(`LOCK TABLE purchase_shares IN SHARE MODE`); err != nil {
+		return fmt.Errorf("erro ao travar partes das compras: %w", err)
+	}
+
+	var balance AvailableBalance
+	err := dbTx.QueryRow(availableBalanceQuery+`
+	WHERE w.id = $1
+	`, walletID).Scan(&balance.WalletID, &balance.DiscordID, &balance.Username, &balance.Contributed, &balance.Spent)
+	if err != nil {
+		return fmt.Errorf("erro ao calcular saldo disponível: %w", err)
+	}
+	if !balance.Covers(amount) {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+func (r *TransactionRepository) FindByID(id int64) (*Transaction, error) {
+	tx, err := scanTransaction(r.db.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE id = $1
+	`, id))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+
+	return tx, nil
+}
+
+func (r *TransactionRepository) FindByExternalReference(externalRef string) (*Transaction, error) {
+	tx, err := scanTransaction(r.db.QueryRow(`
+		SELECT `+transactionColumns+`
 		FROM transactions
 		WHERE external_reference = $1
-	`, externalRef).Scan(
-		&tx.ID, &tx.WalletID, &tx.Amount, &tx.Status,
-		&tx.ExternalReference, &paymentJSON, &tx.CreatedAt, &tx.ConfirmedAt,
-	)
+	`, externalRef))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -78,30 +279,259 @@ func (r *TransactionRepository) FindByExternalReference(externalRef string) (*Tr
 		return nil, fmt.Errorf("erro ao buscar transação: %w", err)
 	}
 
-	json.Unmarshal(paymentJSON, &tx.PaymentData)
 	return tx, nil
 }
 
-func (r *TransactionRepository) UpdateStatus(id int64, status TransactionStatus) error {
-	var confirmedAt interface{}
-	if status == StatusConfirmed {
-		confirmedAt = time.Now()
+func (r *TransactionRepository) FindByIdempotencyKey(key string) (*Transaction, error) {
+	tx, err := scanTransaction(r.db.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE idempotency_key = $1
+	`, key))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+
+	return tx, nil
+}
 
+// CountPending conta os PIX ainda pendentes da carteira criados desde since
+func (r *TransactionRepository) CountPending(walletID int64, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM transactions
+		WHERE wallet_id = $1 AND type = $2 AND status = $3 AND created_at >= $4
+	`, walletID, TypePix, StatusPending, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao contar transações pendentes: %w", err)
+	}
+	return count, nil
+}
+
+// ClaimPendingPix reserva até limit PIX pendentes para o polling, começando
+// pelos que estão há mais tempo sem consulta, e marca a consulta. Assim um
+// lote que falha não trava os demais, e processos em paralelo não pegam os
+// mesmos PIX
+func (r *TransactionRepository) ClaimPendingPix(limit int) ([]*Transaction, error) {
+	rows, err := r.db.Query(`
+		UPDATE transactions
+		SET last_polled_at = CURRENT_TIMESTAMP
+		WHERE id IN (
+			SELECT id FROM transactions
+			WHERE type = $1 AND status = $2 AND external_reference IS NOT NULL
+			ORDER BY last_polled_at NULLS FIRST, created_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+transactionColumns, TypePix, StatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações pendentes: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler transação: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, rows.Err()
+}
+
+// DiscordMessage é a mensagem do bot com o QR Code de um PIX
+type DiscordMessage struct {
+	GuildID   string
+	ChannelID string
+	MessageID string
+}
+
+// AttachDiscordMessage associa a mensagem do QR Code à transação. Só a
+// primeira mensagem vale: devolve false se já havia outra
+func (r *TransactionRepository) AttachDiscordMessage(id int64, message DiscordMessage) (bool, error) {
 	result, err := r.db.Exec(`
+		UPDATE transactions
+		SET discord_guild_id = $2, discord_channel_id = $3, discord_message_id = $4
+		WHERE id = $1 AND (discord_message_id IS NULL OR discord_message_id = $4)
+	`, id, nullString(message.GuildID), message.ChannelID, message.MessageID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao associar mensagem à transação: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao associar mensagem à transação: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// FindDiscordMessage devolve a mensagem do QR Code da transação, ou nil se
+// o bot não registrou nenhuma
+func (r *TransactionRepository) FindDiscordMessage(id int64) (*DiscordMessage, error) {
+	var message DiscordMessage
+	err := r.db.QueryRow(`
+		SELECT COALESCE(discord_guild_id, ''), discord_channel_id, discord_message_id
+		FROM transactions
+		WHERE id = $1 AND discord_message_id IS NOT NULL
+	`, id).Scan(&message.GuildID, &message.ChannelID, &message.MessageID)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagem da transação: %w", err)
+	}
+	return &message, nil
+}
+
+// UpdateStatus altera o status e registra o evento de auditoria na mesma
+// transação do banco. Repetir o status atual não faz nada (idempotente).
+func (r *TransactionRepository) UpdateStatus(id int64, status TransactionStatus, meta AuditMeta) error {
+	_, err := r.updateStatus(id, "", status, meta)
+	return err
+}
+
+// TransitionStatus só altera o status se ele ainda for from; devolve false
+// sem erro quando outra atualização chegou antes
+func (r *TransactionRepository) TransitionStatus(id int64, from, to TransactionStatus, meta AuditMeta) (bool, error) {
+	return r.updateStatus(id, from, to, meta)
+}
+
+func (r *TransactionRepository) updateStatus(id int64, from, status TransactionStatus, meta AuditMeta) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanTransaction(tx.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE id = $1 AND ($2 = '' OR status = $2)
+		FOR UPDATE
+	`, id, from))
+	if err == sql.ErrNoRows && from != "" {
+		return false, nil
+	}
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("transação não encontrada: %d", id)
+	}
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+
+	if current.Status == status {
+		return false, nil
+	}
+
+	updated := *current
+	updated.Status = status
+	switch status {
+	case StatusConfirmed:
+		now := time.Now()
+		updated.ConfirmedAt = &now
+	case StatusDisputed, StatusReversed:
+		// O dinheiro chegou a entrar; a data da confirmação continua valendo
+	default:
+		updated.ConfirmedAt = nil
+	}
+
+	_, err = tx.Exec(`
 		UPDATE transactions
 		SET status = $1, confirmed_at = $2
-		WHERE id = $3 AND status != $1
-	`, status, confirmedAt, id)
+		WHERE id = $3
+	`, updated.Status, updated.ConfirmedAt, id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao atualizar status: %w", err)
+	}
 
+	if err := insertAuditEvent(tx, meta, AuditTransactionStatusChanged, "transaction", id, current.auditState(), updated.auditState()); err != nil {
+		return false, err
+	}
+
+	if err := insertOutboxEvent(tx, TopicTransactionStatusChanged, "transaction", id, TransactionStatusChanged{
+		TransactionID: id,
+		WalletID:      current.WalletID,
+		Type:          current.Type,
+		Amount:        current.Amount,
+		From:          current.Status,
+		To:            updated.Status,
+	}); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar atualização de status: %w", err)
+	}
+
+	return true, nil
+}
+
+func (t *Transaction) auditState() map[string]interface{} {
+	return map[string]interface{}{
+		"wallet_id":    t.WalletID,
+		"type":         t.Type,
+		"status":       t.Status,
+		"amount":       t.Amount,
+		"confirmed_at": t.ConfirmedAt,
+	}
+}
+
+type ReportRow struct {
+	TransactionID     int64
+	DiscordID         string
+	Username          string
+	Type              TransactionType
+	Status            TransactionStatus
+	Amount            float64
+	Description       string
+	ExternalReference string
+	CreatedAt         time.Time
+	ConfirmedAt       *time.Time
+}
+
+// EachReportRow percorre as transações do período [from, to) junto com o
+// usuário dono da carteira, uma linha por vez, sem carregar tudo em memória.
+// Datas zeradas não limitam o período.
+func (r *TransactionRepository) EachReportRow(from, to time.Time, fn func(ReportRow) error) error {
+	rows, err := r.db.Query(`
+		SELECT t.id, u.discord_id, u.username, t.type, t.status, t.amount,
+		       COALESCE(t.description, ''), COALESCE(t.external_reference, ''),
+		       t.created_at, t.confirmed_at
+		FROM transactions t
+		INNER JOIN wallets w ON w.id = t.wallet_id
+		INNER JOIN users u ON u.id = w.user_id
+		WHERE ($1::timestamp IS NULL OR t.created_at >= $1)
+		  AND ($2::timestamp IS NULL OR t.created_at < $2)
+		ORDER BY t.created_at, t.id
+	`, nullTime(from), nullTime(to))
 	if err != nil {
-		return fmt.Errorf("erro ao atualizar status: %w", err)
+		return fmt.Errorf("erro ao buscar transações: %w", err)
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ReportRow
+		if err := rows.Scan(
+			&row.TransactionID, &row.DiscordID, &row.Username, &row.Type, &row.Status, &row.Amount,
+			&row.Description, &row.ExternalReference, &row.CreatedAt, &row.ConfirmedAt,
+		); err != nil {
+			return fmt.Errorf("erro ao ler transação: %w", err)
+		}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return nil
+		if err := fn(row); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return rows.Err()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
 }
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransitionStatusOnlyFromExpected(t *testing.T) {
+	db := testDB(t)
+	repo := NewTransactionRepository(db)
+	_, wallet := testWallet(t, db, "1")
+	meta := AuditMeta{Actor: "test", Source: SourcePoll}
+
+	// Um webhook confirmou o PIX antes da expiração chegar
+	confirmed := testPix(t, db, wallet.ID, 10, StatusConfirmed)
+	changed, err := repo.TransitionStatus(confirmed.ID, StatusPending, StatusExpired, meta)
+	if err != nil {
+		t.Fatalf("TransitionStatus = %v", err)
+	}
+	if changed {
+		t.Error("TransitionStatus alterou um PIX que já não estava PENDING")
+	}
+	if got, _ := repo.FindByID(confirmed.ID); got.Status != StatusConfirmed {
+		t.Errorf("status = %s, quer %s", got.Status, StatusConfirmed)
+	}
+
+	pending := testPix(t, db, wallet.ID, 10, StatusPending)
+	changed, err = repo.TransitionStatus(pending.ID, StatusPending, StatusExpired, meta)
+	if err != nil {
+		t.Fatalf("TransitionStatus = %v", err)
+	}
+	if !changed {
+		t.Error("TransitionStatus não expirou um PIX PENDING")
+	}
+	if got, _ := repo.FindByID(pending.ID); got.Status != StatusExpired {
+		t.Errorf("status = %s, quer %s", got.Status, StatusExpired)
+	}
+}
+
+func TestTransferRequiresAvailableBalance(t *testing.T) {
+	db := testDB(t)
+	repo := NewTransactionRepository(db)
+	_, from := testWallet(t, db, "1")
+	_, to := testWallet(t, db, "2")
+	meta := AuditMeta{Actor: "test", Source: SourceAdmin}
+	testPix(t, db, from.ID, 10, StatusConfirmed)
+
+	if _, _, err := repo.Transfer(from.ID, to.ID, 10.01, "envio", "recebido", "", meta); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("Transfer acima do saldo = %v, quer ErrInsufficientBalance", err)
+	}
+	if _, _, err := repo.Transfer(from.ID, to.ID, 10, "envio", "recebido", "", meta); err != nil {
+		t.Fatalf("Transfer do saldo todo = %v", err)
+	}
+	if _, _, err := repo.Transfer(from.ID, to.ID, 0.01, "envio", "recebido", "", meta); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("Transfer com a carteira zerada = %v, quer ErrInsufficientBalance", err)
+	}
+}
+
+func TestCreateAdjustmentDebitRequiresAvailableBalance(t *testing.T) {
+	db := testDB(t)
+	repo := NewTransactionRepository(db)
+	_, wallet := testWallet(t, db, "1")
+	meta := AuditMeta{Actor: "test", Source: SourceAdmin}
+	testPix(t, db, wallet.ID, 10, StatusConfirmed)
+
+	if _, err := repo.CreateAdjustment(wallet.ID, -15, "estorno", meta); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("débito acima do saldo = %v, quer ErrInsufficientBalance", err)
+	}
+	if _, err := repo.CreateAdjustment(wallet.ID, -10, "estorno", meta); err != nil {
+		t.Fatalf("débito do saldo todo = %v", err)
+	}
+	// Crédito não depende do saldo
+	if _, err := repo.CreateAdjustment(wallet.ID, 5, "bônus", meta); err != nil {
+		t.Fatalf("crédito = %v", err)
+	}
+}
+
+func TestClaimPendingPixRotates(t *testing.T) {
+	db := testDB(t)
+	repo := NewTransactionRepository(db)
+	_, wallet := testWallet(t, db, "1")
+
+	first := testPix(t, db, wallet.ID, 10, StatusPending)
+	second := testPix(t, db, wallet.ID, 10, StatusPending)
+	testPix(t, db, wallet.ID, 10, StatusConfirmed)
+
+	claimed, err := repo.ClaimPendingPix(1)
+	if err != nil {
+		t.Fatalf("ClaimPendingPix = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != first.ID {
+		t.Fatalf("primeiro lote = %+v, quer o PIX %d", claimed, first.ID)
+	}
+
+	// O próximo lote começa pelo que ainda não foi consultado
+	claimed, err = repo.ClaimPendingPix(1)
+	if err != nil {
+		t.Fatalf("ClaimPendingPix = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != second.ID {
+		t.Fatalf("segundo lote = %+v, quer o PIX %d", claimed, second.ID)
+	}
+
+	// Só os pendentes entram no polling
+	claimed, err = repo.ClaimPendingPix(10)
+	if err != nil {
+		t.Fatalf("ClaimPendingPix = %v", err)
+	}
+	if len(claimed) != 2 {
+		t.Errorf("%d PIX reservados, quer os 2 pendentes", len(claimed))
+	}
+}
@@ -2,16 +2,42 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrMergeActiveSubscriptions impede juntar dois usuários que têm mensalidade
+// ativa: só uma pode ficar, e cancelar é decisão de um administrador
+var ErrMergeActiveSubscriptions = errors.New("os dois usuários têm mensalidade ativa; cancele uma antes de juntar")
+
 type User struct {
 	ID        int64
 	DiscordID string
 	Username  string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// PaymentHoldAt é preenchido quando o usuário foi sinalizado por uma
+	// contestação; enquanto isso seus novos pagamentos aguardam aprovação
+	PaymentHoldReason string
+	PaymentHoldAt     *time.Time
+}
+
+func (u *User) OnPaymentHold() bool {
+	return u.PaymentHoldAt != nil
+}
+
+const userColumns = `id, discord_id, username, created_at, updated_at, COALESCE(payment_hold_reason, ''), payment_hold_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	user := &User{}
+	err := row.Scan(&user.ID, &user.DiscordID, &user.Username, &user.CreatedAt, &user.UpdatedAt,
+		&user.PaymentHoldReason, &user.PaymentHoldAt)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 type UserRepository struct {
@@ -23,12 +49,11 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) FindByID(userID int64) (*User, error) {
-	user := &User{}
-	err := r.db.QueryRow(`
-		SELECT id, discord_id, username, created_at, updated_at
+	user, err := scanUser(r.db.QueryRow(`
+		SELECT `+userColumns+`
 		FROM users
 		WHERE id = $1
-	`, userID).Scan(&user.ID, &user.DiscordID, &user.Username, &user.CreatedAt, &user.UpdatedAt)
+	`, userID))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -41,12 +66,11 @@ func (r *UserRepository) FindByID(userID int64) (*User, error) {
 }
 
 func (r *UserRepository) FindByDiscordID(discordID string) (*User, error) {
-	user := &User{}
-	err := r.db.QueryRow(`
-		SELECT id, discord_id, username, created_at, updated_at
+	user, err := scanUser(r.db.QueryRow(`
+		SELECT `+userColumns+`
 		FROM users
 		WHERE discord_id = $1
-	`, discordID).Scan(&user.ID, &user.DiscordID, &user.Username, &user.CreatedAt, &user.UpdatedAt)
+	`, discordID))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -59,13 +83,11 @@ func (r *UserRepository) FindByDiscordID(discordID string) (*User, error) {
 }
 
 func (r *UserRepository) Create(discordID, username string) (*User, error) {
-	user := &User{}
-	err := r.db.QueryRow(`
+	user, err := scanUser(r.db.QueryRow(`
 		INSERT INTO users (discord_id, username)
 		VALUES ($1, $2)
-		RETURNING id, discord_id, username, created_at, updated_at
-	`, discordID, username).Scan(&user.ID, &user.DiscordID, &user.Username, &user.CreatedAt, &user.UpdatedAt)
-
+		RETURNING `+userColumns,
+		discordID, username))
 	if err != nil {
 		return nil, fmt.Errorf("erro ao criar usuário: %w", err)
 	}
@@ -92,3 +114,253 @@ func (r *UserRepository) FindOrCreate(discordID, username string) (*User, error)
 
 	return r.Create(discordID, username)
 }
+
+// Merge move todas as transações de `fromID` para a carteira de `intoID` e
+// remove o usuário duplicado, tudo em uma única transação do banco. A
+// mensalidade do duplicado passa para `intoID` se ele não tiver uma; se os
+// dois tiverem, fica a ativa e a outra é apagada com as cobranças (registradas
+// na auditoria). Com as duas ativas devolve ErrMergeActiveSubscriptions.
+func (r *UserRepository) Merge(fromID, intoID int64, meta AuditMeta) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	var intoWalletID int64
+	err = tx.QueryRow(`
+		INSERT INTO wallets (user_id)
+		VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING id
+	`, intoID).Scan(&intoWalletID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar carteira de destino: %w", err)
+	}
+
+	from, err := scanUser(tx.QueryRow(`
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`, fromID))
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário duplicado: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE transactions
+		SET wallet_id = $1
+		WHERE wallet_id IN (SELECT id FROM wallets WHERE user_id = $2)
+	`, intoWalletID, fromID)
+	if err != nil {
+		return fmt.Errorf("erro ao mover transações: %w", err)
+	}
+	moved, _ := result.RowsAffected()
+
+	// As partes de compras acompanham as transações para que o saldo
+	// disponível do usuário que permanece continue correto
+	if _, err := tx.Exec(`
+		UPDATE purchase_shares
+		SET wallet_id = $1
+		WHERE wallet_id IN (SELECT id FROM wallets WHERE user_id = $2)
+	`, intoWalletID, fromID); err != nil {
+		return fmt.Errorf("erro ao mover partes de compras: %w", err)
+	}
+
+	// A sinalização por contestação não some ao juntar os usuários
+	if from.OnPaymentHold() {
+		if _, err := tx.Exec(`
+			UPDATE users
+			SET payment_hold_reason = $2, payment_hold_at = $3
+			WHERE id = $1 AND payment_hold_at IS NULL
+		`, intoID, from.PaymentHoldReason, from.PaymentHoldAt); err != nil {
+			return fmt.Errorf("erro ao manter sinalização do usuário: %w", err)
+		}
+	}
+
+	subscription, err := mergeSubscriptions(tx, fromID, intoID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = $1`, fromID); err != nil {
+		return fmt.Errorf("erro ao remover usuário duplicado: %w", err)
+	}
+
+	before := map[string]interface{}{
+		"user_id":    from.ID,
+		"discord_id": from.DiscordID,
+		"username":   from.Username,
+	}
+	after := map[string]interface{}{
+		"merged_into_user_id": intoID,
+		"wallet_id":           intoWalletID,
+		"moved_transactions":  moved,
+	}
+	for k, v := range subscription {
+		after[k] = v
+	}
+	if err := insertAuditEvent(tx, meta, AuditUserMerged, "user", intoID, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar mesclagem: %w", err)
+	}
+
+	return nil
+}
+
+type mergedSubscription struct {
+	ID      int64   `json:"id"`
+	Amount  float64 `json:"amount"`
+	Active  bool    `json:"active"`
+	Charges int     `json:"charges"`
+}
+
+func findSubscriptionForMerge(tx *sql.Tx, userID int64) (*mergedSubscription, error) {
+	var sub mergedSubscription
+	err := tx.QueryRow(`
+		SELECT s.id, s.amount, s.active,
+		       (SELECT COUNT(*) FROM subscription_charges c WHERE c.subscription_id = s.id)
+		FROM subscriptions s
+		WHERE s.user_id = $1
+		FOR UPDATE
+	`, userID).Scan(&sub.ID, &sub.Amount, &sub.Active, &sub.Charges)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensalidade: %w", err)
+	}
+	return &sub, nil
+}
+
+// mergeSubscriptions resolve as mensalidades antes de o duplicado ser
+// apagado, que levaria a dele junto em cascata. Devolve o que aconteceu,
+// para a auditoria
+func mergeSubscriptions(tx *sql.Tx, fromID, intoID int64) (map[string]interface{}, error) {
+	from, err := findSubscriptionForMerge(tx, fromID)
+	if err != nil || from == nil {
+		return nil, err
+	}
+	into, err := findSubscriptionForMerge(tx, intoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if into != nil && from.Active && into.Active {
+		return nil, ErrMergeActiveSubscriptions
+	}
+
+	// Fica a ativa; entre duas inativas, a de quem permanece
+	if into == nil || (from.Active && !into.Active) {
+		if into != nil {
+			if _, err := tx.Exec(`DELETE FROM subscriptions WHERE id = $1`, into.ID); err != nil {
+				return nil, fmt.Errorf("erro ao remover mensalidade inativa: %w", err)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE subscriptions SET user_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, intoID, from.ID); err != nil {
+			return nil, fmt.Errorf("erro ao mover mensalidade: %w", err)
+		}
+		result := map[string]interface{}{"moved_subscription_id": from.ID}
+		if into != nil {
+			result["removed_subscription"] = into
+		}
+		return result, nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM subscriptions WHERE id = $1`, from.ID); err != nil {
+		return nil, fmt.Errorf("erro ao remover mensalidade inativa: %w", err)
+	}
+	return map[string]interface{}{"removed_subscription": from}, nil
+}
+
+// SetPaymentHold sinaliza o usuário, com o evento de auditoria na mesma
+// transação. Devolve false se ele já estava sinalizado (o motivo original
+// é mantido)
+func (r *UserRepository) SetPaymentHold(userID int64, reason string, meta AuditMeta) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	user, err := scanUser(tx.QueryRow(`
+		UPDATE users
+		SET payment_hold_reason = $2, payment_hold_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND payment_hold_at IS NULL
+		RETURNING `+userColumns,
+		userID, reason))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("erro ao sinalizar usuário: %w", err)
+	}
+
+	meta.Reason = reason
+	after := map[string]interface{}{
+		"discord_id":   user.DiscordID,
+		"hold_reason":  user.PaymentHoldReason,
+		"payment_hold": true,
+	}
+	if err := insertAuditEvent(tx, meta, AuditUserPaymentHold, "user", userID, nil, after); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar sinalização: %w", err)
+	}
+	return true, nil
+}
+
+// ReleasePaymentHold tira a sinalização do usuário. Devolve false se ele não
+// estava sinalizado
+func (r *UserRepository) ReleasePaymentHold(userID int64, meta AuditMeta) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanUser(tx.QueryRow(`
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1 AND payment_hold_at IS NOT NULL
+		FOR UPDATE
+	`, userID))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users
+		SET payment_hold_reason = NULL, payment_hold_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID); err != nil {
+		return false, fmt.Errorf("erro ao liberar usuário: %w", err)
+	}
+
+	before := map[string]interface{}{
+		"discord_id":      current.DiscordID,
+		"hold_reason":     current.PaymentHoldReason,
+		"payment_hold_at": current.PaymentHoldAt,
+	}
+	after := map[string]interface{}{
+		"discord_id":   current.DiscordID,
+		"payment_hold": false,
+	}
+	if err := insertAuditEvent(tx, meta, AuditUserPaymentReleased, "user", userID, before, after); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar liberação: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func testSubscription(t *testing.T, db *sql.DB, userID int64, active bool) int64 {
+	t.Helper()
+	var id int64
+	err := db.QueryRow(`
+		INSERT INTO subscriptions (user_id, amount, due_day, active)
+		VALUES ($1, 10, 5, $2)
+		RETURNING id
+	`, userID, active).Scan(&id)
+	if err != nil {
+		t.Fatalf("erro ao criar mensalidade: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO subscription_charges (subscription_id, period) VALUES ($1, '2026-01')`, id); err != nil {
+		t.Fatalf("erro ao criar cobrança: %v", err)
+	}
+	return id
+}
+
+func subscriptionOwner(t *testing.T, db *sql.DB, subscriptionID int64) int64 {
+	t.Helper()
+	var userID int64
+	err := db.QueryRow(`SELECT user_id FROM subscriptions WHERE id = $1`, subscriptionID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0
+	}
+	if err != nil {
+		t.Fatalf("erro ao buscar mensalidade: %v", err)
+	}
+	return userID
+}
+
+func TestMergeSubscriptions(t *testing.T) {
+	meta := AuditMeta{Actor: "test", Source: SourceAdmin}
+
+	tests := []struct {
+		name       string
+		fromActive *bool
+		intoActive *bool
+		wantErr    error
+		wantKept   string // "from", "into" ou ""
+	}{
+		{"só o duplicado tem", ptr(true), nil, nil, "from"},
+		{"duplicado ativa, destino inativa", ptr(true), ptr(false), nil, "from"},
+		{"duplicado inativa, destino ativa", ptr(false), ptr(true), nil, "into"},
+		{"as duas inativas", ptr(false), ptr(false), nil, "into"},
+		{"as duas ativas", ptr(true), ptr(true), ErrMergeActiveSubscriptions, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			repo := NewUserRepository(db)
+			from, _ := testWallet(t, db, "1")
+			into, _ := testWallet(t, db, "2")
+
+			var fromSub, intoSub int64
+			if tt.fromActive != nil {
+				fromSub = testSubscription(t, db, from.ID, *tt.fromActive)
+			}
+			if tt.intoActive != nil {
+				intoSub = testSubscription(t, db, into.ID, *tt.intoActive)
+			}
+
+			err := repo.Merge(from.ID, into.ID, meta)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Merge = %v, quer %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				// Nada muda quando a mesclagem é recusada
+				if got, _ := repo.FindByID(from.ID); got == nil {
+					t.Error("o duplicado foi apagado mesmo com a mesclagem recusada")
+				}
+				return
+			}
+
+			kept, removed := fromSub, intoSub
+			if tt.wantKept == "into" {
+				kept, removed = intoSub, fromSub
+			}
+			if owner := subscriptionOwner(t, db, kept); owner != into.ID {
+				t.Errorf("mensalidade %d ficou com o usuário %d, quer %d", kept, owner, into.ID)
+			}
+			if removed != 0 {
+				if owner := subscriptionOwner(t, db, removed); owner != 0 {
+					t.Errorf("mensalidade %d continua com o usuário %d", removed, owner)
+				}
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestPaymentHold(t *testing.T) {
+	db := testDB(t)
+	repo := NewUserRepository(db)
+	user, _ := testWallet(t, db, "1")
+	meta := AuditMeta{Actor: "mercadopago", Source: SourceWebhook}
+
+	if changed, err := repo.SetPaymentHold(user.ID, "chargeback no PIX #1", meta); err != nil || !changed {
+		t.Fatalf("SetPaymentHold = %v, %v", changed, err)
+	}
+	// Uma segunda contestação não troca o motivo original
+	if changed, err := repo.SetPaymentHold(user.ID, "chargeback no PIX #2", meta); err != nil || changed {
+		t.Fatalf("SetPaymentHold repetido = %v, %v", changed, err)
+	}
+	held, err := repo.FindByID(user.ID)
+	if err != nil {
+		t.Fatalf("FindByID = %v", err)
+	}
+	if !held.OnPaymentHold() || held.PaymentHoldReason != "chargeback no PIX #1" {
+		t.Errorf("usuário = sinalizado %v, motivo %q", held.OnPaymentHold(), held.PaymentHoldReason)
+	}
+
+	if changed, err := repo.ReleasePaymentHold(user.ID, meta); err != nil || !changed {
+		t.Fatalf("ReleasePaymentHold = %v, %v", changed, err)
+	}
+	if changed, err := repo.ReleasePaymentHold(user.ID, meta); err != nil || changed {
+		t.Fatalf("ReleasePaymentHold repetido = %v, %v", changed, err)
+	}
+	if released, _ := repo.FindByID(user.ID); released.OnPaymentHold() {
+		t.Error("usuário continua sinalizado")
+	}
+}
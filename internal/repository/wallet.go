@@ -99,50 +99,148 @@ func (r *WalletRepository) GetBalance(walletID int64) (float64, error) {
 	return balance.Float64, nil
 }
 
-func (r *WalletRepository) GetTotalBalance() (float64, error) {
-	var balance sql.NullFloat64
+// FundTotals resume a vaquinha inteira. Raised soma todas as transações
+// confirmadas (inclusive débitos manuais; transferências se anulam).
+// Contributors conta os membros com PIX confirmado, como GetRanking: quem só
+// recebeu transferência ou ajuste não entra.
+type FundTotals struct {
+	Raised       float64
+	Spent        float64
+	Pending      float64
+	Contributors int
+}
+
+func (r *WalletRepository) GetTotals() (*FundTotals, error) {
+	totals := &FundTotals{}
 	err := r.db.QueryRow(`
-		SELECT COALESCE(SUM(amount), 0)
-		FROM transactions
-		WHERE status = 'CONFIRMED'
-	`).Scan(&balance)
+		SELECT
+			COALESCE((SELECT SUM(amount) FROM transactions WHERE status = 'CONFIRMED'), 0),
+			COALESCE((SELECT SUM(amount) FROM purchases), 0),
+			COALESCE((SELECT SUM(amount) FROM transactions WHERE status = 'PENDING'), 0),
+			(SELECT COUNT(*) FROM (
+				SELECT w.user_id
+				FROM transactions t
+				INNER JOIN wallets w ON w.id = t.wallet_id
+				WHERE t.status = 'CONFIRMED' AND t.type = 'PIX'
+				GROUP BY w.user_id
+				HAVING SUM(t.amount) > 0
+			) contributors)
+	`).Scan(&totals.Raised, &totals.Spent, &totals.Pending, &totals.Contributors)
 
 	if err != nil {
-		return 0, fmt.Errorf("erro ao calcular saldo total: %w", err)
+		return nil, fmt.Errorf("erro ao calcular saldo total: %w", err)
 	}
 
-	return balance.Float64, nil
+	return totals, nil
 }
 
 type RankingEntry struct {
-	Username string
-	Balance  float64
+	Rank      int
+	DiscordID string
+	Username  string
+	Balance   float64
+}
+
+// RankingFilter limita o ranking às transações confirmadas em [From, To);
+// datas zeradas deixam o lado correspondente em aberto
+type RankingFilter struct {
+	From      time.Time
+	To        time.Time
+	Limit     int
+	DiscordID string
 }
 
-func (r *WalletRepository) GetRanking(limit int) ([]RankingEntry, error) {
+// Ranking traz as primeiras posições e, se DiscordID foi informado, a
+// posição de quem consultou, mesmo fora do top
+type Ranking struct {
+	Entries []RankingEntry
+	Caller  *RankingEntry
+}
+
+// GetRanking soma só os PIX confirmados: é o ranking de quem contribuiu, então
+// transferências entre membros e ajustes manuais não contam. Usa DENSE_RANK:
+// empatados dividem a posição e a seguinte não é pulada (1, 1, 2)
+func (r *WalletRepository) GetRanking(filter RankingFilter) (*Ranking, error) {
 	rows, err := r.db.Query(`
-		SELECT u.username, COALESCE(SUM(t.amount), 0) as balance
-		FROM users u
-		INNER JOIN wallets w ON w.user_id = u.id
-		LEFT JOIN transactions t ON t.wallet_id = w.id AND t.status = 'CONFIRMED'
-		GROUP BY u.id, u.username
-		HAVING COALESCE(SUM(t.amount), 0) > 0
-		ORDER BY balance DESC
-		LIMIT $1
-	`, limit)
+		WITH totals AS (
+			SELECT u.discord_id, u.username, SUM(t.amount) as balance
+			FROM users u
+			INNER JOIN wallets w ON w.user_id = u.id
+			INNER JOIN transactions t ON t.wallet_id = w.id AND t.status = 'CONFIRMED' AND t.type = 'PIX'
+			WHERE ($1::timestamp IS NULL OR t.confirmed_at >= $1)
+			  AND ($2::timestamp IS NULL OR t.confirmed_at < $2)
+			GROUP BY u.id, u.discord_id, u.username
+			HAVING SUM(t.amount) > 0
+		), ranked AS (
+			SELECT DENSE_RANK() OVER (ORDER BY balance DESC) as rank,
+			       ROW_NUMBER() OVER (ORDER BY balance DESC, username, discord_id) as position,
+			       discord_id, username, balance
+			FROM totals
+		)
+		SELECT rank, position, discord_id, username, balance
+		FROM ranked
+		WHERE position <= $3 OR discord_id = $4
+		ORDER BY position
+	`, nullTime(filter.From), nullTime(filter.To), filter.Limit, filter.DiscordID)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar ranking: %w", err)
 	}
 	defer rows.Close()
 
-	var ranking []RankingEntry
+	ranking := &Ranking{}
 	for rows.Next() {
 		var entry RankingEntry
-		if err := rows.Scan(&entry.Username, &entry.Balance); err != nil {
+		var position int
+		if err := rows.Scan(&entry.Rank, &position, &entry.DiscordID, &entry.Username, &entry.Balance); err != nil {
 			return nil, fmt.Errorf("erro ao ler ranking: %w", err)
 		}
-		ranking = append(ranking, entry)
+
+		if filter.DiscordID != "" && entry.DiscordID == filter.DiscordID {
+			caller := entry
+			ranking.Caller = &caller
+		}
+		if position <= filter.Limit {
+			ranking.Entries = append(ranking.Entries, entry)
+		}
+	}
+
+	return ranking, rows.Err()
+}
+
+type UserBalance struct {
+	UserID    int64
+	DiscordID string
+	Username  string
+	Balance   float64
+	Pending   float64
+	Spent     float64
+}
+
+func (r *WalletRepository) ListBalances() ([]UserBalance, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.discord_id, u.username,
+		       COALESCE(SUM(t.amount) FILTER (WHERE t.status = 'CONFIRMED'), 0) as balance,
+		       COALESCE(SUM(t.amount) FILTER (WHERE t.status = 'PENDING'), 0) as pending,
+		       COALESCE((SELECT SUM(ps.amount) FROM purchase_shares ps WHERE ps.wallet_id = w.id), 0) as spent
+		FROM users u
+		LEFT JOIN wallets w ON w.user_id = u.id
+		LEFT JOIN transactions t ON t.wallet_id = w.id
+		GROUP BY u.id, u.discord_id, u.username, w.id
+		ORDER BY balance DESC, u.username
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar saldos: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []UserBalance
+	for rows.Next() {
+		var b UserBalance
+		if err := rows.Scan(&b.UserID, &b.DiscordID, &b.Username, &b.Balance, &b.Pending, &b.Spent); err != nil {
+			return nil, fmt.Errorf("erro ao ler saldo: %w", err)
+		}
+		balances = append(balances, b)
 	}
 
-	return ranking, nil
+	return balances, rows.Err()
 }
@@ -0,0 +1,76 @@
+package repository
+
+import "testing"
+
+func TestGetTotalsCountsPixContributors(t *testing.T) {
+	db := testDB(t)
+	transactions := NewTransactionRepository(db)
+	meta := AuditMeta{Actor: "test", Source: SourceAdmin}
+
+	_, pix := testWallet(t, db, "1")
+	_, transfer := testWallet(t, db, "2")
+	_, adjusted := testWallet(t, db, "3")
+	_, pending := testWallet(t, db, "4")
+
+	testPix(t, db, pix.ID, 30, StatusConfirmed)
+	testPix(t, db, pix.ID, 20, StatusConfirmed)
+	testPix(t, db, pending.ID, 10, StatusPending)
+	if _, _, err := transactions.Transfer(pix.ID, transfer.ID, 5, "envio", "recebido", "", meta); err != nil {
+		t.Fatalf("Transfer = %v", err)
+	}
+	if _, err := transactions.CreateAdjustment(adjusted.ID, 15, "bônus", meta); err != nil {
+		t.Fatalf("CreateAdjustment = %v", err)
+	}
+
+	totals, err := NewWalletRepository(db).GetTotals()
+	if err != nil {
+		t.Fatalf("GetTotals = %v", err)
+	}
+	if totals.Contributors != 1 {
+		t.Errorf("Contributors = %d, quer 1", totals.Contributors)
+	}
+	if totals.Raised != 65 {
+		t.Errorf("Raised = %v, quer 65", totals.Raised)
+	}
+	if totals.Pending != 10 {
+		t.Errorf("Pending = %v, quer 10", totals.Pending)
+	}
+}
+
+func TestGetRankingUsesDenseRanks(t *testing.T) {
+	db := testDB(t)
+	transactions := NewTransactionRepository(db)
+	meta := AuditMeta{Actor: "test", Source: SourceAdmin}
+
+	amounts := map[string]float64{"1": 50, "2": 50, "3": 30, "4": 10}
+	wallets := map[string]*Wallet{}
+	for discordID, amount := range amounts {
+		_, wallet := testWallet(t, db, discordID)
+		testPix(t, db, wallet.ID, amount, StatusConfirmed)
+		wallets[discordID] = wallet
+	}
+	// Ajustes e transferências não mudam o ranking
+	if _, err := transactions.CreateAdjustment(wallets["4"].ID, 100, "bônus", meta); err != nil {
+		t.Fatalf("CreateAdjustment = %v", err)
+	}
+
+	ranking, err := NewWalletRepository(db).GetRanking(RankingFilter{Limit: 2, DiscordID: "4"})
+	if err != nil {
+		t.Fatalf("GetRanking = %v", err)
+	}
+
+	if len(ranking.Entries) != 2 {
+		t.Fatalf("len(Entries) = %d, quer 2", len(ranking.Entries))
+	}
+	for _, entry := range ranking.Entries {
+		if entry.Rank != 1 {
+			t.Errorf("%s: rank %d, quer 1 (empate)", entry.DiscordID, entry.Rank)
+		}
+	}
+	if ranking.Caller == nil {
+		t.Fatal("Caller = nil para quem está fora do top")
+	}
+	if ranking.Caller.Rank != 3 || ranking.Caller.Balance != 10 {
+		t.Errorf("Caller = rank %d, saldo %v; quer rank 3, saldo 10", ranking.Caller.Rank, ranking.Caller.Balance)
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type InboxStatus string
+
+const (
+	InboxPending InboxStatus = "PENDING"
+	InboxDone    InboxStatus = "DONE"
+	InboxDead    InboxStatus = "DEAD"
+)
+
+type InboxEntry struct {
+	ID          int64
+	Provider    string
+	Body        []byte
+	Headers     http.Header
+	PayloadHash string
+	Status      InboxStatus
+	Attempts    int
+	LastError   string
+	AvailableAt time.Time
+	ReceivedAt  time.Time
+	ProcessedAt *time.Time
+}
+
+// InboxFilter seleciona entradas para a visão administrativa. Stuck traz as
+// mortas e as pendentes que já falharam pelo menos uma vez
+type InboxFilter struct {
+	Status InboxStatus
+	Stuck  bool
+	Limit  int
+}
+
+const inboxColumns = `id, provider, body, headers, payload_hash, status, attempts, COALESCE(last_error, ''), available_at, received_at, processed_at`
+
+type WebhookInboxRepository struct {
+	db    *sql.DB
+	queue leaseQueue[InboxEntry]
+}
+
+func NewWebhookInboxRepository(db *sql.DB) *WebhookInboxRepository {
+	return &WebhookInboxRepository{
+		db: db,
+		queue: leaseQueue[InboxEntry]{
+			db:      db,
+			table:   "webhook_inbox",
+			columns: inboxColumns,
+			orderBy: "received_at",
+			one:     "webhook",
+			many:    "webhooks",
+			scan:    scanInboxEntry,
+		},
+	}
+}
+
+func scanInboxEntry(row interface{ Scan(...interface{}) error }) (*InboxEntry, error) {
+	e := &InboxEntry{}
+	var headers []byte
+	err := row.Scan(&e.ID, &e.Provider, &e.Body, &headers, &e.PayloadHash, &e.Status,
+		&e.Attempts, &e.LastError, &e.AvailableAt, &e.ReceivedAt, &e.ProcessedAt)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(headers, &e.Headers)
+	return e, nil
+}
+
+func (r *WebhookInboxRepository) Insert(provider string, body []byte, headers http.Header, payloadHash string) (*InboxEntry, error) {
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar cabeçalhos do webhook: %w", err)
+	}
+
+	entry, err := scanInboxEntry(r.db.QueryRow(`
+		INSERT INTO webhook_inbox (provider, body, headers, payload_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+inboxColumns, provider, body, headersJSON, payloadHash))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gravar webhook: %w", err)
+	}
+	return entry, nil
+}
+
+// Claim reserva até limit entradas prontas e conta a tentativa. Cada entrada
+// fica invisível por lease; se o processo morrer antes de MarkDone ou
+// MarkFailed, ela volta a ser processada depois disso.
+func (r *WebhookInboxRepository) Claim(limit int, lease time.Duration) ([]InboxEntry, error) {
+	return r.queue.claim(limit, lease)
+}
+
+func (r *WebhookInboxRepository) MarkDone(id int64) error {
+	return r.queue.markDone(id)
+}
+
+// MarkFailed agenda nova tentativa depois de retryIn ou, com dead, desiste
+// da entrada até um administrador reprocessá-la
+func (r *WebhookInboxRepository) MarkFailed(id int64, reason string, retryIn time.Duration, dead bool) error {
+	return r.queue.markFailed(id, reason, retryIn, dead)
+}
+
+// Requeue devolve a entrada para a fila com as tentativas zeradas, inclusive
+// uma já processada. Devolve false se ela não existe
+func (r *WebhookInboxRepository) Requeue(id int64) (bool, error) {
+	return r.queue.requeue(id, true)
+}
+
+// PruneDone apaga as entradas processadas há mais de olderThan
+func (r *WebhookInboxRepository) PruneDone(olderThan time.Duration) (int64, error) {
+	return r.queue.pruneDone(olderThan)
+}
+
+func (r *WebhookInboxRepository) FindByID(id int64) (*InboxEntry, error) {
+	return r.queue.findByID(id)
+}
+
+func (r *WebhookInboxRepository) List(filter InboxFilter) ([]InboxEntry, error) {
+	return r.queue.list(string(filter.Status), filter.Stuck, filter.Limit)
+}
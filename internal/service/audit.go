@@ -0,0 +1,27 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+func (s *AuditService) List(filter repository.AuditFilter) ([]repository.AuditEvent, error) {
+	if filter.Limit > 200 {
+		filter.Limit = 200
+	}
+
+	events, err := s.auditRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar auditoria: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+var (
+	ErrInvalidPrefix  = i18n.NewError("error.invalid_prefix")
+	ErrInvalidChannel = i18n.NewError("error.invalid_channel")
+)
+
+const (
+	// DefaultCommandPrefix vale nos servidores sem prefixo próprio e sempre
+	// nas mensagens privadas
+	DefaultCommandPrefix = "!"
+	maxCommandPrefixLen  = 3
+)
+
+// GuildSettingsUpdate muda só os campos informados; AllowedChannelIDs
+// vazio libera todos os canais e AnnouncementChannelID vazio desliga os
+// anúncios
+type GuildSettingsUpdate struct {
+	CommandPrefix         *string
+	AllowedChannelIDs     *[]string
+	AnnouncementChannelID *string
+}
+
+type GuildSettingsService struct {
+	settingsRepo *repository.GuildSettingsRepository
+}
+
+func NewGuildSettingsService(settingsRepo *repository.GuildSettingsRepository) *GuildSettingsService {
+	return &GuildSettingsService{settingsRepo: settingsRepo}
+}
+
+// Get devolve as configurações do servidor, com os padrões se ele nunca foi
+// configurado
+func (s *GuildSettingsService) Get(guildID string) (*repository.GuildSettings, error) {
+	if guildID == "" {
+		return nil, ErrGuildRequired
+	}
+
+	settings, err := s.settingsRepo.Find(guildID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &repository.GuildSettings{
+			GuildID:           guildID,
+			CommandPrefix:     DefaultCommandPrefix,
+			AllowedChannelIDs: []string{},
+		}
+	}
+	return settings, nil
+}
+
+func (s *GuildSettingsService) Update(guildID string, update GuildSettingsUpdate, meta repository.AuditMeta) (*repository.GuildSettings, error) {
+	settings, err := s.Get(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.CommandPrefix != nil {
+		if !validCommandPrefix(*update.CommandPrefix) {
+			return nil, ErrInvalidPrefix
+		}
+		settings.CommandPrefix = *update.CommandPrefix
+	}
+	if update.AllowedChannelIDs != nil {
+		channels := make([]string, 0, len(*update.AllowedChannelIDs))
+		seen := make(map[string]bool)
+		for _, id := range *update.AllowedChannelIDs {
+			if !validSnowflake(id) {
+				return nil, ErrInvalidChannel
+			}
+			if !seen[id] {
+				seen[id] = true
+				channels = append(channels, id)
+			}
+		}
+		settings.AllowedChannelIDs = channels
+	}
+	if update.AnnouncementChannelID != nil {
+		if *update.AnnouncementChannelID != "" && !validSnowflake(*update.AnnouncementChannelID) {
+			return nil, ErrInvalidChannel
+		}
+		settings.AnnouncementChannelID = *update.AnnouncementChannelID
+	}
+
+	return s.settingsRepo.Save(*settings, meta)
+}
+
+// AnnouncementChannels devolve os servidores que querem anúncios
+func (s *GuildSettingsService) AnnouncementChannels() ([]repository.GuildSettings, error) {
+	return s.settingsRepo.ListAnnouncing()
+}
+
+// validCommandPrefix aceita até três caracteres visíveis; menções e
+// formatação do Discord confundiriam o parser do bot
+func validCommandPrefix(prefix string) bool {
+	if prefix == "" || utf8.RuneCountInString(prefix) > maxCommandPrefixLen {
+		return false
+	}
+	if strings.ContainsAny(prefix, "<@#`*_~|\\") {
+		return false
+	}
+	for _, r := range prefix {
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func validSnowflake(id string) bool {
+	if id == "" || len(id) > 20 {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"log"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+var ErrInvalidLocale = i18n.NewError("error.invalid_locale")
+
+// LocaleSetting é o idioma configurado e de onde ele veio: user, guild ou
+// vazio quando não há configuração (o bot usa então o idioma do Discord)
+type LocaleSetting struct {
+	Locale i18n.Locale
+	Source repository.LocaleScope
+}
+
+type LocaleService struct {
+	localeRepo *repository.LocaleRepository
+}
+
+func NewLocaleService(localeRepo *repository.LocaleRepository) *LocaleService {
+	return &LocaleService{localeRepo: localeRepo}
+}
+
+// Resolve aplica a preferência do usuário e, na falta dela, a do servidor
+func (s *LocaleService) Resolve(discordID, guildID string) (LocaleSetting, error) {
+	user, guild, err := s.localeRepo.Find(discordID, guildID)
+	if err != nil {
+		return LocaleSetting{}, err
+	}
+
+	if locale, ok := i18n.Parse(user); ok {
+		return LocaleSetting{Locale: locale, Source: repository.LocaleScopeUser}, nil
+	}
+	if locale, ok := i18n.Parse(guild); ok {
+		return LocaleSetting{Locale: locale, Source: repository.LocaleScopeGuild}, nil
+	}
+	return LocaleSetting{}, nil
+}
+
+// ForUser devolve o idioma das mensagens privadas de um membro. Sem
+// configuração (ou se a busca falhar) fica o idioma padrão
+func (s *LocaleService) ForUser(discordID string) i18n.Locale {
+	return s.ForMember(discordID, "")
+}
+
+// ForMember devolve o idioma de um membro em um servidor, como o bot o
+// resolveria
+func (s *LocaleService) ForMember(discordID, guildID string) i18n.Locale {
+	if s == nil {
+		return i18n.Default
+	}
+
+	setting, err := s.Resolve(discordID, guildID)
+	if err != nil {
+		log.Printf("Erro ao buscar idioma de %s: %v", discordID, err)
+		return i18n.Default
+	}
+	if setting.Locale == "" {
+		return i18n.Default
+	}
+	return setting.Locale
+}
+
+// Set grava o idioma do usuário ou do servidor. locale vazio volta ao
+// automático
+func (s *LocaleService) Set(scope repository.LocaleScope, scopeID, locale string) (LocaleSetting, error) {
+	if locale == "" {
+		if err := s.localeRepo.Set(scope, scopeID, ""); err != nil {
+			return LocaleSetting{}, err
+		}
+		return LocaleSetting{}, nil
+	}
+
+	parsed, ok := i18n.Parse(locale)
+	if !ok {
+		return LocaleSetting{}, ErrInvalidLocale
+	}
+	if err := s.localeRepo.Set(scope, scopeID, string(parsed)); err != nil {
+		return LocaleSetting{}, err
+	}
+	return LocaleSetting{Locale: parsed, Source: scope}, nil
+}
+
+// ForGuild devolve o idioma dos anúncios em um servidor
+func (s *LocaleService) ForGuild(guildID string) i18n.Locale {
+	if s == nil {
+		return i18n.Default
+	}
+
+	_, guild, err := s.localeRepo.Find("", guildID)
+	if err != nil {
+		log.Printf("Erro ao buscar idioma do servidor %s: %v", guildID, err)
+		return i18n.Default
+	}
+	if locale, ok := i18n.Parse(guild); ok {
+		return locale
+	}
+	return i18n.Default
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+// NewPaymentConfirmedHandler avisa o membro por DM quando um PIX dele é
+// confirmado. Registrado no outbox em TopicTransactionStatusChanged
+func NewPaymentConfirmedHandler(
+	userRepo *repository.UserRepository,
+	walletRepo *repository.WalletRepository,
+	notifier Notifier,
+	locales *LocaleService,
+) OutboxHandler {
+	return func(event repository.OutboxEvent) error {
+		var change repository.TransactionStatusChanged
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return fmt.Errorf("erro ao ler evento: %w", err)
+		}
+
+		if change.Type != repository.TypePix || change.To != repository.StatusConfirmed {
+			return nil
+		}
+
+		wallet, err := walletRepo.FindByID(change.WalletID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar carteira: %w", err)
+		}
+		if wallet == nil {
+			return nil
+		}
+
+		user, err := userRepo.FindByID(wallet.UserID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar usuário: %w", err)
+		}
+		if user == nil {
+			return nil
+		}
+
+		return notifier.SendDirectMessage(user.DiscordID,
+			i18n.T(locales.ForUser(user.DiscordID), "dm.payment.confirmed", i18n.Money(change.Amount)))
+	}
+}
+
+// NewPaymentAnnouncementHandler anuncia os PIX confirmados no canal de
+// anúncios de cada servidor que configurou um. Registrado no outbox em
+// TopicTransactionStatusChanged
+func NewPaymentAnnouncementHandler(
+	userRepo *repository.UserRepository,
+	walletRepo *repository.WalletRepository,
+	guildSettings *GuildSettingsService,
+	notifier Notifier,
+	locales *LocaleService,
+) OutboxHandler {
+	return func(event repository.OutboxEvent) error {
+		var change repository.TransactionStatusChanged
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return fmt.Errorf("erro ao ler evento: %w", err)
+		}
+
+		if change.Type != repository.TypePix || change.To != repository.StatusConfirmed {
+			return nil
+		}
+
+		channels, err := guildSettings.AnnouncementChannels()
+		if err != nil {
+			return err
+		}
+		if len(channels) == 0 {
+			return nil
+		}
+
+		wallet, err := walletRepo.FindByID(change.WalletID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar carteira: %w", err)
+		}
+		if wallet == nil {
+			return nil
+		}
+		user, err := userRepo.FindByID(wallet.UserID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar usuário: %w", err)
+		}
+		if user == nil {
+			return nil
+		}
+
+		// Como nos avisos aos administradores, um canal apagado não impede
+		// os demais anúncios
+		var lastErr error
+		delivered := 0
+		for _, settings := range channels {
+			message := i18n.T(locales.ForGuild(settings.GuildID), "announcement.payment.confirmed",
+				user.DiscordID, i18n.Money(change.Amount))
+			if err := notifier.SendChannelMessage(settings.AnnouncementChannelID, message); err != nil {
+				lastErr = err
+				continue
+			}
+			delivered++
+		}
+		if delivered == 0 {
+			return fmt.Errorf("nenhum anúncio enviado: %w", lastErr)
+		}
+		return nil
+	}
+}
+
+// NewPixMessageHandler edita a mensagem do QR Code de um PIX quando ele é
+// pago, expira ou falha. Registrado no outbox em
+// TopicTransactionStatusChanged
+func NewPixMessageHandler(
+	userRepo *repository.UserRepository,
+	walletRepo *repository.WalletRepository,
+	txRepo *repository.TransactionRepository,
+	notifier Notifier,
+	locales *LocaleService,
+) OutboxHandler {
+	return func(event repository.OutboxEvent) error {
+		var change repository.TransactionStatusChanged
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return fmt.Errorf("erro ao ler evento: %w", err)
+		}
+
+		if change.Type != repository.TypePix {
+			return nil
+		}
+		switch change.To {
+		case repository.StatusConfirmed, repository.StatusAwaitingApproval,
+			repository.StatusExpired, repository.StatusFailed:
+		default:
+			return nil
+		}
+
+		message, err := txRepo.FindDiscordMessage(change.TransactionID)
+		if err != nil {
+			return err
+		}
+		if message == nil {
+			return nil
+		}
+
+		// A mensagem fica no idioma de quem gerou o PIX, como foi enviada
+		locale := locales.ForGuild(message.GuildID)
+		wallet, err := walletRepo.FindByID(change.WalletID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar carteira: %w", err)
+		}
+		if wallet != nil {
+			user, err := userRepo.FindByID(wallet.UserID)
+			if err != nil {
+				return fmt.Errorf("erro ao buscar usuário: %w", err)
+			}
+			if user != nil {
+				locale = locales.ForMember(user.DiscordID, message.GuildID)
+			}
+		}
+
+		return notifier.UpdatePixMessage(*message, PixMessageUpdate{
+			TransactionID: change.TransactionID,
+			Amount:        change.Amount,
+			Status:        change.To,
+			Locale:        locale,
+		})
+	}
+}
+
+// NewPaymentReviewAlertHandler avisa os administradores por DM quando um PIX
+// é contestado (mediação ou chargeback) ou fica aguardando aprovação.
+// Registrado no outbox em TopicTransactionStatusChanged
+func NewPaymentReviewAlertHandler(
+	userRepo *repository.UserRepository,
+	walletRepo *repository.WalletRepository,
+	notifier Notifier,
+	locales *LocaleService,
+	adminIDs []string,
+) OutboxHandler {
+	return func(event repository.OutboxEvent) error {
+		var change repository.TransactionStatusChanged
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return fmt.Errorf("erro ao ler evento: %w", err)
+		}
+
+		var headline string
+		switch change.To {
+		case repository.StatusDisputed:
+			headline = "dm.review.disputed"
+		case repository.StatusReversed:
+			headline = "dm.review.reversed"
+		case repository.StatusAwaitingApproval:
+			headline = "dm.review.awaiting_approval"
+		default:
+			return nil
+		}
+		if len(adminIDs) == 0 {
+			return nil
+		}
+
+		var member *repository.User
+		wallet, err := walletRepo.FindByID(change.WalletID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar carteira: %w", err)
+		}
+		if wallet != nil {
+			if member, err = userRepo.FindByID(wallet.UserID); err != nil {
+				return fmt.Errorf("erro ao buscar usuário: %w", err)
+			}
+		}
+
+		// Um administrador sem DM aberta não impede o aviso aos demais; o
+		// evento só falha se ninguém recebeu
+		var lastErr error
+		delivered := 0
+		for _, adminID := range adminIDs {
+			locale := locales.ForUser(adminID)
+
+			memberText := i18n.T(locale, "dm.review.unknown_member", change.WalletID)
+			if member != nil {
+				memberText = fmt.Sprintf("%s (<@%s>)", member.Username, member.DiscordID)
+			}
+			message := i18n.T(locale, headline) + "\n" + i18n.T(locale, "dm.review.details",
+				memberText, change.TransactionID, i18n.Money(change.Amount), change.From, change.To)
+			if change.To == repository.StatusAwaitingApproval {
+				message += "\n" + i18n.T(locale, "dm.review.how_to_approve", change.TransactionID)
+			}
+
+			if err := notifier.SendDirectMessage(adminID, message); err != nil {
+				lastErr = err
+				continue
+			}
+			delivered++
+		}
+		if delivered == 0 {
+			return fmt.Errorf("nenhum administrador avisado: %w", lastErr)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+const (
+	outboxPollInterval = 2 * time.Second
+	outboxBatchSize    = 20
+	outboxLease        = 2 * time.Minute
+	outboxMaxAttempts  = 8
+	outboxBaseBackoff  = 30 * time.Second
+	outboxMaxBackoff   = time.Hour
+)
+
+var ErrOutboxEventNotFound = i18n.NewError("error.outbox_event_not_found")
+
+// ErrRecipientUnreachable indica um membro que não aceita mensagens diretas
+// do bot. Tentar de novo não adianta, então o handler que recebe esse erro
+// conta como tratado
+var ErrRecipientUnreachable = errors.New("membro não aceita mensagens diretas")
+
+// OutboxHandler trata um evento do outbox. A entrega é at-least-once: o
+// mesmo evento pode chegar mais de uma vez, então o handler deve tolerar
+// repetições
+type OutboxHandler func(event repository.OutboxEvent) error
+
+type namedOutboxHandler struct {
+	name   string
+	handle OutboxHandler
+}
+
+// OutboxService entrega os eventos do outbox aos handlers registrados por
+// tópico, com novas tentativas e backoff; depois de outboxMaxAttempts falhas
+// o evento fica DEAD até um administrador reenfileirá-lo
+type OutboxService struct {
+	outboxRepo *repository.OutboxRepository
+	handlers   map[string][]namedOutboxHandler
+}
+
+func NewOutboxService(outboxRepo *repository.OutboxRepository) *OutboxService {
+	return &OutboxService{
+		outboxRepo: outboxRepo,
+		handlers:   make(map[string][]namedOutboxHandler),
+	}
+}
+
+// Register deve ser chamado antes de Start. O nome identifica o handler em
+// Handled, então não pode mudar entre versões nem repetir no mesmo tópico
+func (s *OutboxService) Register(topic, name string, handler OutboxHandler) {
+	s.handlers[topic] = append(s.handlers[topic], namedOutboxHandler{name: name, handle: handler})
+}
+
+// Start entrega eventos periodicamente até o contexto ser cancelado
+func (s *OutboxService) Start(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Esvazia o que estiver pronto antes de esperar o próximo ciclo
+		for {
+			n, err := s.DispatchOnce()
+			if err != nil {
+				log.Printf("Erro ao entregar eventos do outbox: %v", err)
+			}
+			if n < outboxBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce entrega um lote de eventos e devolve quantos foram reservados
+func (s *OutboxService) DispatchOnce() (int, error) {
+	events, err := s.outboxRepo.Claim(outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := s.deliver(event); err != nil {
+			dead := event.Attempts >= outboxMaxAttempts
+			log.Printf("Erro no evento %d do outbox (%s, tentativa %d): %v", event.ID, event.Topic, event.Attempts, err)
+			if dead {
+				log.Printf("⚠️ Evento %d do outbox desistido após %d tentativas", event.ID, event.Attempts)
+			}
+			if err := s.outboxRepo.MarkFailed(event.ID, err.Error(), backoff(event.Attempts, outboxBaseBackoff, outboxMaxBackoff), dead); err != nil {
+				return len(events), err
+			}
+			continue
+		}
+
+		if err := s.outboxRepo.MarkDone(event.ID); err != nil {
+			return len(events), err
+		}
+	}
+
+	return len(events), nil
+}
+
+// deliver roda os handlers do tópico que ainda não trataram o evento. Cada
+// um que termina fica gravado em Handled, então a falha de um não faz os
+// outros repetirem DMs e avisos na próxima tentativa
+func (s *OutboxService) deliver(event repository.OutboxEvent) error {
+	var failures []string
+	for _, handler := range s.handlers[event.Topic] {
+		if slices.Contains(event.Handled, handler.name) {
+			continue
+		}
+
+		err := runOutboxHandler(handler.handle, event)
+		if errors.Is(err, ErrRecipientUnreachable) {
+			log.Printf("Evento %d do outbox: %s não entregue e não será repetido: %v", event.ID, handler.name, err)
+			err = nil
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", handler.name, err))
+			continue
+		}
+
+		if err := s.outboxRepo.MarkHandled(event.ID, handler.name); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func runOutboxHandler(handler OutboxHandler, event repository.OutboxEvent) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic no handler: %v", p)
+		}
+	}()
+	return handler(event)
+}
+
+// backoff dobra a espera a cada tentativa a partir de base, até max. Vale
+// para o outbox (30s, 1min, 2min...) e para o inbox de webhooks (15s, 30s...)
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// ParseOutboxFilter aceita stuck (padrão), pending, dead ou done
+func ParseOutboxFilter(status string) (repository.OutboxFilter, error) {
+	switch strings.ToLower(status) {
+	case "", "stuck":
+		return repository.OutboxFilter{Stuck: true}, nil
+	case "pending":
+		return repository.OutboxFilter{Status: repository.OutboxPending}, nil
+	case "dead":
+		return repository.OutboxFilter{Status: repository.OutboxDead}, nil
+	case "done":
+		return repository.OutboxFilter{Status: repository.OutboxDone}, nil
+	}
+	return repository.OutboxFilter{}, i18n.NewError("error.invalid_status", status, "stuck, pending, dead, done")
+}
+
+// List lista os eventos do outbox para a visão administrativa
+func (s *OutboxService) List(filter repository.OutboxFilter) ([]repository.OutboxEvent, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	return s.outboxRepo.List(filter)
+}
+
+// Retry devolve um evento para a fila com as tentativas zeradas
+func (s *OutboxService) Retry(id int64) (*repository.OutboxEvent, error) {
+	ok, err := s.outboxRepo.Requeue(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrOutboxEventNotFound
+	}
+	return s.outboxRepo.FindByID(id)
+}
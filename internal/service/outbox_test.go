@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts, 30*time.Second, time.Hour); got != tt.want {
+			t.Errorf("backoff(%d) = %s, quer %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliverSkipsHandledAndCollectsFailures(t *testing.T) {
+	s := NewOutboxService(nil)
+	var ran []string
+	s.Register("topico", "feito", func(repository.OutboxEvent) error {
+		ran = append(ran, "feito")
+		return nil
+	})
+	s.Register("topico", "falha", func(repository.OutboxEvent) error {
+		ran = append(ran, "falha")
+		return errors.New("discord fora do ar")
+	})
+	s.Register("topico", "panico", func(repository.OutboxEvent) error {
+		ran = append(ran, "panico")
+		panic("nil map")
+	})
+
+	err := s.deliver(repository.OutboxEvent{ID: 1, Topic: "topico", Handled: []string{"feito"}})
+	if err == nil {
+		t.Fatal("deliver = nil, quer as falhas")
+	}
+	if want := "falha: discord fora do ar; panico: panic no handler: nil map"; err.Error() != want {
+		t.Errorf("deliver = %q, quer %q", err, want)
+	}
+	if !slices.Equal(ran, []string{"falha", "panico"}) {
+		t.Errorf("handlers rodados = %v, quer [falha panico]", ran)
+	}
+}
+
+func TestParseOutboxFilter(t *testing.T) {
+	tests := []struct {
+		status string
+		want   repository.OutboxFilter
+	}{
+		{"", repository.OutboxFilter{Stuck: true}},
+		{"stuck", repository.OutboxFilter{Stuck: true}},
+		{"PENDING", repository.OutboxFilter{Status: repository.OutboxPending}},
+		{"dead", repository.OutboxFilter{Status: repository.OutboxDead}},
+		{"done", repository.OutboxFilter{Status: repository.OutboxDone}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOutboxFilter(tt.status)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseOutboxFilter(%q) = %+v, %v; quer %+v", tt.status, got, err, tt.want)
+		}
+	}
+	if _, err := ParseOutboxFilter("sumido"); err == nil {
+		t.Error("ParseOutboxFilter(sumido) aceitou um status inválido")
+	}
+}
@@ -1,8 +1,15 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
 
+	"github.com/mateus/familia-steam/internal/i18n"
 	"github.com/mateus/familia-steam/internal/mercadopago"
 	"github.com/mateus/familia-steam/internal/repository"
 )
@@ -12,6 +19,19 @@ type PaymentService struct {
 	txRepo     *repository.TransactionRepository
 	userRepo   *repository.UserRepository
 	walletRepo *repository.WalletRepository
+	limits     PaymentLimits
+}
+
+// PaymentLimits restringe a criação de PIX. Zero desliga o limite
+// correspondente
+type PaymentLimits struct {
+	MinAmount float64
+	MaxAmount float64
+
+	// MaxPending é quantos PIX pendentes criados nos últimos PendingWindow
+	// uma carteira pode ter; QR codes mais antigos já expiraram no Mercado Pago
+	MaxPending    int
+	PendingWindow time.Duration
 }
 
 func NewPaymentService(
@@ -19,30 +39,75 @@ func NewPaymentService(
 	txRepo *repository.TransactionRepository,
 	userRepo *repository.UserRepository,
 	walletRepo *repository.WalletRepository,
+	limits PaymentLimits,
 ) *PaymentService {
 	return &PaymentService{
 		mpClient:   mpClient,
 		txRepo:     txRepo,
 		userRepo:   userRepo,
 		walletRepo: walletRepo,
+		limits:     limits,
 	}
 }
 
+var (
+	ErrIdempotencyKeyReused = i18n.NewError("error.idempotency_key_reused")
+	ErrAmountOutOfRange     = i18n.NewError("error.amount_out_of_range")
+	ErrTooManyPending       = i18n.NewError("error.too_many_pending")
+	ErrPaymentNotFound      = i18n.NewError("error.payment_not_found")
+	ErrMessageAttached      = i18n.NewError("error.payment_message_attached")
+	ErrNotAwaitingApproval  = i18n.NewError("error.payment_not_awaiting_approval")
+)
+
+func (s *PaymentService) Limits() PaymentLimits {
+	return s.limits
+}
+
+func (s *PaymentService) MercadoPagoState() mercadopago.BreakerState {
+	return s.mpClient.BreakerState()
+}
+
 type CreatePixPaymentRequest struct {
-	DiscordID string
-	Username  string
-	Amount    float64
+	DiscordID      string
+	Username       string
+	Amount         float64
+	IdempotencyKey string
+
+	// SkipLimits libera cobranças geradas pelo próprio sistema, como a da
+	// mensalidade, dos limites de valor e de PIX pendentes
+	SkipLimits bool
 }
 
 type CreatePixPaymentResponse struct {
-	TransactionID     int64   `json:"transaction_id"`
-	Amount            float64 `json:"amount"`
-	QRCode            string  `json:"qr_code"`
-	QRCodeBase64      string  `json:"qr_code_base64"`
-	ExternalReference string  `json:"external_reference"`
+	TransactionID     int64
+	Amount            float64
+	QRCode            string
+	QRCodeBase64      string
+	ExternalReference string
+	Replayed          bool
+
+	// RequiresApproval indica que o usuário está sinalizado por uma
+	// contestação e o valor só entra no saldo depois de aprovado
+	RequiresApproval bool
 }
 
-func (s *PaymentService) CreatePixPayment(req CreatePixPaymentRequest) (*CreatePixPaymentResponse, error) {
+func (s *PaymentService) CreatePixPayment(ctx context.Context, req CreatePixPaymentRequest) (*CreatePixPaymentResponse, error) {
+	if req.IdempotencyKey != "" {
+		existing, err := s.txRepo.FindByIdempotencyKey(req.IdempotencyKey)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar transação: %w", err)
+		}
+		if existing != nil {
+			return s.replayPixPayment(existing, req)
+		}
+	}
+
+	if !req.SkipLimits {
+		if err := s.checkAmount(req.Amount); err != nil {
+			return nil, err
+		}
+	}
+
 	user, err := s.userRepo.FindOrCreate(req.DiscordID, req.Username)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar/criar usuário: %w", err)
@@ -53,8 +118,22 @@ func (s *PaymentService) CreatePixPayment(req CreatePixPaymentRequest) (*CreateP
 		return nil, fmt.Errorf("erro ao buscar/criar carteira: %w", err)
 	}
 
+	if s.limits.MaxPending > 0 && !req.SkipLimits {
+		var since time.Time
+		if s.limits.PendingWindow > 0 {
+			since = time.Now().Add(-s.limits.PendingWindow).UTC()
+		}
+		pending, err := s.txRepo.CountPending(wallet.ID, since)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao contar pagamentos pendentes: %w", err)
+		}
+		if pending >= s.limits.MaxPending {
+			return nil, i18n.Wrap(ErrTooManyPending, "error.too_many_pending_count", pending, s.limits.MaxPending)
+		}
+	}
+
 	description := fmt.Sprintf("Vaquinha - %s - R$ %.2f", req.Username, req.Amount)
-	payment, err := s.mpClient.CreatePixPayment(req.Amount, description)
+	payment, err := s.mpClient.CreatePixPayment(ctx, req.Amount, description, req.IdempotencyKey)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao criar pagamento: %w", err)
 	}
@@ -71,7 +150,18 @@ func (s *PaymentService) CreatePixPayment(req CreatePixPaymentRequest) (*CreateP
 		"status":         payment.Status,
 	}
 
-	transaction, err := s.txRepo.Create(wallet.ID, req.Amount, externalRef, paymentData)
+	transaction, err := s.txRepo.Create(wallet.ID, req.Amount, externalRef, req.IdempotencyKey, paymentData)
+	if errors.Is(err, repository.ErrDuplicateTransaction) && req.IdempotencyKey != "" {
+		// Outra requisição com a mesma chave venceu a corrida; o Mercado Pago
+		// devolveu o mesmo pagamento para ambas, então basta repetir a resposta.
+		existing, findErr := s.txRepo.FindByIdempotencyKey(req.IdempotencyKey)
+		if findErr != nil {
+			return nil, fmt.Errorf("erro ao buscar transação: %w", findErr)
+		}
+		if existing != nil {
+			return s.replayPixPayment(existing, req)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("erro ao criar transação: %w", err)
 	}
@@ -82,41 +172,325 @@ func (s *PaymentService) CreatePixPayment(req CreatePixPaymentRequest) (*CreateP
 		QRCode:            payment.PointOfInteraction.TransactionData.QRCode,
 		QRCodeBase64:      payment.PointOfInteraction.TransactionData.QRCodeBase64,
 		ExternalReference: externalRef,
+		RequiresApproval:  user.OnPaymentHold(),
+	}, nil
+}
+
+func (s *PaymentService) checkAmount(amount float64) error {
+	cents := math.Round(amount * 100)
+	if s.limits.MinAmount > 0 && cents < math.Round(s.limits.MinAmount*100) {
+		return i18n.Wrap(ErrAmountOutOfRange, "error.amount_below_min", i18n.Money(s.limits.MinAmount))
+	}
+	if s.limits.MaxAmount > 0 && cents > math.Round(s.limits.MaxAmount*100) {
+		return i18n.Wrap(ErrAmountOutOfRange, "error.amount_above_max", i18n.Money(s.limits.MaxAmount))
+	}
+	return nil
+}
+
+func (s *PaymentService) replayPixPayment(transaction *repository.Transaction, req CreatePixPaymentRequest) (*CreatePixPaymentResponse, error) {
+	wallet, err := s.walletRepo.FindByID(transaction.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar carteira: %w", err)
+	}
+
+	user, err := s.userRepo.FindByID(wallet.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+
+	if user.DiscordID != req.DiscordID || math.Round(transaction.Amount*100) != math.Round(req.Amount*100) {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	qrCode, _ := transaction.PaymentData["qr_code"].(string)
+	qrCodeBase64, _ := transaction.PaymentData["qr_code_base64"].(string)
+
+	return &CreatePixPaymentResponse{
+		TransactionID:     transaction.ID,
+		Amount:            transaction.Amount,
+		QRCode:            qrCode,
+		QRCodeBase64:      qrCodeBase64,
+		ExternalReference: transaction.ExternalReference,
+		Replayed:          true,
+		RequiresApproval:  user.OnPaymentHold(),
 	}, nil
 }
 
-type PaymentConfirmedData struct {
-	DiscordID string
-	Username  string
-	Amount    float64
+// statusFromMercadoPago converte o status de um pagamento no Mercado Pago
+// para o status da transação local. Um PIX não pago no prazo vem como
+// cancelled com o detalhe expired
+func statusFromMercadoPago(status, detail string) repository.TransactionStatus {
+	switch status {
+	case "approved":
+		return repository.StatusConfirmed
+	case "in_mediation":
+		return repository.StatusDisputed
+	case "charged_back":
+		return repository.StatusReversed
+	case "cancelled":
+		if detail == "expired" {
+			return repository.StatusExpired
+		}
+		return repository.StatusFailed
+	case "rejected", "refunded":
+		return repository.StatusFailed
+	default:
+		return repository.StatusPending
+	}
 }
 
-func (s *PaymentService) ConfirmPayment(externalRef string) (*PaymentConfirmedData, error) {
+// errUnknownPayment indica um pagamento do Mercado Pago sem transação da
+// vaquinha
+var errUnknownPayment = errors.New("transação não encontrada")
+
+// SyncPayment consulta o pagamento no Mercado Pago e alinha o status da
+// transação local com ele. Uma contestação sinaliza o dono da carteira, e um
+// pagamento novo de um usuário sinalizado fica aguardando aprovação em vez
+// de entrar no saldo
+func (s *PaymentService) SyncPayment(externalRef string, meta repository.AuditMeta) (*repository.Transaction, error) {
 	transaction, err := s.txRepo.FindByExternalReference(externalRef)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar transação: %w", err)
 	}
 	if transaction == nil {
-		return nil, fmt.Errorf("transação não encontrada: %s", externalRef)
+		return nil, fmt.Errorf("%w: %s", errUnknownPayment, externalRef)
 	}
 
-	if err := s.txRepo.UpdateStatus(transaction.ID, repository.StatusConfirmed); err != nil {
-		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	paymentID, err := strconv.ParseInt(externalRef, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("referência externa inválida: %s", externalRef)
+	}
+
+	payment, err := s.mpClient.GetPayment(context.Background(), paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar pagamento: %w", err)
+	}
+
+	status := statusFromMercadoPago(payment.Status, payment.StatusDetail)
+	if status != transaction.Status {
+		if status, err = s.applyHold(transaction, status, meta); err != nil {
+			return nil, err
+		}
+	}
+	if status != transaction.Status {
+		if err := s.txRepo.UpdateStatus(transaction.ID, status, meta); err != nil {
+			return nil, fmt.Errorf("erro ao atualizar transação: %w", err)
+		}
+	}
+
+	return s.txRepo.FindByID(transaction.ID)
+}
+
+// applyHold ajusta o novo status de uma transação à sinalização do dono:
+// contestar um pagamento sinaliza o usuário, e enquanto ele estiver
+// sinalizado um pagamento novo aprovado fica AWAITING_APPROVAL. Um PIX que
+// sai da mediação volta direto ao saldo, porque não é um pagamento novo.
+// A sinalização é gravada antes do status, então uma nova tentativa depois de
+// uma falha não a perde
+func (s *PaymentService) applyHold(transaction *repository.Transaction, status repository.TransactionStatus, meta repository.AuditMeta) (repository.TransactionStatus, error) {
+	switch status {
+	case repository.StatusConfirmed:
+		if transaction.Status == repository.StatusAwaitingApproval {
+			return transaction.Status, nil
+		}
+		switch transaction.Status {
+		case repository.StatusPending, repository.StatusFailed, repository.StatusExpired:
+		default:
+			return status, nil
+		}
+
+		user, err := s.owner(transaction)
+		if err != nil {
+			return "", err
+		}
+		if user.OnPaymentHold() {
+			return repository.StatusAwaitingApproval, nil
+		}
+
+	case repository.StatusDisputed, repository.StatusReversed:
+		user, err := s.owner(transaction)
+		if err != nil {
+			return "", err
+		}
+
+		reason := fmt.Sprintf("chargeback no PIX #%d (pagamento %s)", transaction.ID, transaction.ExternalReference)
+		if status == repository.StatusDisputed {
+			reason = fmt.Sprintf("PIX #%d (pagamento %s) em mediação", transaction.ID, transaction.ExternalReference)
+		}
+		if _, err := s.userRepo.SetPaymentHold(user.ID, reason, meta); err != nil {
+			return "", err
+		}
 	}
 
+	return status, nil
+}
+
+func (s *PaymentService) owner(transaction *repository.Transaction) (*repository.User, error) {
 	wallet, err := s.walletRepo.FindByID(transaction.WalletID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar wallet: %w", err)
+		return nil, fmt.Errorf("erro ao buscar carteira: %w", err)
+	}
+	if wallet == nil {
+		return nil, fmt.Errorf("carteira não encontrada: %d", transaction.WalletID)
 	}
 
 	user, err := s.userRepo.FindByID(wallet.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
 	}
+	if user == nil {
+		return nil, fmt.Errorf("usuário não encontrado: %d", wallet.UserID)
+	}
+	return user, nil
+}
 
-	return &PaymentConfirmedData{
-		DiscordID: user.DiscordID,
-		Username:  user.Username,
-		Amount:    transaction.Amount,
-	}, nil
+// SyncChargeback busca a contestação no Mercado Pago e sincroniza os
+// pagamentos dela que são da vaquinha; os demais são ignorados
+func (s *PaymentService) SyncChargeback(chargebackID string, meta repository.AuditMeta) ([]*repository.Transaction, error) {
+	chargeback, err := s.mpClient.GetChargeback(context.Background(), chargebackID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar contestação: %w", err)
+	}
+
+	var updated []*repository.Transaction
+	for _, paymentID := range chargeback.Payments {
+		externalRef := strconv.FormatInt(paymentID, 10)
+
+		transaction, err := s.txRepo.FindByExternalReference(externalRef)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar transação: %w", err)
+		}
+		if transaction == nil {
+			continue
+		}
+
+		synced, err := s.SyncPayment(externalRef, meta)
+		if err != nil {
+			return nil, err
+		}
+		updated = append(updated, synced)
+	}
+
+	return updated, nil
+}
+
+// FindTransaction devolve a transação, ou nil se ela não existir
+func (s *PaymentService) FindTransaction(id int64) (*repository.Transaction, error) {
+	return s.txRepo.FindByID(id)
+}
+
+// pollBatchSize é quantos PIX pendentes cada rodada do polling consulta
+const pollBatchSize = 50
+
+// AttachDiscordMessage registra a mensagem do bot com o QR Code do PIX, para
+// que ela seja editada quando o pagamento mudar de status
+func (s *PaymentService) AttachDiscordMessage(transactionID int64, discordID string, message repository.DiscordMessage) error {
+	transaction, err := s.txRepo.FindByID(transactionID)
+	if err != nil {
+		return err
+	}
+	if transaction == nil || transaction.Type != repository.TypePix {
+		return ErrPaymentNotFound
+	}
+
+	// Só o dono do PIX associa a mensagem; para os demais ele não existe
+	user, err := s.owner(transaction)
+	if err != nil {
+		return err
+	}
+	if user.DiscordID != discordID {
+		return ErrPaymentNotFound
+	}
+
+	attached, err := s.txRepo.AttachDiscordMessage(transactionID, message)
+	if err != nil {
+		return err
+	}
+	if !attached {
+		return ErrMessageAttached
+	}
+	return nil
+}
+
+// ApprovePayment libera um PIX retido em AWAITING_APPROVAL porque o dono
+// está sinalizado: ele fica CONFIRMED e entra no saldo
+func (s *PaymentService) ApprovePayment(transactionID int64, meta repository.AuditMeta) (*repository.Transaction, error) {
+	transaction, err := s.txRepo.FindByID(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+	if transaction == nil || transaction.Type != repository.TypePix {
+		return nil, ErrPaymentNotFound
+	}
+	if transaction.Status != repository.StatusAwaitingApproval {
+		return nil, ErrNotAwaitingApproval
+	}
+
+	if err := s.txRepo.UpdateStatus(transaction.ID, repository.StatusConfirmed, meta); err != nil {
+		return nil, fmt.Errorf("erro ao aprovar pagamento: %w", err)
+	}
+	return s.txRepo.FindByID(transaction.ID)
+}
+
+// StartPolling consulta os PIX pendentes no Mercado Pago a cada interval,
+// para o caso de um webhook não chegar, até o contexto ser cancelado.
+// interval zero desliga o polling
+func (s *PaymentService) StartPolling(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.PollPending(time.Now()); err != nil {
+			log.Printf("Erro ao consultar PIX pendentes: %v", err)
+		}
+	}
+}
+
+// PollPending sincroniza um lote de PIX pendentes com o Mercado Pago. Os que
+// seguem pendentes depois de PendingWindow expiram: o QR Code já não vale
+// mais
+func (s *PaymentService) PollPending(now time.Time) error {
+	pending, err := s.txRepo.ClaimPendingPix(pollBatchSize)
+	if err != nil {
+		return err
+	}
+
+	meta := repository.AuditMeta{Actor: ProviderMercadoPago, Source: repository.SourcePoll}
+	for _, transaction := range pending {
+		synced, err := s.SyncPayment(transaction.ExternalReference, meta)
+		if err != nil {
+			// Sem resposta do Mercado Pago não dá para saber se foi pago;
+			// a próxima rodada tenta de novo
+			log.Printf("Erro ao sincronizar PIX #%d: %v", transaction.ID, err)
+			continue
+		}
+
+		if !shouldExpire(synced, s.limits.PendingWindow, now) {
+			continue
+		}
+
+		// Só expira se continuar PENDING: um webhook pode ter confirmado o
+		// pagamento entre a sincronização e aqui
+		expired := meta
+		expired.Reason = "QR Code expirado sem pagamento"
+		if _, err := s.txRepo.TransitionStatus(synced.ID, repository.StatusPending, repository.StatusExpired, expired); err != nil {
+			log.Printf("Erro ao expirar PIX #%d: %v", synced.ID, err)
+		}
+	}
+	return nil
+}
+
+// shouldExpire diz se um PIX ainda pendente passou do prazo de pagamento
+func shouldExpire(transaction *repository.Transaction, window time.Duration, now time.Time) bool {
+	return transaction.Status == repository.StatusPending && window > 0 && now.Sub(transaction.CreatedAt) >= window
 }
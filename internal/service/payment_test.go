@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+func TestShouldExpire(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	window := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		status  repository.TransactionStatus
+		created time.Time
+		window  time.Duration
+		want    bool
+	}{
+		{"pendente dentro do prazo", repository.StatusPending, now.Add(-time.Hour), window, false},
+		{"pendente no limite do prazo", repository.StatusPending, now.Add(-window), window, true},
+		{"pendente depois do prazo", repository.StatusPending, now.Add(-48 * time.Hour), window, true},
+		{"confirmado depois do prazo", repository.StatusConfirmed, now.Add(-48 * time.Hour), window, false},
+		{"expirado depois do prazo", repository.StatusExpired, now.Add(-48 * time.Hour), window, false},
+		{"sem prazo configurado", repository.StatusPending, now.Add(-48 * time.Hour), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := &repository.Transaction{Status: tt.status, CreatedAt: tt.created}
+			if got := shouldExpire(transaction, tt.window, now); got != tt.want {
+				t.Errorf("shouldExpire = %v, quer %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusFromMercadoPago(t *testing.T) {
+	tests := []struct {
+		status, detail string
+		want           repository.TransactionStatus
+	}{
+		{"approved", "accredited", repository.StatusConfirmed},
+		{"pending", "pending_waiting_transfer", repository.StatusPending},
+		{"in_process", "", repository.StatusPending},
+		{"in_mediation", "", repository.StatusDisputed},
+		{"charged_back", "settled", repository.StatusReversed},
+		{"cancelled", "expired", repository.StatusExpired},
+		{"cancelled", "by_collector", repository.StatusFailed},
+		{"rejected", "cc_rejected_other_reason", repository.StatusFailed},
+		{"refunded", "", repository.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		if got := statusFromMercadoPago(tt.status, tt.detail); got != tt.want {
+			t.Errorf("statusFromMercadoPago(%q, %q) = %s, quer %s", tt.status, tt.detail, got, tt.want)
+		}
+	}
+}
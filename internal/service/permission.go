@@ -0,0 +1,125 @@
+package service
+
+import (
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+var (
+	ErrInvalidCapability = i18n.NewError("error.invalid_capability")
+	ErrGuildRequired     = i18n.NewError("error.guild_required")
+	ErrRoleRequired      = i18n.NewError("error.role_required")
+)
+
+// Capability é uma operação administrativa que pode ser concedida a um cargo
+type Capability string
+
+const (
+	CapabilityAudit       Capability = "audit"       // ver a auditoria
+	CapabilityExport      Capability = "export"      // exportar o extrato
+	CapabilityPurchase    Capability = "purchase"    // registrar e consultar compras
+	CapabilityAdjust      Capability = "adjust"      // creditar e debitar saldo manualmente
+	CapabilityRefund      Capability = "refund"      // contestações: aprovar PIX retidos
+	CapabilityOperations  Capability = "operations"  // outbox e inbox de webhooks
+	CapabilitySettings    Capability = "settings"    // configurações do servidor
+	CapabilityPermissions Capability = "permissions" // conceder e retirar capacidades
+)
+
+// Capabilities lista todas as capacidades, na ordem mostrada aos usuários
+var Capabilities = []Capability{
+	CapabilityAudit,
+	CapabilityExport,
+	CapabilityPurchase,
+	CapabilityAdjust,
+	CapabilityRefund,
+	CapabilityOperations,
+	CapabilitySettings,
+	CapabilityPermissions,
+}
+
+func ParseCapability(s string) (Capability, error) {
+	for _, c := range Capabilities {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	return "", ErrInvalidCapability
+}
+
+// Caller é o membro do Discord em nome de quem uma operação é feita, com os
+// cargos que ele tem no servidor
+type Caller struct {
+	DiscordID string
+	GuildID   string
+	RoleIDs   []string
+}
+
+type PermissionService struct {
+	permissionRepo *repository.PermissionRepository
+	owners         map[string]bool
+}
+
+// NewPermissionService recebe os donos (ADMIN_DISCORD_IDS), que têm todas as
+// capacidades em qualquer servidor e concedem as primeiras
+func NewPermissionService(permissionRepo *repository.PermissionRepository, ownerIDs []string) *PermissionService {
+	owners := make(map[string]bool, len(ownerIDs))
+	for _, id := range ownerIDs {
+		owners[id] = true
+	}
+	return &PermissionService{permissionRepo: permissionRepo, owners: owners}
+}
+
+func (s *PermissionService) IsOwner(discordID string) bool {
+	return s.owners[discordID]
+}
+
+// Allowed indica se o membro pode executar a operação. Fora de um servidor
+// só os donos podem
+func (s *PermissionService) Allowed(caller Caller, capability Capability) (bool, error) {
+	if s.IsOwner(caller.DiscordID) {
+		return true, nil
+	}
+	if caller.GuildID == "" {
+		return false, nil
+	}
+	return s.permissionRepo.HasAny(caller.GuildID, caller.RoleIDs, string(capability))
+}
+
+func (s *PermissionService) List(guildID string) ([]repository.RolePermission, error) {
+	if guildID == "" {
+		return nil, ErrGuildRequired
+	}
+	return s.permissionRepo.List(guildID)
+}
+
+// Grant devolve false se o cargo já tinha a capacidade
+func (s *PermissionService) Grant(guildID, roleID string, capability Capability, meta repository.AuditMeta) (bool, error) {
+	if err := validatePermission(guildID, roleID, capability); err != nil {
+		return false, err
+	}
+
+	granted, err := s.permissionRepo.Grant(guildID, roleID, string(capability), meta)
+	if err != nil {
+		return false, err
+	}
+	return granted != nil, nil
+}
+
+// Revoke devolve false se o cargo não tinha a capacidade
+func (s *PermissionService) Revoke(guildID, roleID string, capability Capability, meta repository.AuditMeta) (bool, error) {
+	if err := validatePermission(guildID, roleID, capability); err != nil {
+		return false, err
+	}
+	return s.permissionRepo.Revoke(guildID, roleID, string(capability), meta)
+}
+
+func validatePermission(guildID, roleID string, capability Capability) error {
+	if guildID == "" {
+		return ErrGuildRequired
+	}
+	if roleID == "" {
+		return ErrRoleRequired
+	}
+	_, err := ParseCapability(string(capability))
+	return err
+}
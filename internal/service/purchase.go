@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+var (
+	ErrInsufficientFunds = i18n.NewError("error.fund_insufficient")
+	ErrTitleRequired     = i18n.NewError("error.title_required")
+	ErrInvalidSplitMode  = i18n.NewError("error.invalid_split_mode")
+	ErrPurchaseNotFound  = i18n.NewError("error.purchase_not_found")
+)
+
+// ParseSplitMode aceita os nomes usados na configuração, na CLI e no bot
+func ParseSplitMode(s string) (repository.SplitMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "pro_rata", "proporcional":
+		return repository.SplitProRata, nil
+	case "equal", "igual":
+		return repository.SplitEqual, nil
+	default:
+		return "", ErrInvalidSplitMode
+	}
+}
+
+type PurchaseService struct {
+	purchaseRepo *repository.PurchaseRepository
+	defaultMode  repository.SplitMode
+}
+
+func NewPurchaseService(
+	purchaseRepo *repository.PurchaseRepository,
+	defaultMode repository.SplitMode,
+) *PurchaseService {
+	return &PurchaseService{
+		purchaseRepo: purchaseRepo,
+		defaultMode:  defaultMode,
+	}
+}
+
+// RecordPurchase registra um jogo comprado com o dinheiro da vaquinha e
+// desconta de cada membro a sua parte. Sem modo informado, vale o padrão
+// da configuração.
+func (s *PurchaseService) RecordPurchase(title string, amount float64, mode repository.SplitMode, meta repository.AuditMeta) (*repository.Purchase, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, ErrTitleRequired
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if mode == "" {
+		mode = s.defaultMode
+	}
+
+	var split func(int64, []int64) []int64
+	switch mode {
+	case repository.SplitProRata:
+		split = splitProRata
+	case repository.SplitEqual:
+		split = splitEqual
+	default:
+		return nil, ErrInvalidSplitMode
+	}
+
+	allocate := func(amount float64, balances []repository.AvailableBalance) ([]repository.PurchaseShare, error) {
+		cost := toCents(amount)
+
+		var (
+			members   []repository.AvailableBalance
+			available []int64
+			total     int64
+		)
+		for _, b := range balances {
+			if cents := toCents(b.Available()); cents > 0 {
+				members = append(members, b)
+				available = append(available, cents)
+				total += cents
+			}
+		}
+		if total < cost {
+			return nil, ErrInsufficientFunds
+		}
+
+		var shares []repository.PurchaseShare
+		for i, cents := range split(cost, available) {
+			if cents == 0 {
+				continue
+			}
+			shares = append(shares, repository.PurchaseShare{
+				WalletID:        members[i].WalletID,
+				DiscordID:       members[i].DiscordID,
+				Username:        members[i].Username,
+				Amount:          fromCents(cents),
+				AvailableBefore: members[i].Available(),
+			})
+		}
+		return shares, nil
+	}
+
+	purchase, err := s.purchaseRepo.Create(title, amount, mode, allocate, meta)
+	if err != nil {
+		if errors.Is(err, ErrInsufficientFunds) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao registrar compra: %w", err)
+	}
+
+	return purchase, nil
+}
+
+func (s *PurchaseService) Get(id int64) (*repository.Purchase, error) {
+	purchase, err := s.purchaseRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar compra: %w", err)
+	}
+	if purchase == nil {
+		return nil, ErrPurchaseNotFound
+	}
+	return purchase, nil
+}
+
+func (s *PurchaseService) List(limit int) ([]repository.Purchase, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	purchases, err := s.purchaseRepo.List(limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar compras: %w", err)
+	}
+	return purchases, nil
+}
+
+func (s *PurchaseService) ListAvailableBalances() ([]repository.AvailableBalance, error) {
+	balances, err := s.purchaseRepo.ListAvailableBalances()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar saldos disponíveis: %w", err)
+	}
+	return balances, nil
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func fromCents(c int64) float64 {
+	return float64(c) / 100
+}
+
+// splitProRata divide cost proporcionalmente a available. Os centavos que
+// sobram do arredondamento vão para as maiores frações (maior resto), então
+// a soma bate exatamente com cost e ninguém paga mais do que tem. Se o saldo
+// somado não cobre cost, cada um paga tudo o que tem.
+func splitProRata(cost int64, available []int64) []int64 {
+	var total int64
+	for _, a := range available {
+		total += a
+	}
+	if cost >= total {
+		return append([]int64(nil), available...)
+	}
+
+	shares := make([]int64, len(available))
+	remainders := make([]int64, len(available))
+	var assigned int64
+	for i, a := range available {
+		shares[i] = cost * a / total
+		remainders[i] = cost * a % total
+		assigned += shares[i]
+	}
+
+	order := make([]int, len(available))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		return remainders[order[x]] > remainders[order[y]]
+	})
+
+	for _, i := range order[:cost-assigned] {
+		shares[i]++
+	}
+	return shares
+}
+
+// splitEqual divide cost em partes iguais; quem não tem saldo para a parte
+// inteira paga o que tem e a diferença é redividida entre os demais. Se o
+// saldo somado não cobre cost, cada um paga tudo o que tem.
+func splitEqual(cost int64, available []int64) []int64 {
+	order := make([]int, len(available))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		return available[order[x]] < available[order[y]]
+	})
+
+	shares := make([]int64, len(available))
+	remaining := cost
+	for pos, i := range order {
+		left := int64(len(order) - pos)
+		per := remaining / left
+		if available[i] <= per {
+			shares[i] = available[i]
+			remaining -= available[i]
+			continue
+		}
+
+		// Daqui em diante todos têm mais que a parte; os centavos que não
+		// dividem ficam com quem tem mais saldo (final da ordem)
+		extra := remaining % left
+		for k, j := range order[pos:] {
+			shares[j] = per
+			if int64(k) >= left-extra {
+				shares[j]++
+			}
+		}
+		break
+	}
+	return shares
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+type splitCase struct {
+	name      string
+	cost      int64
+	available []int64
+	want      []int64
+}
+
+func TestSplitProRata(t *testing.T) {
+	testSplit(t, "splitProRata", splitProRata, []splitCase{
+		{"proporcional exato", 300, []int64{100, 200, 300}, []int64{50, 100, 150}},
+		{"centavo que sobra vai para o primeiro empate", 100, []int64{100, 100, 100}, []int64{34, 33, 33}},
+		{"centavo que sobra vai para o maior resto", 7, []int64{5, 3, 2}, []int64{4, 2, 1}},
+		{"saldo zero não paga", 50, []int64{100, 0, 100}, []int64{25, 0, 25}},
+		{"todos sem saldo", 50, []int64{0, 0}, []int64{0, 0}},
+		{"saldo não cobre o custo", 500, []int64{100, 200}, []int64{100, 200}},
+		{"saldo igual ao custo", 300, []int64{100, 200}, []int64{100, 200}},
+		{"um membro só", 99, []int64{150}, []int64{99}},
+		{"custo zero", 0, []int64{100, 200}, []int64{0, 0}},
+	})
+}
+
+func TestSplitEqual(t *testing.T) {
+	testSplit(t, "splitEqual", splitEqual, []splitCase{
+		{"partes iguais", 300, []int64{200, 200, 200}, []int64{100, 100, 100}},
+		{"centavo que sobra vai para quem tem mais", 100, []int64{50, 60, 70}, []int64{33, 33, 34}},
+		{"quem tem pouco paga o que tem", 100, []int64{10, 100, 100}, []int64{10, 45, 45}},
+		{"diferença redividida com resto", 101, []int64{100, 40}, []int64{61, 40}},
+		{"saldo zero não paga", 100, []int64{0, 100, 100}, []int64{0, 50, 50}},
+		{"todos sem saldo", 10, []int64{0, 0}, []int64{0, 0}},
+		{"saldo não cobre o custo", 500, []int64{100, 200}, []int64{100, 200}},
+		{"um membro só", 99, []int64{150}, []int64{99}},
+		{"custo zero", 0, []int64{100, 200}, []int64{0, 0}},
+	})
+}
+
+func testSplit(t *testing.T, name string, split func(int64, []int64) []int64, tests []splitCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := split(tt.cost, tt.available)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s(%d, %v) = %v, quer %v", name, tt.cost, tt.available, got, tt.want)
+			}
+			checkSplitInvariants(t, tt.cost, tt.available, got)
+		})
+	}
+
+	// Invariantes em entradas variadas: a soma é o custo (ou todo o saldo,
+	// se não cobrir) e ninguém paga mais do que tem
+	for cost := int64(0); cost <= 1000; cost += 37 {
+		for _, available := range [][]int64{
+			{1},
+			{0, 7, 13},
+			{333, 333, 334},
+			{1, 2, 3, 4, 5, 6, 7},
+			{999, 1, 0, 250},
+			{50, 50, 50, 50, 50, 50},
+		} {
+			checkSplitInvariants(t, cost, available, split(cost, available))
+		}
+	}
+}
+
+func checkSplitInvariants(t *testing.T, cost int64, available, shares []int64) {
+	t.Helper()
+	if len(shares) != len(available) {
+		t.Fatalf("custo %d, saldos %v: %d partes, quer %d", cost, available, len(shares), len(available))
+	}
+
+	var sum, total int64
+	for i, share := range shares {
+		if share < 0 || share > available[i] {
+			t.Errorf("custo %d, saldos %v: parte %d = %d fora de [0, %d]", cost, available, i, share, available[i])
+		}
+		sum += share
+		total += available[i]
+	}
+	if want := min(cost, total); sum != want {
+		t.Errorf("custo %d, saldos %v: partes somam %d, quer %d", cost, available, sum, want)
+	}
+}
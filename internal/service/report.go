@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+var ErrInvalidReportFormat = i18n.NewError("error.invalid_report_format")
+
+type ReportFormat string
+
+const (
+	ReportCSV  ReportFormat = "csv"
+	ReportOFX  ReportFormat = "ofx"
+	ReportJSON ReportFormat = "json"
+)
+
+func (f ReportFormat) ContentType() string {
+	switch f {
+	case ReportCSV:
+		return "text/csv; charset=utf-8"
+	case ReportOFX:
+		return "application/x-ofx"
+	default:
+		return "application/json"
+	}
+}
+
+func (f ReportFormat) Valid() bool {
+	return f == ReportCSV || f == ReportOFX || f == ReportJSON
+}
+
+type ReportService struct {
+	txRepo *repository.TransactionRepository
+}
+
+func NewReportService(txRepo *repository.TransactionRepository) *ReportService {
+	return &ReportService{txRepo: txRepo}
+}
+
+// reportWriter recebe as linhas uma a uma; nada do relatório fica acumulado
+// em memória além do buffer de escrita
+type reportWriter interface {
+	begin() error
+	row(repository.ReportRow) error
+	end() error
+}
+
+// Export escreve em w as transações do período [from, to) no formato pedido
+func (s *ReportService) Export(w io.Writer, format ReportFormat, from, to time.Time) error {
+	buf := bufio.NewWriter(w)
+
+	var rw reportWriter
+	switch format {
+	case ReportCSV:
+		rw = &csvReportWriter{w: csv.NewWriter(buf)}
+	case ReportJSON:
+		rw = &jsonReportWriter{w: buf}
+	case ReportOFX:
+		rw = &ofxReportWriter{w: buf, from: from, to: to}
+	default:
+		return ErrInvalidReportFormat
+	}
+
+	if err := rw.begin(); err != nil {
+		return err
+	}
+
+	if err := s.txRepo.EachReportRow(from, to, rw.row); err != nil {
+		return fmt.Errorf("erro ao exportar transações: %w", err)
+	}
+
+	if err := rw.end(); err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+type ReportRow struct {
+	TransactionID     int64      `json:"transaction_id"`
+	DiscordID         string     `json:"discord_id"`
+	Username          string     `json:"username"`
+	Type              string     `json:"type"`
+	Status            string     `json:"status"`
+	Amount            float64    `json:"amount"`
+	Description       string     `json:"description,omitempty"`
+	ExternalReference string     `json:"external_reference,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ConfirmedAt       *time.Time `json:"confirmed_at"`
+}
+
+func newReportRow(row repository.ReportRow) ReportRow {
+	return ReportRow{
+		TransactionID:     row.TransactionID,
+		DiscordID:         row.DiscordID,
+		Username:          row.Username,
+		Type:              string(row.Type),
+		Status:            string(row.Status),
+		Amount:            row.Amount,
+		Description:       row.Description,
+		ExternalReference: row.ExternalReference,
+		CreatedAt:         row.CreatedAt,
+		ConfirmedAt:       row.ConfirmedAt,
+	}
+}
+
+type csvReportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvReportWriter) begin() error {
+	return c.w.Write([]string{
+		"transaction_id", "discord_id", "username", "type", "status", "amount",
+		"description", "external_reference", "created_at", "confirmed_at",
+	})
+}
+
+func (c *csvReportWriter) row(row repository.ReportRow) error {
+	confirmedAt := ""
+	if row.ConfirmedAt != nil {
+		confirmedAt = row.ConfirmedAt.Format(time.RFC3339)
+	}
+
+	return c.w.Write([]string{
+		strconv.FormatInt(row.TransactionID, 10),
+		row.DiscordID,
+		row.Username,
+		string(row.Type),
+		string(row.Status),
+		strconv.FormatFloat(row.Amount, 'f', 2, 64),
+		row.Description,
+		row.ExternalReference,
+		row.CreatedAt.Format(time.RFC3339),
+		confirmedAt,
+	})
+}
+
+func (c *csvReportWriter) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonReportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonReportWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonReportWriter) row(row repository.ReportRow) error {
+	data, err := json.Marshal(newReportRow(row))
+	if err != nil {
+		return err
+	}
+
+	sep := "\n"
+	if j.count > 0 {
+		sep = ",\n"
+	}
+	j.count++
+
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonReportWriter) end() error {
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// ofxReportWriter gera um extrato OFX 2.2 com as transações confirmadas.
+// Pendentes e falhas não são lançamentos de extrato e ficam de fora.
+type ofxReportWriter struct {
+	w       io.Writer
+	from    time.Time
+	to      time.Time
+	balance float64
+}
+
+const ofxTimeLayout = "20060102150405"
+
+func (o *ofxReportWriter) begin() error {
+	now := time.Now()
+	start := o.from
+	if start.IsZero() {
+		start = time.Unix(0, 0)
+	}
+
+	_, err := fmt.Fprintf(o.w, `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>POR</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>%d</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>BRL</CURDEF>
+<BANKACCTFROM><BANKID>0000</BANKID><ACCTID>familia-steam</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, now.Format(ofxTimeLayout), now.Unix(), start.Format(ofxTimeLayout), o.periodEnd().Format(ofxTimeLayout))
+	return err
+}
+
+func (o *ofxReportWriter) periodEnd() time.Time {
+	if o.to.IsZero() {
+		return time.Now()
+	}
+	return o.to
+}
+
+func (o *ofxReportWriter) row(row repository.ReportRow) error {
+	if row.Status != repository.StatusConfirmed {
+		return nil
+	}
+
+	posted := row.CreatedAt
+	if row.ConfirmedAt != nil {
+		posted = *row.ConfirmedAt
+	}
+
+	trnType := "CREDIT"
+	if row.Amount < 0 {
+		trnType = "DEBIT"
+	}
+
+	memo := string(row.Type)
+	if row.Description != "" {
+		memo += " - " + row.Description
+	}
+
+	o.balance += row.Amount
+
+	_, err := fmt.Fprintf(o.w,
+		"<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%d</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+		trnType, posted.Format(ofxTimeLayout), strconv.FormatFloat(row.Amount, 'f', 2, 64),
+		row.TransactionID, xmlEscape(row.Username), xmlEscape(memo))
+	return err
+}
+
+func (o *ofxReportWriter) end() error {
+	_, err := fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`, strconv.FormatFloat(o.balance, 'f', 2, 64), o.periodEnd().Format(ofxTimeLayout))
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
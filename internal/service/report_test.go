@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+func reportRows() []repository.ReportRow {
+	confirmed := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	return []repository.ReportRow{
+		{TransactionID: 1, DiscordID: "1", Username: "ana", Type: repository.TypePix, Status: repository.StatusConfirmed, Amount: 20, CreatedAt: confirmed.Add(-time.Minute), ConfirmedAt: &confirmed},
+		{TransactionID: 2, DiscordID: "2", Username: "bia & cia", Type: repository.TypeAdjustment, Status: repository.StatusConfirmed, Amount: -5.5, Description: "estorno <manual>", CreatedAt: confirmed, ConfirmedAt: &confirmed},
+		{TransactionID: 3, DiscordID: "1", Username: "ana", Type: repository.TypePix, Status: repository.StatusPending, Amount: 10, CreatedAt: confirmed},
+	}
+}
+
+func writeReport(t *testing.T, rw reportWriter, rows []repository.ReportRow) {
+	t.Helper()
+	if err := rw.begin(); err != nil {
+		t.Fatalf("begin = %v", err)
+	}
+	for _, row := range rows {
+		if err := rw.row(row); err != nil {
+			t.Fatalf("row = %v", err)
+		}
+	}
+	if err := rw.end(); err != nil {
+		t.Fatalf("end = %v", err)
+	}
+}
+
+func TestCSVReport(t *testing.T) {
+	var buf bytes.Buffer
+	writeReport(t, &csvReportWriter{w: csv.NewWriter(&buf)}, reportRows())
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("%d linhas, quer cabeçalho + 3", len(records))
+	}
+	if got := strings.Join(records[2], ","); got != "2,2,bia & cia,ADJUSTMENT,CONFIRMED,-5.50,estorno <manual>,,2026-01-02T10:00:00Z,2026-01-02T10:00:00Z" {
+		t.Errorf("linha do ajuste = %q", got)
+	}
+	if got := records[3][9]; got != "" {
+		t.Errorf("confirmed_at do pendente = %q, quer vazio", got)
+	}
+}
+
+func TestJSONReport(t *testing.T) {
+	for _, rows := range [][]repository.ReportRow{nil, reportRows()} {
+		var buf bytes.Buffer
+		writeReport(t, &jsonReportWriter{w: &buf}, rows)
+
+		var got []ReportRow
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("JSON inválido com %d linhas: %v\n%s", len(rows), err, buf.String())
+		}
+		if len(got) != len(rows) {
+			t.Errorf("%d linhas, quer %d", len(got), len(rows))
+		}
+	}
+}
+
+func TestOFXReportListsOnlyConfirmed(t *testing.T) {
+	var buf bytes.Buffer
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	writeReport(t, &ofxReportWriter{w: &buf, to: to}, reportRows())
+	out := buf.String()
+
+	if n := strings.Count(out, "<STMTTRN>"); n != 2 {
+		t.Errorf("%d lançamentos, quer 2 (o pendente fica de fora)", n)
+	}
+	for _, want := range []string{
+		"<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20260102100000</DTPOSTED><TRNAMT>-5.50</TRNAMT><FITID>2</FITID>",
+		"<NAME>bia &amp; cia</NAME><MEMO>ADJUSTMENT - estorno &lt;manual&gt;</MEMO>",
+		"<BALAMT>14.50</BALAMT><DTASOF>20260201000000</DTASOF>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("OFX sem %q", want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+var (
+	ErrInvalidDueDay        = i18n.NewError("error.invalid_due_day")
+	ErrSubscriptionNotFound = i18n.NewError("error.subscription_not_found")
+)
+
+// O Brasil não tem mais horário de verão, então um fuso fixo basta para
+// decidir em que dia/mês estamos
+var brasilia = time.FixedZone("BRT", -3*60*60)
+
+const (
+	defaultReminderInterval  = 72 * time.Hour
+	defaultSchedulerInterval = time.Hour
+
+	// chargeLease é quanto uma cobrança sem PIX, ou com um PIX a trocar,
+	// espera desde o último aviso antes de outro processo retomá-la
+	chargeLease = 10 * time.Minute
+)
+
+// Notifier entrega mensagens privadas aos membros e anúncios nos canais, e
+// atualiza a mensagem do QR Code de um PIX. É implementado pelo bot, mas o
+// serviço não depende do Discord diretamente.
+type Notifier interface {
+	SendDirectMessage(discordID, message string) error
+	SendPixCharge(discordID, message string, payment *CreatePixPaymentResponse) error
+	SendChannelMessage(channelID, message string) error
+	UpdatePixMessage(message repository.DiscordMessage, update PixMessageUpdate) error
+}
+
+// PixMessageUpdate é o novo estado da mensagem do QR Code de um PIX
+type PixMessageUpdate struct {
+	TransactionID int64
+	Amount        float64
+	Status        repository.TransactionStatus
+	Locale        i18n.Locale
+}
+
+type SubscriptionService struct {
+	subRepo          *repository.SubscriptionRepository
+	userRepo         *repository.UserRepository
+	paymentService   *PaymentService
+	notifier         Notifier
+	locales          *LocaleService
+	reminderInterval time.Duration
+}
+
+func NewSubscriptionService(
+	subRepo *repository.SubscriptionRepository,
+	userRepo *repository.UserRepository,
+	paymentService *PaymentService,
+	notifier Notifier,
+	locales *LocaleService,
+) *SubscriptionService {
+	return &SubscriptionService{
+		subRepo:          subRepo,
+		userRepo:         userRepo,
+		paymentService:   paymentService,
+		notifier:         notifier,
+		locales:          locales,
+		reminderInterval: defaultReminderInterval,
+	}
+}
+
+func (s *SubscriptionService) Subscribe(discordID, username string, amount float64, dueDay int) (*repository.Subscription, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if dueDay < 1 || dueDay > 31 {
+		return nil, ErrInvalidDueDay
+	}
+
+	user, err := s.userRepo.FindOrCreate(discordID, username)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar/criar usuário: %w", err)
+	}
+
+	sub, err := s.subRepo.Upsert(user.ID, amount, dueDay)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar mensalidade: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (s *SubscriptionService) Cancel(discordID string) error {
+	user, err := s.userRepo.FindByDiscordID(discordID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return ErrSubscriptionNotFound
+	}
+
+	cancelled, err := s.subRepo.Deactivate(user.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao cancelar mensalidade: %w", err)
+	}
+	if !cancelled {
+		return ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+type SubscriptionStatusEntry struct {
+	DiscordID string
+	Username  string
+	Amount    float64
+	DueDay    int
+	Paid      float64
+	UpToDate  bool
+	Overdue   bool
+}
+
+type SubscriptionStatusResponse struct {
+	Period  string
+	Members []SubscriptionStatusEntry
+}
+
+// Status mostra, para o mês de `now`, quem já contribuiu o valor combinado
+func (s *SubscriptionService) Status(now time.Time) (*SubscriptionStatusResponse, error) {
+	period, statuses, err := s.periodStatus(now)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SubscriptionStatusResponse{Period: period, Members: []SubscriptionStatusEntry{}}
+	for _, st := range statuses {
+		upToDate := isPaid(st)
+		response.Members = append(response.Members, SubscriptionStatusEntry{
+			DiscordID: st.DiscordID,
+			Username:  st.Username,
+			Amount:    st.Amount,
+			DueDay:    st.DueDay,
+			Paid:      st.Paid,
+			UpToDate:  upToDate,
+			Overdue:   !upToDate && isDue(st.DueDay, now),
+		})
+	}
+
+	return response, nil
+}
+
+func (s *SubscriptionService) periodStatus(now time.Time) (string, []repository.SubscriptionStatus, error) {
+	local := now.In(brasilia)
+	start := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, brasilia)
+	end := start.AddDate(0, 1, 0)
+
+	statuses, err := s.subRepo.ListStatus(start.UTC(), end.UTC())
+	if err != nil {
+		return "", nil, fmt.Errorf("erro ao buscar mensalidades: %w", err)
+	}
+
+	return start.Format("2006-01"), statuses, nil
+}
+
+func isPaid(st repository.SubscriptionStatus) bool {
+	return st.Paid+0.005 >= st.Amount
+}
+
+// isDue indica se o vencimento do mês já chegou. Dias que não existem no mês
+// (ex: 31 em fevereiro) vencem no último dia.
+func isDue(dueDay int, now time.Time) bool {
+	local := now.In(brasilia)
+	lastDay := time.Date(local.Year(), local.Month()+1, 0, 0, 0, 0, 0, brasilia).Day()
+	if dueDay > lastDay {
+		dueDay = lastDay
+	}
+	return local.Day() >= dueDay
+}
+
+// RunDue gera a cobrança PIX das mensalidades vencidas no mês e reenvia
+// lembretes das que continuam em aberto
+func (s *SubscriptionService) RunDue(now time.Time) error {
+	period, statuses, err := s.periodStatus(now)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range statuses {
+		if isPaid(st) || !isDue(st.DueDay, now) {
+			continue
+		}
+
+		if err := s.chargeOrRemind(st, period, now); err != nil {
+			log.Printf("Erro na mensalidade de %s (%s): %v", st.Username, period, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SubscriptionService) chargeOrRemind(st repository.SubscriptionStatus, period string, now time.Time) error {
+	charge, claimed, err := s.subRepo.ClaimCharge(st.ID, period)
+	if err != nil {
+		return err
+	}
+	if claimed {
+		return s.charge(st, period, charge, "")
+	}
+
+	// Uma cobrança sem transação ficou pela metade numa execução anterior
+	if charge.TransactionID == 0 {
+		if claimed, err = s.subRepo.ClaimStaleCharge(charge.ID, chargeLease); err != nil || !claimed {
+			return err
+		}
+		return s.charge(st, period, charge, "")
+	}
+
+	// QR Code vencido ou PIX recusado não serve mais de lembrete: gera outro
+	transaction, err := s.paymentService.FindTransaction(charge.TransactionID)
+	if err != nil {
+		return err
+	}
+	if transaction != nil && (transaction.Status == repository.StatusExpired || transaction.Status == repository.StatusFailed) {
+		if claimed, err = s.subRepo.ClaimRecharge(charge.ID, charge.TransactionID, chargeLease); err != nil || !claimed {
+			return err
+		}
+		return s.charge(st, period, charge, fmt.Sprintf("-%d", charge.TransactionID))
+	}
+
+	if claimed, err = s.subRepo.ClaimReminder(charge.ID, s.reminderInterval); err != nil || !claimed {
+		return err
+	}
+
+	// O valor do comando fica com ponto decimal, que é o que o !pix aceita
+	message := i18n.T(s.locales.ForUser(st.DiscordID), "dm.subscription.reminder",
+		period, i18n.Money(st.Amount-st.Paid), charge.TransactionID, fmt.Sprintf("%.2f", st.Amount-st.Paid))
+	return s.notify(st.DiscordID, func() error {
+		return s.notifier.SendDirectMessage(st.DiscordID, message)
+	})
+}
+
+// charge gera o PIX da cobrança e o envia ao membro. Só chama quem
+// reivindicou a cobrança; a chave de idempotência garante um único PIX por
+// mês, valor e tentativa (retry distingue o PIX que substitui um vencido)
+func (s *SubscriptionService) charge(st repository.SubscriptionStatus, period string, charge *repository.SubscriptionCharge, retry string) error {
+	amount := st.Amount - st.Paid
+	payment, err := s.paymentService.CreatePixPayment(context.Background(), CreatePixPaymentRequest{
+		DiscordID:      st.DiscordID,
+		Username:       st.Username,
+		Amount:         amount,
+		IdempotencyKey: fmt.Sprintf("mensalidade-%d-%s-%.0f%s", st.ID, period, amount*100, retry),
+		SkipLimits:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao gerar cobrança: %w", err)
+	}
+
+	if err := s.subRepo.AttachTransaction(charge.ID, payment.TransactionID); err != nil {
+		return err
+	}
+
+	message := i18n.T(s.locales.ForUser(st.DiscordID), "dm.subscription.charge",
+		period, i18n.Money(payment.Amount), payment.TransactionID)
+	return s.notify(st.DiscordID, func() error {
+		return s.notifier.SendPixCharge(st.DiscordID, message, payment)
+	})
+}
+
+func (s *SubscriptionService) notify(discordID string, send func() error) error {
+	if s.notifier == nil {
+		log.Printf("Sem notificador configurado; mensagem para %s não enviada", discordID)
+		return nil
+	}
+	if err := send(); err != nil {
+		return fmt.Errorf("erro ao enviar mensagem privada: %w", err)
+	}
+	return nil
+}
+
+// StartScheduler roda RunDue periodicamente até o contexto ser cancelado
+func (s *SubscriptionService) StartScheduler(ctx context.Context) {
+	ticker := time.NewTicker(defaultSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunDue(time.Now()); err != nil {
+			log.Printf("Erro ao processar mensalidades: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+func TestIsDue(t *testing.T) {
+	tests := []struct {
+		name   string
+		dueDay int
+		now    time.Time
+		want   bool
+	}{
+		{"antes do vencimento", 10, time.Date(2026, 3, 9, 12, 0, 0, 0, brasilia), false},
+		{"no dia do vencimento", 10, time.Date(2026, 3, 10, 0, 0, 0, 0, brasilia), true},
+		{"depois do vencimento", 10, time.Date(2026, 3, 20, 12, 0, 0, 0, brasilia), true},
+		{"dia 31 vence no fim de fevereiro", 31, time.Date(2026, 2, 28, 12, 0, 0, 0, brasilia), true},
+		{"dia 31 ainda não venceu em 30 de março", 31, time.Date(2026, 3, 30, 12, 0, 0, 0, brasilia), false},
+		// 01:00 UTC do dia 10 ainda é dia 9 em Brasília
+		{"usa o horário de Brasília", 10, time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDue(tt.dueDay, tt.now); got != tt.want {
+				t.Errorf("isDue(%d, %v) = %v, quer %v", tt.dueDay, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPaid(t *testing.T) {
+	tests := []struct {
+		amount, paid float64
+		want         bool
+	}{
+		{20, 20, true},
+		{20, 25, true},
+		{0.3, 0.1 + 0.2, true},
+		{20, 19.99, false},
+		{20, 0, false},
+	}
+
+	for _, tt := range tests {
+		st := repository.SubscriptionStatus{Subscription: repository.Subscription{Amount: tt.amount}, Paid: tt.paid}
+		if got := isPaid(st); got != tt.want {
+			t.Errorf("isPaid(valor %v, pago %v) = %v, quer %v", tt.amount, tt.paid, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+var (
+	ErrTransferSameUser    = i18n.NewError("error.transfer_same_user")
+	ErrInsufficientBalance = i18n.NewError("error.insufficient_balance")
+)
+
+type TransferService struct {
+	userRepo   *repository.UserRepository
+	walletRepo *repository.WalletRepository
+	txRepo     *repository.TransactionRepository
+	notifier   Notifier
+	locales    *LocaleService
+}
+
+func NewTransferService(
+	userRepo *repository.UserRepository,
+	walletRepo *repository.WalletRepository,
+	txRepo *repository.TransactionRepository,
+	notifier Notifier,
+	locales *LocaleService,
+) *TransferService {
+	return &TransferService{
+		userRepo:   userRepo,
+		walletRepo: walletRepo,
+		txRepo:     txRepo,
+		notifier:   notifier,
+		locales:    locales,
+	}
+}
+
+type TransferRequest struct {
+	FromDiscordID  string
+	FromUsername   string
+	ToDiscordID    string
+	ToUsername     string
+	Amount         float64
+	IdempotencyKey string
+}
+
+type TransferResponse struct {
+	DebitTransactionID  int64
+	CreditTransactionID int64
+	Amount              float64
+	Replayed            bool
+}
+
+// Transfer move saldo do remetente para o destinatário ("paga pra mim que
+// eu te devolvo"). O destinatário é criado se ainda não usou o bot.
+func (s *TransferService) Transfer(req TransferRequest, meta repository.AuditMeta) (*TransferResponse, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if req.FromDiscordID == req.ToDiscordID {
+		return nil, ErrTransferSameUser
+	}
+
+	if req.IdempotencyKey != "" {
+		replayed, err := s.replayTransfer(req)
+		if err != nil || replayed != nil {
+			return replayed, err
+		}
+	}
+
+	from, err := s.userRepo.FindByDiscordID(req.FromDiscordID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if from == nil {
+		return nil, ErrInsufficientBalance
+	}
+
+	to, err := s.userRepo.FindOrCreate(req.ToDiscordID, req.ToUsername)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar/criar usuário: %w", err)
+	}
+
+	fromWallet, err := s.walletRepo.FindOrCreate(from.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar/criar carteira: %w", err)
+	}
+	toWallet, err := s.walletRepo.FindOrCreate(to.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar/criar carteira: %w", err)
+	}
+
+	debit, credit, err := s.txRepo.Transfer(
+		fromWallet.ID, toWallet.ID, req.Amount,
+		"Transferência para "+to.Username,
+		"Transferência de "+from.Username,
+		req.IdempotencyKey, meta,
+	)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		return nil, ErrInsufficientBalance
+	}
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		// Outra requisição com a mesma chave terminou primeiro
+		replayed, replayErr := s.replayTransfer(req)
+		if replayErr != nil || replayed != nil {
+			return replayed, replayErr
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao transferir: %w", err)
+	}
+
+	s.notifyTransfer(from, to, req.Amount, debit.ID)
+
+	return &TransferResponse{
+		DebitTransactionID:  debit.ID,
+		CreditTransactionID: credit.ID,
+		Amount:              req.Amount,
+	}, nil
+}
+
+func (s *TransferService) replayTransfer(req TransferRequest) (*TransferResponse, error) {
+	debit, err := s.txRepo.FindByIdempotencyKey(req.IdempotencyKey)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+	if debit == nil {
+		return nil, nil
+	}
+
+	credit, err := s.txRepo.FindByIdempotencyKey(req.IdempotencyKey + ":credito")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+	if debit.Type != repository.TypeTransfer || credit == nil || toCents(-debit.Amount) != toCents(req.Amount) {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	wallet, err := s.walletRepo.FindByID(debit.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar carteira: %w", err)
+	}
+	from, err := s.userRepo.FindByDiscordID(req.FromDiscordID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if wallet == nil || from == nil || wallet.UserID != from.ID {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	return &TransferResponse{
+		DebitTransactionID:  debit.ID,
+		CreditTransactionID: credit.ID,
+		Amount:              credit.Amount,
+		Replayed:            true,
+	}, nil
+}
+
+// notifyTransfer avisa os dois lados por mensagem privada. A transferência
+// já foi gravada, então uma falha aqui só é registrada no log.
+func (s *TransferService) notifyTransfer(from, to *repository.User, amount float64, transactionID int64) {
+	if s.notifier == nil {
+		return
+	}
+
+	messages := map[string]string{
+		from.DiscordID: i18n.T(s.locales.ForUser(from.DiscordID), "dm.transfer.sent", i18n.Money(amount), to.Username, transactionID),
+		to.DiscordID:   i18n.T(s.locales.ForUser(to.DiscordID), "dm.transfer.received", from.Username, i18n.Money(amount), transactionID),
+	}
+	for discordID, message := range messages {
+		if err := s.notifier.SendDirectMessage(discordID, message); err != nil {
+			log.Printf("Erro ao avisar %s sobre transferência: %v", discordID, err)
+		}
+	}
+}
@@ -1,26 +1,44 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/mateus/familia-steam/internal/i18n"
 	"github.com/mateus/familia-steam/internal/repository"
 )
 
 type WalletService struct {
-	userRepo   *repository.UserRepository
-	walletRepo *repository.WalletRepository
+	userRepo     *repository.UserRepository
+	walletRepo   *repository.WalletRepository
+	txRepo       *repository.TransactionRepository
+	purchaseRepo *repository.PurchaseRepository
 }
 
 func NewWalletService(
 	userRepo *repository.UserRepository,
 	walletRepo *repository.WalletRepository,
+	txRepo *repository.TransactionRepository,
+	purchaseRepo *repository.PurchaseRepository,
 ) *WalletService {
 	return &WalletService{
-		userRepo:   userRepo,
-		walletRepo: walletRepo,
+		userRepo:     userRepo,
+		walletRepo:   walletRepo,
+		txRepo:       txRepo,
+		purchaseRepo: purchaseRepo,
 	}
 }
 
+var (
+	ErrUserNotFound        = i18n.NewError("error.user_not_found")
+	ErrReasonRequired      = i18n.NewError("error.reason_required")
+	ErrInvalidAmount       = i18n.NewError("error.invalid_amount")
+	ErrMergeSameUser       = i18n.NewError("error.merge_same_user")
+	ErrDebitExceedsBalance = i18n.NewError("error.debit_exceeds_balance")
+)
+
 func (s *WalletService) GetUserBalance(discordID string) (float64, error) {
 	user, err := s.userRepo.FindByDiscordID(discordID)
 	if err != nil {
@@ -46,18 +64,240 @@ func (s *WalletService) GetUserBalance(discordID string) (float64, error) {
 	return balance, nil
 }
 
-func (s *WalletService) GetTotalBalance() (float64, error) {
-	balance, err := s.walletRepo.GetTotalBalance()
+// BalanceSummary mostra o total contribuído pelo membro, quanto dele já foi
+// usado em compras e o que ainda está disponível
+type BalanceSummary struct {
+	Contributed float64
+	Spent       float64
+	Available   float64
+}
+
+func (s *WalletService) GetUserSummary(discordID string) (*BalanceSummary, error) {
+	user, err := s.userRepo.FindByDiscordID(discordID)
 	if err != nil {
-		return 0, fmt.Errorf("erro ao calcular saldo total: %w", err)
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
 	}
-	return balance, nil
+	if user == nil {
+		return &BalanceSummary{}, nil
+	}
+
+	wallet, err := s.walletRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar carteira: %w", err)
+	}
+	if wallet == nil {
+		return &BalanceSummary{}, nil
+	}
+
+	balance, err := s.purchaseRepo.GetAvailableBalance(wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular saldo disponível: %w", err)
+	}
+	if balance == nil {
+		return &BalanceSummary{}, nil
+	}
+
+	return &BalanceSummary{
+		Contributed: balance.Contributed,
+		Spent:       balance.Spent,
+		Available:   balance.Available(),
+	}, nil
+}
+
+type TotalResponse struct {
+	Raised       float64
+	Spent        float64
+	Available    float64
+	Pending      float64
+	Contributors int
 }
 
-func (s *WalletService) GetRanking(limit int) ([]repository.RankingEntry, error) {
-	ranking, err := s.walletRepo.GetRanking(limit)
+func (s *WalletService) GetTotals() (*TotalResponse, error) {
+	totals, err := s.walletRepo.GetTotals()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular saldo total: %w", err)
+	}
+
+	return &TotalResponse{
+		Raised:       totals.Raised,
+		Spent:        totals.Spent,
+		Available:    totals.Raised - totals.Spent,
+		Pending:      totals.Pending,
+		Contributors: totals.Contributors,
+	}, nil
+}
+
+type RankingPeriod string
+
+const (
+	RankingAllTime    RankingPeriod = "sempre"
+	RankingMonth      RankingPeriod = "mes"
+	RankingLast30Days RankingPeriod = "30d"
+	RankingYear       RankingPeriod = "ano"
+	RankingCustom     RankingPeriod = "personalizado"
+)
+
+var ErrInvalidRankingPeriod = i18n.NewError("error.invalid_ranking_period")
+
+func ParseRankingPeriod(s string) (RankingPeriod, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "sempre", "all":
+		return RankingAllTime, nil
+	case "mes", "mês", "month":
+		return RankingMonth, nil
+	case "30d":
+		return RankingLast30Days, nil
+	case "ano", "year":
+		return RankingYear, nil
+	default:
+		return "", ErrInvalidRankingPeriod
+	}
+}
+
+// RankingQuery escolhe o período pelo nome ou, se From/To vierem
+// preenchidos, pelo intervalo [From, To)
+type RankingQuery struct {
+	Period    RankingPeriod
+	From      time.Time
+	To        time.Time
+	Limit     int
+	DiscordID string
+}
+
+type RankingEntry struct {
+	Rank      int
+	DiscordID string
+	Username  string
+	Balance   float64
+}
+
+type RankingResponse struct {
+	Period  RankingPeriod
+	From    *time.Time
+	To      *time.Time
+	Entries []RankingEntry
+	Caller  *RankingEntry
+}
+
+func (s *WalletService) GetRanking(q RankingQuery, now time.Time) (*RankingResponse, error) {
+	from, to := q.From, q.To
+	period := q.Period
+	if !from.IsZero() || !to.IsZero() {
+		period = RankingCustom
+	} else {
+		from, to = rankingWindow(period, now)
+	}
+
+	ranking, err := s.walletRepo.GetRanking(repository.RankingFilter{
+		From:      from,
+		To:        to,
+		Limit:     q.Limit,
+		DiscordID: q.DiscordID,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar ranking: %w", err)
 	}
-	return ranking, nil
+
+	response := &RankingResponse{Period: period, Entries: []RankingEntry{}}
+	if !from.IsZero() {
+		response.From = &from
+	}
+	if !to.IsZero() {
+		response.To = &to
+	}
+	for _, e := range ranking.Entries {
+		response.Entries = append(response.Entries, RankingEntry(e))
+	}
+	if ranking.Caller != nil {
+		caller := RankingEntry(*ranking.Caller)
+		response.Caller = &caller
+	}
+
+	return response, nil
+}
+
+// rankingWindow calcula o intervalo no horário de Brasília; "sempre" não
+// tem limites
+func rankingWindow(period RankingPeriod, now time.Time) (time.Time, time.Time) {
+	local := now.In(brasilia)
+	switch period {
+	case RankingMonth:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, brasilia).UTC(), time.Time{}
+	case RankingLast30Days:
+		return now.AddDate(0, 0, -30).UTC(), time.Time{}
+	case RankingYear:
+		return time.Date(local.Year(), 1, 1, 0, 0, 0, 0, brasilia).UTC(), time.Time{}
+	default:
+		return time.Time{}, time.Time{}
+	}
+}
+
+func (s *WalletService) ListBalances() ([]repository.UserBalance, error) {
+	balances, err := s.walletRepo.ListBalances()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar saldos: %w", err)
+	}
+	return balances, nil
+}
+
+// AdjustBalance credita (amount > 0) ou debita (amount < 0) manualmente a
+// carteira do usuário. O motivo fica registrado na transação; um débito
+// maior que o saldo disponível devolve ErrDebitExceedsBalance.
+func (s *WalletService) AdjustBalance(discordID string, amount float64, reason string, meta repository.AuditMeta) (*repository.Transaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+	if amount == 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	user, err := s.userRepo.FindByDiscordID(discordID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	wallet, err := s.walletRepo.FindOrCreate(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar/criar carteira: %w", err)
+	}
+
+	transaction, err := s.txRepo.CreateAdjustment(wallet.ID, amount, reason, meta)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		return nil, ErrDebitExceedsBalance
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar ajuste: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// MergeUsers junta um usuário duplicado (fromDiscordID) ao usuário que deve
+// permanecer (intoDiscordID), levando junto todas as transações
+func (s *WalletService) MergeUsers(fromDiscordID, intoDiscordID string, meta repository.AuditMeta) error {
+	if fromDiscordID == intoDiscordID {
+		return ErrMergeSameUser
+	}
+
+	from, err := s.userRepo.FindByDiscordID(fromDiscordID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	into, err := s.userRepo.FindByDiscordID(intoDiscordID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if from == nil || into == nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.userRepo.Merge(from.ID, into.ID, meta); err != nil {
+		return fmt.Errorf("erro ao mesclar usuários: %w", err)
+	}
+
+	return nil
 }
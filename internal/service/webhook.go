@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mateus/familia-steam/internal/i18n"
+	"github.com/mateus/familia-steam/internal/repository"
+)
+
+const (
+	webhookPollInterval = 2 * time.Second
+	webhookBatchSize    = 20
+	webhookLease        = 2 * time.Minute
+	webhookMaxAttempts  = 10
+	webhookBaseBackoff  = 15 * time.Second
+	webhookMaxBackoff   = time.Hour
+
+	// Um pagamento que a vaquinha não criou pode ser só um webhook que
+	// chegou antes da transação; depois de tantas tentativas (uns 4 minutos)
+	// ele é ignorado
+	webhookUnknownAttempts = 5
+
+	// Entradas processadas ficam no inbox por webhookRetention para consulta
+	webhookRetention     = 30 * 24 * time.Hour
+	webhookPruneInterval = time.Hour
+
+	ProviderMercadoPago = "mercadopago"
+)
+
+var (
+	ErrWebhookNotFound = i18n.NewError("error.webhook_not_found")
+	ErrInvalidWebhook  = i18n.NewError("error.invalid_webhook")
+)
+
+// storedWebhookHeaders são os cabeçalhos guardados com a entrada, para
+// depuração; os demais são descartados
+var storedWebhookHeaders = []string{"Content-Type", "User-Agent", "X-Request-Id", "X-Signature"}
+
+// webhookNotification é o que o processamento usa do corpo. Só isso é
+// gravado no inbox, então uma requisição qualquer não ocupa mais espaço
+type webhookNotification struct {
+	Action string `json:"action,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Data   struct {
+		ID webhookID `json:"id"`
+	} `json:"data"`
+}
+
+// webhookID aceita o id como texto ou número
+type webhookID string
+
+func (id *webhookID) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		*id = webhookID(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(b, &number); err != nil {
+		return err
+	}
+	*id = webhookID(number.String())
+	return nil
+}
+
+// permanentError marca falhas que não melhoram com novas tentativas, como um
+// corpo que não é JSON; a entrada vai direto para DEAD
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// WebhookService grava cada webhook no inbox assim que ele chega e o
+// processa depois, em segundo plano, com novas tentativas e backoff
+type WebhookService struct {
+	inboxRepo      *repository.WebhookInboxRepository
+	paymentService *PaymentService
+}
+
+func NewWebhookService(
+	inboxRepo *repository.WebhookInboxRepository,
+	paymentService *PaymentService,
+) *WebhookService {
+	return &WebhookService{
+		inboxRepo:      inboxRepo,
+		paymentService: paymentService,
+	}
+}
+
+// Receive grava a notificação com o hash do corpo recebido. Só quando isso
+// falha o provedor deve receber erro (e tentar de novo); um corpo que não é
+// uma notificação devolve ErrInvalidWebhook e não é gravado
+func (s *WebhookService) Receive(provider string, body []byte, headers http.Header) (*repository.InboxEntry, error) {
+	var notification webhookNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, ErrInvalidWebhook
+	}
+	stored, err := json.Marshal(notification)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar webhook: %w", err)
+	}
+
+	kept := make(http.Header)
+	for _, name := range storedWebhookHeaders {
+		if value := headers.Get(name); value != "" {
+			kept.Set(name, value)
+		}
+	}
+
+	hash := sha256.Sum256(body)
+	return s.inboxRepo.Insert(provider, stored, kept, hex.EncodeToString(hash[:]))
+}
+
+// Start processa o inbox periodicamente até o contexto ser cancelado
+func (s *WebhookService) Start(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		if time.Since(lastPrune) >= webhookPruneInterval {
+			if n, err := s.inboxRepo.PruneDone(webhookRetention); err != nil {
+				log.Printf("Erro ao limpar webhooks processados: %v", err)
+			} else if n > 0 {
+				log.Printf("%d webhooks processados removidos do inbox", n)
+			}
+			lastPrune = time.Now()
+		}
+
+		for {
+			n, err := s.ProcessOnce()
+			if err != nil {
+				log.Printf("Erro ao processar webhooks: %v", err)
+			}
+			if n < webhookBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOnce processa um lote do inbox e devolve quantas entradas reservou
+func (s *WebhookService) ProcessOnce() (int, error) {
+	entries, err := s.inboxRepo.Claim(webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if err := s.process(entry); err != nil {
+			var permanent permanentError
+			dead := errors.As(err, &permanent) || entry.Attempts >= webhookMaxAttempts
+			log.Printf("Erro no webhook %d (tentativa %d): %v", entry.ID, entry.Attempts, err)
+			if dead {
+				log.Printf("⚠️ Webhook %d desistido após %d tentativas", entry.ID, entry.Attempts)
+			}
+			if err := s.inboxRepo.MarkFailed(entry.ID, err.Error(), backoff(entry.Attempts, webhookBaseBackoff, webhookMaxBackoff), dead); err != nil {
+				return len(entries), err
+			}
+			continue
+		}
+
+		if err := s.inboxRepo.MarkDone(entry.ID); err != nil {
+			return len(entries), err
+		}
+	}
+
+	return len(entries), nil
+}
+
+func (s *WebhookService) process(entry repository.InboxEntry) error {
+	if entry.Provider != ProviderMercadoPago {
+		return permanentError{fmt.Errorf("provedor desconhecido: %s", entry.Provider)}
+	}
+
+	var webhook webhookNotification
+	if err := json.Unmarshal(entry.Body, &webhook); err != nil {
+		return permanentError{fmt.Errorf("corpo inválido: %w", err)}
+	}
+
+	isPayment := webhook.Action == "payment.updated" || webhook.Action == "payment.created"
+	isChargeback := webhook.Type == "chargebacks"
+	if !isPayment && !isChargeback {
+		return nil
+	}
+	id := string(webhook.Data.ID)
+	if id == "" {
+		return permanentError{fmt.Errorf("webhook %s sem data.id", webhook.Type)}
+	}
+
+	meta := repository.AuditMeta{
+		Actor:       ProviderMercadoPago,
+		Source:      repository.SourceWebhook,
+		PayloadHash: entry.PayloadHash,
+		Reason:      fmt.Sprintf("webhook #%d", entry.ID),
+	}
+
+	// O status vem sempre da consulta ao Mercado Pago, não do corpo do
+	// webhook, que não é assinado
+	if isChargeback {
+		transactions, err := s.paymentService.SyncChargeback(id, meta)
+		if err != nil {
+			return fmt.Errorf("erro ao processar contestação %s: %w", id, err)
+		}
+		for _, transaction := range transactions {
+			log.Printf("⚠️ Contestação %s: transação %d agora %s", id, transaction.ID, transaction.Status)
+		}
+		return nil
+	}
+
+	// O pagamento pode chegar antes de a transação ser gravada; nesse caso
+	// a falha é temporária e a próxima tentativa resolve. Se continuar
+	// desconhecido, não é um PIX da vaquinha
+	transaction, err := s.paymentService.SyncPayment(id, meta)
+	if errors.Is(err, errUnknownPayment) && entry.Attempts >= webhookUnknownAttempts {
+		log.Printf("Webhook %d ignorado: pagamento %s não é da vaquinha", entry.ID, id)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao sincronizar pagamento %s: %w", id, err)
+	}
+
+	log.Printf("Pagamento %s sincronizado: transação %d %s, R$ %.2f", id, transaction.ID, transaction.Status, transaction.Amount)
+	return nil
+}
+
+// ParseInboxFilter aceita all (padrão), stuck, pending, dead ou done
+func ParseInboxFilter(status string) (repository.InboxFilter, error) {
+	switch strings.ToLower(status) {
+	case "", "all":
+		return repository.InboxFilter{}, nil
+	case "stuck":
+		return repository.InboxFilter{Stuck: true}, nil
+	case "pending":
+		return repository.InboxFilter{Status: repository.InboxPending}, nil
+	case "dead":
+		return repository.InboxFilter{Status: repository.InboxDead}, nil
+	case "done":
+		return repository.InboxFilter{Status: repository.InboxDone}, nil
+	}
+	return repository.InboxFilter{}, i18n.NewError("error.invalid_status", status, "all, stuck, pending, dead, done")
+}
+
+func (s *WebhookService) List(filter repository.InboxFilter) ([]repository.InboxEntry, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	return s.inboxRepo.List(filter)
+}
+
+// Replay devolve a entrada para a fila, mesmo já processada. O
+// processamento só alinha a transação ao Mercado Pago, então reprocessar é
+// seguro
+func (s *WebhookService) Replay(id int64) (*repository.InboxEntry, error) {
+	ok, err := s.inboxRepo.Requeue(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	return s.inboxRepo.FindByID(id)
+}
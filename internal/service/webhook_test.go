@@ -0,0 +1,55 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWebhookNotificationKeepsOnlyWhatProcessingUses(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			"pagamento com id em texto",
+			`{"action":"payment.updated","type":"payment","data":{"id":"123"},"user_id":9,"extra":"x"}`,
+			`{"action":"payment.updated","type":"payment","data":{"id":"123"}}`,
+		},
+		{
+			"contestação com id numérico",
+			`{"type":"chargebacks","data":{"id":456,"outro":true}}`,
+			`{"type":"chargebacks","data":{"id":"456"}}`,
+		},
+		{
+			"sem data",
+			`{"padding":"xxxxxxxxxxxxxxxxxxxxxxxx"}`,
+			`{"data":{"id":""}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notification webhookNotification
+			if err := json.Unmarshal([]byte(tt.body), &notification); err != nil {
+				t.Fatalf("Unmarshal = %v", err)
+			}
+			stored, err := json.Marshal(notification)
+			if err != nil {
+				t.Fatalf("Marshal = %v", err)
+			}
+			if string(stored) != tt.want {
+				t.Errorf("gravado %s, quer %s", stored, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookNotificationRejectsInvalidBodies(t *testing.T) {
+	for _, body := range []string{`não é json`, `[1,2]`, `{"data":{"id":{"x":1}}}`} {
+		var notification webhookNotification
+		if err := json.Unmarshal([]byte(body), &notification); err == nil {
+			t.Errorf("Unmarshal(%s) aceitou um corpo inválido", body)
+		}
+	}
+}
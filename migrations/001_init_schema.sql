@@ -7,7 +7,7 @@ CREATE TABLE IF NOT EXISTS users (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE INDEX idx_users_discord_id ON users(discord_id);
+CREATE INDEX IF NOT EXISTS idx_users_discord_id ON users(discord_id);
 
 -- Tabela de carteiras (1 por usuário)
 CREATE TABLE IF NOT EXISTS wallets (
@@ -17,7 +17,7 @@ CREATE TABLE IF NOT EXISTS wallets (
     UNIQUE(user_id)
 );
 
-CREATE INDEX idx_wallets_user_id ON wallets(user_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets(user_id);
 
 -- Tabela de transações
 CREATE TABLE IF NOT EXISTS transactions (
@@ -31,6 +31,6 @@ CREATE TABLE IF NOT EXISTS transactions (
     confirmed_at TIMESTAMP
 );
 
-CREATE INDEX idx_transactions_wallet_id ON transactions(wallet_id);
-CREATE INDEX idx_transactions_status ON transactions(status);
-CREATE INDEX idx_transactions_external_reference ON transactions(external_reference);
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_id ON transactions(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
+CREATE INDEX IF NOT EXISTS idx_transactions_external_reference ON transactions(external_reference);
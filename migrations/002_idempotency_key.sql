@@ -0,0 +1,2 @@
+-- Chave de idempotência enviada pelo bot (derivada da mensagem do Discord)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255) UNIQUE;
@@ -0,0 +1,7 @@
+-- Tipo da transação: PIX (contribuição via Mercado Pago) ou ADJUSTMENT (ajuste manual)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'PIX';
+
+-- Motivo informado em ajustes manuais
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS description TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions(created_at);
@@ -0,0 +1,29 @@
+-- Log de auditoria (append-only) de tudo que altera saldo ou é ação administrativa
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(50) NOT NULL,       -- transaction.status_changed, balance.adjusted, user.merged...
+    actor VARCHAR(255) NOT NULL,       -- Discord ID, "mercadopago", usuário da CLI...
+    source VARCHAR(20) NOT NULL,       -- webhook, poll, admin, api
+    entity_type VARCHAR(50) NOT NULL,  -- transaction, user
+    entity_id BIGINT NOT NULL,
+    before_state JSONB,
+    after_state JSONB,
+    payload_hash VARCHAR(64),          -- SHA-256 do corpo bruto do webhook
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events é append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_no_update ON audit_events;
+CREATE TRIGGER audit_events_no_update
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,21 @@
+-- Compras feitas com o dinheiro da vaquinha
+CREATE TABLE IF NOT EXISTS purchases (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    split_mode VARCHAR(20) NOT NULL, -- PRO_RATA, EQUAL
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Quanto de cada compra saiu do saldo disponível de cada carteira
+CREATE TABLE IF NOT EXISTS purchase_shares (
+    id SERIAL PRIMARY KEY,
+    purchase_id INTEGER NOT NULL REFERENCES purchases(id) ON DELETE CASCADE,
+    wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount >= 0),
+    available_before DECIMAL(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_shares_purchase_id ON purchase_shares(purchase_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_shares_wallet_id ON purchase_shares(wallet_id);
@@ -0,0 +1,18 @@
+-- Outbox: efeitos colaterais (DMs, avisos...) gravados na mesma transação da
+-- alteração que os causou e entregues depois pelo dispatcher
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(100) NOT NULL,            -- transaction.status_changed...
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- próxima tentativa (ou fim do lease)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(available_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_outbox_status ON outbox(status, created_at);
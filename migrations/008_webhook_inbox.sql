@@ -0,0 +1,18 @@
+-- Inbox dos webhooks: cada notificação é gravada como chegou, antes de
+-- qualquer processamento, e um worker a processa com novas tentativas
+CREATE TABLE IF NOT EXISTS webhook_inbox (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,          -- mercadopago
+    body BYTEA NOT NULL,                    -- corpo bruto, sem alteração
+    headers JSONB NOT NULL,                 -- sem Authorization e Cookie
+    payload_hash VARCHAR(64) NOT NULL,      -- SHA-256 do corpo
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- próxima tentativa (ou fim do lease)
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_inbox_pending ON webhook_inbox(available_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_webhook_inbox_received_at ON webhook_inbox(received_at);
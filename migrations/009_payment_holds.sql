@@ -0,0 +1,7 @@
+-- Status de transação: PENDING, CONFIRMED, FAILED, DISPUTED (em mediação/MED),
+-- REVERSED (chargeback) e AWAITING_APPROVAL (pago por usuário sinalizado)
+
+-- Usuário sinalizado por contestação: novos pagamentos dele só entram no
+-- saldo depois de aprovados por um administrador
+ALTER TABLE users ADD COLUMN IF NOT EXISTS payment_hold_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS payment_hold_at TIMESTAMP;
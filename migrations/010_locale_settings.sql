@@ -0,0 +1,8 @@
+-- Idioma escolhido por usuário (!idioma en) ou por servidor do Discord
+CREATE TABLE IF NOT EXISTS locale_settings (
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('user', 'guild')),
+    scope_id VARCHAR(100) NOT NULL,   -- Discord ID do usuário ou do servidor
+    locale VARCHAR(10) NOT NULL,      -- pt-BR, en, es
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (scope, scope_id)
+);
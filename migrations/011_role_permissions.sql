@@ -0,0 +1,13 @@
+-- Capacidades administrativas concedidas a cargos do Discord, por servidor.
+-- Os Discord IDs em ADMIN_DISCORD_IDS têm todas, sem precisar de linha aqui
+CREATE TABLE IF NOT EXISTS role_permissions (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id VARCHAR(100) NOT NULL,
+    role_id VARCHAR(100) NOT NULL,
+    capability VARCHAR(50) NOT NULL,  -- audit, export, purchase, operations, settings, permissions
+    granted_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (guild_id, role_id, capability)
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_permissions_guild ON role_permissions(guild_id);
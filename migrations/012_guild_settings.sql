@@ -0,0 +1,11 @@
+-- Configurações do bot por servidor do Discord. Sem linha valem os padrões:
+-- prefixo "!", todos os canais e nenhum canal de anúncios
+CREATE TABLE IF NOT EXISTS guild_settings (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id VARCHAR(100) NOT NULL UNIQUE,
+    command_prefix VARCHAR(5) NOT NULL DEFAULT '!',
+    allowed_channel_ids TEXT[] NOT NULL DEFAULT '{}',  -- vazio: todos os canais
+    announcement_channel_id VARCHAR(100) NOT NULL DEFAULT '',  -- vazio: sem anúncios
+    updated_by VARCHAR(100) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
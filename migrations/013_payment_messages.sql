@@ -0,0 +1,9 @@
+-- Novo status de transação: EXPIRED, PIX cujo QR Code expirou sem pagamento
+
+-- Mensagem do Discord com o QR Code de um PIX, editada quando o pagamento
+-- muda de status
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discord_guild_id VARCHAR(100);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discord_channel_id VARCHAR(100);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discord_message_id VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_pix ON transactions(created_at) WHERE status = 'PENDING' AND type = 'PIX';
@@ -0,0 +1,3 @@
+-- Handlers que já trataram cada evento do outbox: numa nova tentativa só
+-- rodam os que faltam
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS handled TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+-- Última vez que o polling consultou o PIX no Mercado Pago: cada rodada
+-- começa pelos que estão há mais tempo sem consulta
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS last_polled_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_pix_polled ON transactions(last_polled_at NULLS FIRST, created_at) WHERE status = 'PENDING' AND type = 'PIX';
@@ -0,0 +1,4 @@
+-- O inbox passa a guardar só o que o processamento usa do corpo (action,
+-- type e data.id) e alguns cabeçalhos; payload_hash continua sendo o do corpo
+-- recebido. As entradas processadas são apagadas depois de 30 dias
+CREATE INDEX IF NOT EXISTS idx_webhook_inbox_done ON webhook_inbox(processed_at) WHERE status = 'DONE';